@@ -0,0 +1,420 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+)
+
+var (
+	ErrLoginFailed    = errors.New("auth: login failed")
+	ErrSessionExpired = errors.New("auth: session expired")
+)
+
+// maxSessionRetries is the number of times a URL is requeued after login
+const maxSessionRetries = 2
+
+// Auth holds the credentials sent with the requests of crawlers: static
+// headers, headers of URLs matching a rule, HTTP basic or bearer auth and
+// the cookies of a session opened with a form login.
+//
+// Headers, Basic and BearerToken are only sent to the hosts matching Hosts,
+// by default the hosts of the seed URLs and of the login page, and are
+// removed from redirects to other hosts.
+//
+// Engine.Run calls Start before starting the crawlers; call Start before
+// Crawler.Crawl when crawlers are not run by an Engine.
+type Auth struct {
+	Hosts       []string          `json:"hosts"`        // host rules, as of Scope, Headers and credentials are sent to; hosts of seeds and login when empty
+	Headers     map[string]string `json:"headers"`      // headers sent with every request to Hosts
+	Rules       []HeaderRule      `json:"rules"`        // headers sent with requests to matching URLs
+	Basic       *BasicAuth        `json:"basic"`        // HTTP basic auth; not sent when nil
+	BearerToken string            `json:"bearer_token"` // sent as 'Authorization: Bearer <token>' when not empty
+	CookieFile  string            `json:"cookie_file"`  // file cookies are loaded from and saved to; cookies are kept in memory when empty
+	Login       *FormLogin        `json:"login"`        // form login opening the session; no login when nil
+
+	jar   *cookieJar
+	scope *Scope // hosts Headers and credentials are sent to; set by scopeTo
+
+	mu      sync.Mutex
+	loginAt time.Time // time of the last successful login
+}
+
+// HeaderRule sets Headers on the requests to URLs on Host, or on the
+// hosts credentials are sent to when empty, whose path contains Pattern,
+// when not empty
+type HeaderRule struct {
+	Host    string            `json:"host"`
+	Pattern string            `json:"pattern"`
+	Headers map[string]string `json:"headers"`
+}
+
+// BasicAuth holds the credentials of HTTP basic auth
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// FormLogin logs in by submitting a login form. The login page at URL is
+// fetched and the form is submitted with its hidden fields, Fields and the
+// CSRF token, if any. Login succeeds when the final response, after redirects,
+// has SuccessStatus (any 2xx when 0) and SuccessSelector, when not empty,
+// matches an element of the page.
+//
+// A session expired when a page responds with HTTP 401 or its URL or
+// content matches ExpiryPattern; crawlers log in again and retry the URL.
+type FormLogin struct {
+	URL             string            `json:"url"`              // URL of the login page
+	Form            string            `json:"form"`             // selector of the login form; the first form when empty
+	Fields          map[string]string `json:"fields"`           // values of form fields e.g. username and password
+	CSRFSelector    string            `json:"csrf_selector"`    // selector of the element holding the CSRF token in its value or content attribute
+	CSRFField       string            `json:"csrf_field"`       // form field of the CSRF token; name attribute of the element when empty
+	SuccessSelector string            `json:"success_selector"` // selector of an element only found after login
+	SuccessStatus   int               `json:"success_status"`   // HTTP status after login; any 2xx when 0
+	ExpiryPattern   string            `json:"expiry_pattern"`   // regexp matching the URL or content of pages of an expired session
+
+	loginURL *url.URL
+	expiry   *regexp.Regexp
+}
+
+// Validate checks the fields of a and compiles the patterns of its login
+func (a *Auth) Validate() error {
+	for _, rule := range a.Hosts {
+		if !ValidHostRule(rule) {
+			return fmt.Errorf("invalid host rule '%s'", rule)
+		}
+	}
+	for i, rule := range a.Rules {
+		if len(rule.Headers) == 0 {
+			return fmt.Errorf("rule #%d: headers must be provided", i+1)
+		}
+	}
+	if a.Basic != nil && a.Basic.Username == "" {
+		return errors.New("basic: username must be provided")
+	}
+	if a.Basic != nil && a.BearerToken != "" {
+		return errors.New("basic and bearer_token cannot be used together")
+	}
+	if a.Login == nil {
+		return nil
+	}
+
+	l := a.Login
+	u, err := url.Parse(l.URL)
+	if err != nil || !internal.IsValidScheme(u.Scheme) || u.Host == "" {
+		return fmt.Errorf("login: url %q must be absolute http/https URL", l.URL)
+	}
+	l.loginURL = u
+	if l.Form == "" {
+		l.Form = "form"
+	}
+	if len(l.Fields) == 0 {
+		return errors.New("login: fields must be provided")
+	}
+	if l.SuccessStatus != 0 && (l.SuccessStatus < 100 || l.SuccessStatus > 599) {
+		return fmt.Errorf("login: invalid success_status %d", l.SuccessStatus)
+	}
+	if l.ExpiryPattern != "" {
+		l.expiry, err = regexp.Compile(l.ExpiryPattern)
+		if err != nil {
+			return fmt.Errorf("login: invalid expiry_pattern: %v", err)
+		}
+	}
+	return nil
+}
+
+// Start sets the cookie jar of a on client, loading the cookies of CookieFile,
+// and logs in with client when a has a form login. Returns error wrapping
+// ErrLoginFailed when the login fails.
+func (a *Auth) Start(ctx context.Context, client *http.Client, userAgent string) error {
+	return a.start(ctx, client, &HTTPFetcher{Client: client}, userAgent)
+}
+
+// start sets the cookie jar of a on client and logs in with fetcher
+func (a *Auth) start(ctx context.Context, client *http.Client, fetcher Fetcher, userAgent string) error {
+	if err := a.Validate(); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+	if a.jar == nil {
+		jar, err := newCookieJar(a.CookieFile)
+		if err != nil {
+			return fmt.Errorf("auth: could not load cookies: %w", err)
+		}
+		a.jar = jar
+	}
+	client.Jar = a.jar
+
+	// credentials of a are not forwarded to other hosts on redirect
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		a.authorizeRedirect(req, via[len(via)-1])
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	if a.Login == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.login(ctx, fetcher, userAgent)
+}
+
+// SaveCookies writes the cookies of the session to CookieFile, if set
+func (a *Auth) SaveCookies() error {
+	if a.jar == nil {
+		return nil
+	}
+	return a.jar.save()
+}
+
+// scopeTo sends Headers and credentials of a to the hosts of seeds,
+// and of the login page, when Hosts is empty. Only the first call,
+// before a is used, has effect.
+func (a *Auth) scopeTo(seeds []*url.URL) {
+	if a.scope == nil {
+		a.scope = a.newScope(seeds)
+	}
+}
+
+// newScope returns the Scope of the hosts Headers and credentials of a are sent to
+func (a *Auth) newScope(seeds []*url.URL) *Scope {
+	if len(a.Hosts) > 0 {
+		return &Scope{Hosts: a.Hosts}
+	}
+	s := &Scope{Seeds: slices.Clone(seeds)}
+	if a.Login != nil && a.Login.loginURL != nil {
+		s.Seeds = append(s.Seeds, a.Login.loginURL)
+	}
+	return s
+}
+
+// sendsCredentials tells if Headers and credentials of a are sent to the host of u
+func (a *Auth) sendsCredentials(u *url.URL) bool {
+	scope := a.scope
+	if scope == nil {
+		scope = a.newScope(nil)
+	}
+	return scope.allowsHost(u.Hostname())
+}
+
+// authorize sets the headers and credentials of a on req
+func (a *Auth) authorize(req *http.Request) {
+	send := a.sendsCredentials(req.URL)
+	if send {
+		for k, v := range a.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+	for _, rule := range a.Rules {
+		if rule.Host == "" && !send {
+			continue
+		}
+		if rule.Host != "" && !strings.EqualFold(rule.Host, req.URL.Hostname()) {
+			continue
+		}
+		if !strings.Contains(req.URL.Path, rule.Pattern) {
+			continue
+		}
+		for k, v := range rule.Headers {
+			req.Header.Set(k, v)
+		}
+	}
+	switch {
+	case !send:
+	case a.Basic != nil:
+		req.SetBasicAuth(a.Basic.Username, a.Basic.Password)
+	case a.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+a.BearerToken)
+	}
+}
+
+// authorizeRedirect removes the headers and credentials of a from the
+// redirect req of prev to another host and sets those of its host
+func (a *Auth) authorizeRedirect(req, prev *http.Request) {
+	if strings.EqualFold(req.URL.Host, prev.URL.Host) {
+		return
+	}
+	for _, name := range a.HeaderNames() {
+		req.Header.Del(name)
+	}
+	a.authorize(req)
+}
+
+// HeaderNames returns the names of the headers a sets on requests,
+// e.g. to redact their values
+func (a *Auth) HeaderNames() []string {
+	var names []string
+	for k := range a.Headers {
+		names = append(names, k)
+	}
+	for _, rule := range a.Rules {
+		for k := range rule.Headers {
+			names = append(names, k)
+		}
+	}
+	if a.Basic != nil || a.BearerToken != "" {
+		names = append(names, "Authorization")
+	}
+	return names
+}
+
+// expired tells if resp of a page, with its content when already
+// read, shows that the session of the form login expired
+func (a *Auth) expired(resp *http.Response, content string) bool {
+	if a.Login == nil {
+		return false
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	if a.Login.expiry == nil {
+		return false
+	}
+	return a.Login.expiry.MatchString(resp.Request.URL.String()) ||
+		(content != "" && a.Login.expiry.MatchString(content))
+}
+
+// relogin logs in again unless another crawler did
+// after the request of the expired page started at since
+func (a *Auth) relogin(ctx context.Context, fetcher Fetcher, userAgent string, since time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.loginAt.After(since) {
+		return nil
+	}
+	return a.login(ctx, fetcher, userAgent)
+}
+
+// login submits the login form of a. a.mu must be held.
+func (a *Auth) login(ctx context.Context, fetcher Fetcher, userAgent string) error {
+	l := a.Login
+
+	resp, err := a.do(ctx, fetcher, userAgent, http.MethodGet, l.loginURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("%w: could not get login page: %v", ErrLoginFailed, err)
+	}
+	doc, err := readDocument(resp)
+	if err != nil {
+		return fmt.Errorf("%w: could not read login page: %v", ErrLoginFailed, err)
+	}
+
+	form := doc.Find(l.Form).First()
+	if form.Length() == 0 {
+		return fmt.Errorf("%w: no form matches '%s' on login page", ErrLoginFailed, l.Form)
+	}
+
+	values := url.Values{}
+	form.Find("input[name]").Each(func(i int, s *goquery.Selection) {
+		switch strings.ToLower(s.AttrOr("type", "text")) {
+		case "submit", "button", "image", "file", "reset":
+			return
+		case "checkbox", "radio":
+			if _, checked := s.Attr("checked"); !checked {
+				return
+			}
+		}
+		values.Set(s.AttrOr("name", ""), s.AttrOr("value", ""))
+	})
+	for k, v := range l.Fields {
+		values.Set(k, v)
+	}
+
+	if l.CSRFSelector != "" {
+		el := doc.Find(l.CSRFSelector).First()
+		token, found := el.Attr("value")
+		if !found {
+			token, found = el.Attr("content")
+		}
+		field := l.CSRFField
+		if field == "" {
+			field = el.AttrOr("name", "")
+		}
+		if !found || field == "" {
+			return fmt.Errorf("%w: no CSRF token matches '%s' on login page", ErrLoginFailed, l.CSRFSelector)
+		}
+		values.Set(field, token)
+	}
+
+	// submit to the form action, resolved against the login page
+	action, err := resp.Request.URL.Parse(strings.TrimSpace(form.AttrOr("action", "")))
+	if err != nil {
+		return fmt.Errorf("%w: invalid form action: %v", ErrLoginFailed, err)
+	}
+	method := strings.ToUpper(form.AttrOr("method", http.MethodPost))
+	if method == http.MethodGet {
+		action.RawQuery = values.Encode()
+		resp, err = a.do(ctx, fetcher, userAgent, method, action.String(), nil)
+	} else {
+		resp, err = a.do(ctx, fetcher, userAgent, http.MethodPost, action.String(), values)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: could not submit login form: %v", ErrLoginFailed, err)
+	}
+	doc, err = readDocument(resp)
+	if err != nil {
+		return fmt.Errorf("%w: could not read login response: %v", ErrLoginFailed, err)
+	}
+
+	switch {
+	case l.SuccessStatus != 0 && resp.StatusCode != l.SuccessStatus:
+		return fmt.Errorf("%w: received HTTP status %d, expected %d", ErrLoginFailed, resp.StatusCode, l.SuccessStatus)
+	case l.SuccessStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+		return fmt.Errorf("%w: received HTTP status %d", ErrLoginFailed, resp.StatusCode)
+	case l.SuccessSelector != "" && doc.Find(l.SuccessSelector).Length() == 0:
+		return fmt.Errorf("%w: no element matches '%s' after login", ErrLoginFailed, l.SuccessSelector)
+	}
+
+	a.loginAt = time.Now()
+	if err := a.jar.save(); err != nil {
+		return fmt.Errorf("auth: could not save cookies: %w", err)
+	}
+	return nil
+}
+
+// do sends a request of the form login with fetcher and the headers of a;
+// form values, when not nil, are sent as the url-encoded body
+func (a *Auth) do(
+	ctx context.Context,
+	fetcher Fetcher,
+	userAgent, method, urlStr string,
+	form url.Values,
+) (*http.Response, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	a.authorize(req)
+	return fetcher.Fetch(req)
+}
+
+// readDocument parses and closes the body of resp
+func readDocument(resp *http.Response) (*goquery.Document, error) {
+	defer resp.Body.Close()
+	return goquery.NewDocumentFromReader(resp.Body)
+}
@@ -0,0 +1,134 @@
+package webcrawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAuth(t *testing.T) {
+	t.Run("Hosts", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			auth  *Auth
+			input string
+			want  bool
+		}{
+			{name: "seed host", auth: &Auth{}, input: "https://example.com/a", want: true},
+			{name: "other host", auth: &Auth{}, input: "https://cdn.example.net/a", want: false},
+			{
+				name:  "login host",
+				auth:  &Auth{Login: &FormLogin{URL: "https://sso.example.org/login", Fields: map[string]string{"u": "x"}}},
+				input: "https://sso.example.org/callback",
+				want:  true,
+			},
+			{name: "host rule", auth: &Auth{Hosts: []string{"*.example.net"}}, input: "https://cdn.example.net/a", want: true},
+			{name: "seed host not in rules", auth: &Auth{Hosts: []string{"*.example.net"}}, input: "https://example.com/a", want: false},
+		}
+
+		for _, test := range tests {
+			test.auth.Headers = map[string]string{"X-Api-Key": "secret"}
+			test.auth.BearerToken = "token"
+			if err := test.auth.Validate(); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			test.auth.scopeTo([]*url.URL{mustParseURL(t, "https://example.com")})
+
+			req, err := http.NewRequest(http.MethodGet, test.input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.auth.authorize(req)
+			gotKey := req.Header.Get("X-Api-Key") == "secret"
+			gotToken := req.Header.Get("Authorization") == "Bearer token"
+			if gotKey != test.want || gotToken != test.want {
+				t.Errorf("%s: got header %t, token %t, want %t", test.name, gotKey, gotToken, test.want)
+			}
+		}
+
+		if err := (&Auth{Hosts: []string{"example.com/docs"}}).Validate(); err == nil {
+			t.Error("expected error for an invalid host rule")
+		}
+	})
+
+	t.Run("Rules", func(t *testing.T) {
+		auth := &Auth{Rules: []HeaderRule{
+			{Host: "docs.example.com", Pattern: "/internal/", Headers: map[string]string{"X-Team": "ops"}},
+			{Pattern: "/admin/", Headers: map[string]string{"X-Role": "admin"}},
+		}}
+		auth.scopeTo([]*url.URL{mustParseURL(t, "https://example.com")})
+
+		tests := []struct {
+			input    string
+			wantTeam bool
+			wantRole bool
+		}{
+			{input: "https://docs.example.com/internal/a", wantTeam: true},
+			{input: "https://docs.example.com/public/a"},
+			{input: "https://example.com/internal/a"},
+			{input: "https://example.com/admin/a", wantRole: true},
+			// host-less rules follow the hosts of credentials
+			{input: "https://cdn.example.net/admin/a"},
+		}
+		for _, test := range tests {
+			req, err := http.NewRequest(http.MethodGet, test.input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			auth.authorize(req)
+			gotTeam := req.Header.Get("X-Team") == "ops"
+			gotRole := req.Header.Get("X-Role") == "admin"
+			if gotTeam != test.wantTeam || gotRole != test.wantRole {
+				t.Errorf("input: %s, got rule headers %t, %t, want %t, %t", test.input, gotTeam, gotRole, test.wantTeam, test.wantRole)
+			}
+		}
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		received := make(chan http.Header, 1)
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- r.Header.Clone()
+		}))
+		defer other.Close()
+		// same server under another host name
+		otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+		seed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, otherURL+"/landing", http.StatusFound)
+		}))
+		defer seed.Close()
+
+		auth := &Auth{
+			Headers: map[string]string{"X-Api-Key": "secret"},
+			Rules:   []HeaderRule{{Headers: map[string]string{"X-Team": "ops"}}},
+			Basic:   &BasicAuth{Username: "crawler", Password: "secret"},
+		}
+		auth.scopeTo([]*url.URL{mustParseURL(t, seed.URL)})
+		client := &http.Client{}
+		if err := auth.Start(context.Background(), client, "webcrawlerGo"); err != nil {
+			t.Fatal(err)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, seed.URL+"/start", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		auth.authorize(req)
+		if req.Header.Get("X-Api-Key") == "" || req.Header.Get("X-Team") == "" || req.Header.Get("Authorization") == "" {
+			t.Fatal("expected credentials on the request to the seed host")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		header := <-received
+		if header.Get("X-Api-Key") != "" || header.Get("X-Team") != "" || header.Get("Authorization") != "" {
+			t.Errorf("credentials forwarded on redirect to another host: %v", header)
+		}
+	})
+}
@@ -0,0 +1,573 @@
+package webcrawler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNotRecorded is returned by Replayer for requests not in its cassette
+var ErrNotRecorded = errors.New("replay: request not recorded")
+
+// replayMatchHeaders are matched to pick the response of a request
+// when several responses of its URL were recorded e.g. of fetch variants
+var replayMatchHeaders = []string{"User-Agent", "Accept-Language", "Cookie"}
+
+// redactedHeaders are written to cassettes without their values
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization"}
+
+// redactedCookiePrefix prefixes the digests replacing the values of cookies
+const redactedCookiePrefix = "redacted-"
+
+// Recorder is a Fetcher saving the responses fetched by Next to a cassette,
+// which can be served back by Replayer. A cassette is a directory with a
+// JSON file of a HAR entry per request, or a HAR file when its path ends
+// with ".har". The HAR file is written by Close.
+//
+// Failed requests are recorded with their error. Values of Authorization
+// headers and of Redact are redacted. Values of cookies in Cookie and
+// Set-Cookie headers are replaced by their digest, so that requests
+// with the cookies set by replayed responses still match. Cassettes
+// are only readable by their owner.
+type Recorder struct {
+	Next   Fetcher  // Fetcher of the responses to record
+	Redact []string // names of other headers to redact e.g. of Auth.HeaderNames
+
+	path string
+	har  bool
+
+	mu      sync.Mutex
+	entries []*harEntry // entries of the HAR file
+	seq     int         // number of the next file in the cassette directory
+}
+
+// NewRecorder returns pointer to a new Recorder of the responses of next
+// to the cassette at path. The cassette directory is created if missing
+// and new responses are added after the responses already in it.
+func NewRecorder(next Fetcher, path string) (*Recorder, error) {
+	if next == nil {
+		return nil, errors.New("record: fetcher cannot be nil")
+	}
+
+	r := &Recorder{Next: next, path: path, har: isHARFile(path)}
+	if r.har {
+		if _, err := os.Stat(filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("record: %w", err)
+		}
+		return r, nil
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, fmt.Errorf("record: %w", err)
+	}
+	files, err := cassetteFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("record: %w", err)
+	}
+	r.seq = len(files)
+	return r, nil
+}
+
+// Fetch implements Fetcher. The body of the response is read into
+// memory so that it can be recorded. Returns error when the response
+// could not be written to the cassette.
+func (r *Recorder) Fetch(req *http.Request) (*http.Response, error) {
+	// clients add the cookies of their jar to req
+	header := req.Header.Clone()
+	started := time.Now()
+
+	resp, err := r.Next.Fetch(req)
+	if err != nil {
+		entry := newHAREntry(req, header, nil, nil, started, r.redacted())
+		entry.Error = err.Error()
+		if saveErr := r.save(entry); saveErr != nil {
+			return nil, errors.Join(err, saveErr)
+		}
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := r.save(newHAREntry(req, header, resp, body, started, r.redacted())); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// redacted returns the names of the headers redacted by r
+func (r *Recorder) redacted() []string {
+	return slices.Concat(redactedHeaders, r.Redact)
+}
+
+// Close writes the HAR file of r; no-op for cassette directories
+func (r *Recorder) Close() error {
+	if !r.har {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := json.MarshalIndent(harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "webcrawlerGo"},
+		Entries: r.entries,
+	}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("record: %w", err)
+	}
+	if err := os.WriteFile(r.path, b, 0600); err != nil {
+		return fmt.Errorf("record: %w", err)
+	}
+	return nil
+}
+
+// save adds entry to the cassette
+func (r *Recorder) save(entry *harEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.har {
+		r.entries = append(r.entries, entry)
+		return nil
+	}
+
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("record: %w", err)
+	}
+	name := filepath.Join(r.path, fmt.Sprintf("%06d.json", r.seq))
+	if err := os.WriteFile(name, b, 0600); err != nil {
+		return fmt.Errorf("record: %w", err)
+	}
+	r.seq++
+	return nil
+}
+
+// Replayer is a Fetcher serving the responses of a cassette written by
+// Recorder, or of a HAR file e.g. exported by a browser, without network.
+//
+// Requests are matched by method and URL, and by the User-Agent,
+// Accept-Language and Cookie headers when they match a recorded request;
+// cookies match the digests of their values written by Recorder.
+// Responses of a request are served in the order they were recorded and
+// the last one repeatedly. Requests for robots.txt which were not recorded
+// are served HTTP 404; other requests not recorded return error wrapping
+// ErrNotRecorded.
+type Replayer struct {
+	mu      sync.Mutex
+	entries map[string][]*harEntry // keyed by <method> <url>
+	served  map[string]int         // responses served by request key and headers
+}
+
+// NewReplayer returns pointer to a new Replayer of the cassette at path
+func NewReplayer(path string) (*Replayer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+
+	var entries []*harEntry
+	if info.IsDir() {
+		files, err := cassetteFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("replay: %w", err)
+		}
+		for _, name := range files {
+			var entry harEntry
+			if err := readJSONFile(filepath.Join(path, name), &entry); err != nil {
+				return nil, fmt.Errorf("replay: %s: %w", name, err)
+			}
+			entries = append(entries, &entry)
+		}
+	} else {
+		var har harFile
+		if err := readJSONFile(path, &har); err != nil {
+			return nil, fmt.Errorf("replay: %w", err)
+		}
+		entries = har.Log.Entries
+	}
+
+	rp := &Replayer{entries: map[string][]*harEntry{}, served: map[string]int{}}
+	for _, entry := range entries {
+		key := entry.Request.Method + " " + entry.Request.URL
+		rp.entries[key] = append(rp.entries[key], entry)
+	}
+	return rp, nil
+}
+
+// Len returns the number of responses in the cassette
+func (rp *Replayer) Len() int {
+	n := 0
+	for _, entries := range rp.entries {
+		n += len(entries)
+	}
+	return n
+}
+
+// Fetch implements Fetcher
+func (rp *Replayer) Fetch(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	entry := rp.next(req)
+	if entry == nil {
+		if req.URL.Path == "/robots.txt" {
+			return newReplayResponse(req, http.StatusNotFound, http.Header{}, nil), nil
+		}
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL)
+	}
+	return entry.response(req)
+}
+
+// next returns the entry to serve for req; nil when req was not recorded
+func (rp *Replayer) next(req *http.Request) *harEntry {
+	key := req.Method + " " + req.URL.String()
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	entries := rp.entries[key]
+	if len(entries) == 0 {
+		return nil
+	}
+
+	matchKey := headerMatchKey(req.Header)
+	var matched []*harEntry
+	for _, entry := range entries {
+		if entry.Request.matchKey() == matchKey {
+			matched = append(matched, entry)
+		}
+	}
+	if len(matched) == 0 {
+		matched = entries
+		matchKey = ""
+	}
+
+	servedKey := key + "\n" + matchKey
+	i := min(rp.served[servedKey], len(matched)-1)
+	rp.served[servedKey]++
+	return matched[i]
+}
+
+// headerMatchKey returns the values of the replayMatchHeaders of header;
+// cookies are compared by the digest of their values
+func headerMatchKey(header http.Header) string {
+	values := make([]string, len(replayMatchHeaders))
+	for i, name := range replayMatchHeaders {
+		values[i] = strings.Join(header.Values(name), ", ")
+		if name == "Cookie" && values[i] != "" {
+			values[i] = redactCookie(values[i])
+		}
+	}
+	return strings.Join(values, "\n")
+}
+
+// newReplayResponse returns a response to req with status, header and body
+func newReplayResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// isHARFile tells if path is of a HAR file
+func isHARFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".har")
+}
+
+// cassetteFiles returns the sorted names of the JSON files in dir
+func cassetteFiles(dir string) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, de := range dirEntries {
+		if !de.IsDir() && strings.HasSuffix(de.Name(), ".json") {
+			files = append(files, de.Name())
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// readJSONFile decodes the JSON file at path into v
+func readJSONFile(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// HAR 1.2 types; see http://www.softwareishard.com/blog/har-12-spec/
+// Fields of webcrawlerGo are prefixed with '_' as per the spec.
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // milliseconds taken by the request
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	FinalURL        string      `json:"_finalURL,omitempty"` // URL of the response after redirects, when redirected
+	Error           string      `json:"_error,omitempty"`    // error of a failed request
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"` // "base64" when Text is base64 encoded
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// newHAREntry returns the entry of req sent with header at started,
+// and of resp with body, with the values of redacted headers removed;
+// resp is nil for failed requests
+func newHAREntry(
+	req *http.Request,
+	header http.Header,
+	resp *http.Response,
+	body []byte,
+	started time.Time,
+	redacted []string,
+) *harEntry {
+	elapsed := float64(time.Since(started).Microseconds()) / 1000
+	entry := &harEntry{
+		StartedDateTime: started,
+		Time:            elapsed,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(header, redacted),
+			QueryString: harQuery(req.URL.Query()),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: harTimings{Wait: elapsed},
+	}
+	if resp == nil {
+		return entry
+	}
+
+	entry.Response.Status = resp.StatusCode
+	entry.Response.StatusText = http.StatusText(resp.StatusCode)
+	entry.Response.HTTPVersion = resp.Proto
+	entry.Response.Headers = harHeaders(resp.Header, redacted)
+	entry.Response.RedirectURL = resp.Header.Get("Location")
+	entry.Response.BodySize = len(body)
+	entry.Response.Content = harContent{
+		Size:     len(body),
+		MimeType: resp.Header.Get("Content-Type"),
+	}
+	if utf8.Valid(body) {
+		entry.Response.Content.Text = string(body)
+	} else {
+		entry.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+		entry.Response.Content.Encoding = "base64"
+	}
+	if resp.Request != nil && resp.Request.URL.String() != entry.Request.URL {
+		entry.FinalURL = resp.Request.URL.String()
+	}
+	return entry
+}
+
+// response returns the recorded response to req
+func (e *harEntry) response(req *http.Request) (*http.Response, error) {
+	if e.Error != "" {
+		return nil, errors.New(e.Error)
+	}
+
+	body := []byte(e.Response.Content.Text)
+	if e.Response.Content.Encoding == "base64" {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(e.Response.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("replay: invalid content of %s: %w", e.Request.URL, err)
+		}
+	}
+
+	header := http.Header{}
+	for _, h := range e.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	// recorded content is decoded
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+
+	// relative URLs of a redirected page resolve against the final URL
+	if e.FinalURL != "" {
+		finalURL, err := url.Parse(e.FinalURL)
+		if err != nil {
+			return nil, fmt.Errorf("replay: invalid final URL of %s: %w", e.Request.URL, err)
+		}
+		req = req.Clone(req.Context())
+		req.URL = finalURL
+	}
+
+	return newReplayResponse(req, e.Response.Status, header, body), nil
+}
+
+// matchKey returns the values of the replayMatchHeaders of r
+func (r *harRequest) matchKey() string {
+	header := http.Header{}
+	for _, h := range r.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	return headerMatchKey(header)
+}
+
+// harHeaders returns header as HAR name-value pairs, with the values
+// of redacted headers removed and the values of cookies digested
+func harHeaders(header http.Header, redacted []string) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			switch {
+			case slices.ContainsFunc(redacted, func(h string) bool { return strings.EqualFold(h, name) }):
+				value = "REDACTED"
+			case strings.EqualFold(name, "Cookie"):
+				value = redactCookie(value)
+			case strings.EqualFold(name, "Set-Cookie"):
+				value = redactSetCookie(value)
+			}
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	slices.SortStableFunc(pairs, func(a, b harNameValue) int { return strings.Compare(a.Name, b.Name) })
+	return pairs
+}
+
+// redactCookie returns the value of a Cookie header
+// with the value of each cookie replaced by its digest
+func redactCookie(value string) string {
+	cookies := strings.Split(value, ";")
+	for i, cookie := range cookies {
+		cookies[i] = redactCookiePair(strings.TrimSpace(cookie))
+	}
+	return strings.Join(cookies, "; ")
+}
+
+// redactSetCookie returns the value of a Set-Cookie header with
+// the value of its cookie replaced by its digest; attributes are kept
+func redactSetCookie(value string) string {
+	pair, attrs, found := strings.Cut(value, ";")
+	pair = redactCookiePair(strings.TrimSpace(pair))
+	if !found {
+		return pair
+	}
+	return pair + ";" + attrs
+}
+
+// redactCookiePair returns the name=value pair of a cookie with its
+// value replaced by its digest; values already redacted are kept
+func redactCookiePair(pair string) string {
+	name, value, ok := strings.Cut(pair, "=")
+	if !ok {
+		return "REDACTED"
+	}
+	// clients send quoted values without their quotes
+	value = strings.Trim(value, `"`)
+	if value == "" || strings.HasPrefix(value, redactedCookiePrefix) {
+		return name + "=" + value
+	}
+	sum := sha256.Sum256([]byte(value))
+	return name + "=" + redactedCookiePrefix + hex.EncodeToString(sum[:8])
+}
+
+// harQuery returns the query values as HAR name-value pairs
+func harQuery(query url.Values) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	slices.SortStableFunc(pairs, func(a, b harNameValue) int { return strings.Compare(a.Name, b.Name) })
+	return pairs
+}
@@ -0,0 +1,175 @@
+package webcrawler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	binary := []byte{0xff, 0x00, 0xfe}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("Set-Cookie", "session=secret-session; Path=/; HttpOnly")
+			io.WriteString(w, "welcome")
+		case "/page":
+			if c, err := r.Cookie("session"); err == nil && c.Value == "secret-session" {
+				io.WriteString(w, "member")
+				return
+			}
+			io.WriteString(w, "guest")
+		case "/bin":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(binary)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// newRequest returns a GET request of path on srv with header
+	newRequest := func(t *testing.T, path string, header map[string]string) *http.Request {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		return req
+	}
+
+	// fetchBody returns the body of the response of f to req
+	fetchBody := func(t *testing.T, f Fetcher, req *http.Request) (*http.Response, string) {
+		t.Helper()
+		resp, err := f.Fetch(req)
+		if err != nil {
+			t.Fatalf("%s: got error %v", req.URL, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(body)
+	}
+
+	for _, name := range []string{"cassette", "cassette.har"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			rec, err := NewRecorder(&HTTPFetcher{Client: srv.Client()}, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec.Redact = []string{"X-Api-Key"}
+
+			fetchBody(t, rec, newRequest(t, "/login", map[string]string{
+				"X-Api-Key":     "secret-key",
+				"Authorization": "Bearer secret-token",
+			}))
+			fetchBody(t, rec, newRequest(t, "/page", nil))
+			fetchBody(t, rec, newRequest(t, "/page", map[string]string{"Cookie": "session=secret-session"}))
+			fetchBody(t, rec, newRequest(t, "/bin", nil))
+			if err := rec.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// files of the cassette are only readable by their owner
+			var content bytes.Buffer
+			err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return err
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				if mode := info.Mode().Perm(); mode != 0600 {
+					t.Errorf("%s: got mode %v, want %v", d.Name(), mode, os.FileMode(0600))
+				}
+				b, err := os.ReadFile(p)
+				content.Write(b)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range []string{"secret-key", "secret-token", "secret-session"} {
+				if strings.Contains(content.String(), secret) {
+					t.Errorf("cassette contains %q", secret)
+				}
+			}
+
+			replayer, err := NewReplayer(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replayer.Len() != 4 {
+				t.Errorf("got %d responses, want 4", replayer.Len())
+			}
+
+			resp, body := fetchBody(t, replayer, newRequest(t, "/login", nil))
+			cookies := resp.Cookies()
+			if body != "welcome" || len(cookies) != 1 || !strings.HasPrefix(cookies[0].Value, redactedCookiePrefix) {
+				t.Fatalf("/login: got body %q, cookies %v, want redacted session cookie", body, cookies)
+			}
+			if cookies[0].Path != "/" || !cookies[0].HttpOnly {
+				t.Errorf("/login: got cookie %v, want its attributes kept", cookies[0])
+			}
+
+			tests := []struct {
+				name   string
+				path   string
+				cookie string
+				want   string
+			}{
+				{name: "no cookie", path: "/page", want: "guest"},
+				{name: "replayed cookie", path: "/page", cookie: cookies[0].Name + "=" + cookies[0].Value, want: "member"},
+				{name: "cookie as sent", path: "/page", cookie: "session=secret-session", want: "member"},
+				{name: "binary", path: "/bin", want: string(binary)},
+			}
+			for _, test := range tests {
+				header := map[string]string{}
+				if test.cookie != "" {
+					header["Cookie"] = test.cookie
+				}
+				if _, got := fetchBody(t, replayer, newRequest(t, test.path, header)); got != test.want {
+					t.Errorf("%s: got body %q, want %q", test.name, got, test.want)
+				}
+			}
+
+			resp, _ = fetchBody(t, replayer, newRequest(t, "/robots.txt", nil))
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("/robots.txt: got status %d, want %d", resp.StatusCode, http.StatusNotFound)
+			}
+			if _, err := replayer.Fetch(newRequest(t, "/missing", nil)); !errors.Is(err, ErrNotRecorded) {
+				t.Errorf("/missing: got error %v, want %v", err, ErrNotRecorded)
+			}
+		})
+	}
+
+	t.Run("redactCookie", func(t *testing.T) {
+		tests := []struct {
+			input string
+			want  string
+		}{
+			{input: "a=1; b=", want: "a=" + redactedCookiePrefix + "6b86b273ff34fce1; b="},
+			{input: `a="1"`, want: "a=" + redactedCookiePrefix + "6b86b273ff34fce1"},
+			{input: "a=" + redactedCookiePrefix + "00", want: "a=" + redactedCookiePrefix + "00"},
+			{input: "invalid", want: "REDACTED"},
+		}
+		for _, test := range tests {
+			if got := redactCookie(test.input); got != test.want {
+				t.Errorf("input: %q, got %q, want %q", test.input, got, test.want)
+			}
+		}
+	})
+}
@@ -3,32 +3,38 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"time"
 
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/metrics"
 	"github.com/0x00f00bar/webcrawlerGo/models"
 	"github.com/julienschmidt/httprouter"
 )
 
-const serverPort = 8100
-
 type webapp struct {
+	Addr   string // address to serve the API on
 	Models *models.Models
+	Engine *webcrawler.Engine // running crawl engine; set only for control endpoints
+	Jobs   *crawlJobs         // crawls started from the API
+	Daemon *daemon            // scheduled crawls; set only in daemon mode
+	Events *webcrawler.EventBus
 	Logger *log.Logger
 }
 
 func (app *webapp) serve(ctx context.Context, quitChan chan os.Signal) error {
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", serverPort),
+		Addr:         app.Addr,
 		Handler:      app.routes(),
 		ErrorLog:     app.Logger,
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second, // test with load
 	}
+	// end event streams so that shutdown does not wait for them
+	srv.RegisterOnShutdown(app.Events.Close)
 
 	shutdownErr := make(chan error)
 
@@ -42,7 +48,7 @@ func (app *webapp) serve(ctx context.Context, quitChan chan os.Signal) error {
 
 	// go func() {
 	// 	time.Sleep(time.Second)
-	// 	app.openBrowser(fmt.Sprintf("http://localhost:%d", serverPort))
+	// 	app.openBrowser("http://localhost" + app.Addr)
 	// }()
 
 	err := srv.ListenAndServe()
@@ -55,6 +61,8 @@ func (app *webapp) serve(ctx context.Context, quitChan chan os.Signal) error {
 		return err
 	}
 
+	app.Jobs.stop()
+
 	app.Logger.Println("stopped server")
 	return nil
 }
@@ -71,6 +79,57 @@ func (app *webapp) routes() http.Handler {
 
 	router.HandlerFunc(http.MethodGet, "/v1/page", app.listPageHandler)
 	router.HandlerFunc(http.MethodGet, "/v1/page/:id", app.getPageByIdHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/page/:id/diff", app.diffPageHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v1/run", app.listRunHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/run/:id", app.getRunByIdHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v1/robots", app.listRobotsHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/robots/:id", app.getRobotsByIdHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/robots/:id/diff", app.diffRobotsHandler)
+
+	router.HandlerFunc(http.MethodPost, "/v1/crawl", app.createCrawlHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/crawl/:id", app.getCrawlHandler)
+	router.HandlerFunc(http.MethodDelete, "/v1/crawl/:id", app.cancelCrawlHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v1/events", app.listEventsHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v1/delivery", app.listDeliveryHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/delivery/:id", app.getDeliveryByIdHandler)
+
+	if app.Daemon != nil {
+		router.HandlerFunc(http.MethodGet, "/v1/schedule", app.listScheduleHandler)
+	}
+
+	router.Handler(http.MethodGet, "/metrics", metrics.Handler())
 
 	return app.logRequestMiddleware(router)
 }
+
+// serveMetrics serves Prometheus metrics on addr at /metrics
+// until ctx is done. Errors are written to logger.
+func serveMetrics(ctx context.Context, addr string, logger *log.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ErrorLog:     logger,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	logger.Printf("serving metrics on %s/metrics", addr)
+	err := srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		logger.Printf("metrics server error: %v", err)
+	}
+}
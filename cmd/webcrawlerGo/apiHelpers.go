@@ -1,16 +1,19 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/0x00f00bar/webcrawlerGo/internal"
 	"github.com/julienschmidt/httprouter"
@@ -253,6 +256,17 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap returns the wrapped writer for [http.ResponseController]
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
+// Hijack implements [http.Hijacker] for WebSocket upgrades
+func (lrw *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	lrw.statusCode = http.StatusSwitchingProtocols
+	return http.NewResponseController(lrw.ResponseWriter).Hijack()
+}
+
 func (app *webapp) logRequestMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		preNextLog := fmt.Sprintf(
@@ -262,8 +276,11 @@ func (app *webapp) logRequestMiddleware(next http.Handler) http.Handler {
 			r.RequestURI,
 			r.Proto,
 		)
+		start := time.Now()
 		lrw := NewLoggingResponseWriter(w)
 		next.ServeHTTP(lrw, r)
+		apiRequestDurationSeconds.Observe(time.Since(start).Seconds())
+		apiRequestsTotal.WithLabelValues(r.Method, strconv.Itoa(lrw.statusCode)).Inc()
 		app.Logger.Printf("%s %d", preNextLog, lrw.statusCode)
 	})
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+)
+
+// loadAuth reads and validates the credentials in the JSON file at path
+func loadAuth(path string) (*webcrawler.Auth, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var auth webcrawler.Auth
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&auth); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := auth.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &auth, nil
+}
@@ -1,163 +1,715 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"log"
+	"log/slog"
 	"net/url"
 	"os"
 	"strings"
 	"time"
 
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
 	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/webhook"
 )
 
 type cmdFlags struct {
-	baseURL        *url.URL      // -baseurl
-	cutOffDate     time.Time     // -date
-	updateDaysPast *int          // -days
-	dbDSN          *string       // -db-dsn
-	dbToDisk       bool          // -db2disk
-	idleTimeout    time.Duration // -idle-time
-	ignorePattern  []string      // -ignore
-	markedURLs     []string      // -murls
-	nCrawlers      *int          // -n
-	savePath       string        // -path
-	reqDelay       time.Duration // -req-delay
-	retryTime      *int          // -retry
-	userAgent      *string       // -ua
-	updateHrefs    bool          // -update-hrefs
-	runserver      bool          // -server
-	verbose        bool          // -verbose
-}
-
-// parseCmdFlags will parse cmd flags and validate them.
-// Validation failure will exit the program.
-func parseCmdFlags(v *internal.Validator) *cmdFlags {
-	// define cmd args; usage message is formatted for better visibility on standard
-	// terminal width of 80 chars
-	printVersion := flag.Bool("v", false, "Display app version")
-	nCrawlers := flag.Int("n", 10, "Number of crawlers to invoke")
-	idleTimeout := flag.String(
-		"idle-time",
-		"10s",
-		"Idle time after which crawler quits when queue is empty.\nMin: 1s",
-	)
-	baseURL := flag.String(
-		"baseurl",
-		"",
-		"Absolute base URL to crawl (required).\nE.g. <http/https>://<domain-name>",
-	)
-	userAgent := flag.String("ua", defaultUserAgent, "User-Agent string to use while crawling\n")
-	reqDelay := flag.String("req-delay", "50ms", "Delay between subsequent requests.\nMin: 1ms")
-	dbDSN := flag.String(
+	baseURL        *url.URL                    // -baseurl; -vhost for local sources
+	source         *localSource                // -baseurl dir:<path> or file://<path>
+	seeds          []*url.URL                  // -seeds
+	hostRules      []string                    // -hosts
+	scheme         string                      // -scheme
+	cutOffDate     time.Time                   // -date
+	updateDaysPast *int                        // -days
+	dbDSN          *string                     // -db-dsn
+	dbToDisk       bool                        // export; -db2disk
+	idleTimeout    time.Duration               // -idle-time
+	ignorePattern  []string                    // -ignore
+	markedURLs     []string                    // -murls
+	nCrawlers      *int                        // -n
+	savePath       string                      // -path
+	reqDelay       time.Duration               // -req-delay
+	hostRate       float64                     // -host-rps
+	hostConns      int                         // -host-conns
+	adaptiveDelay  bool                        // -adaptive-delay
+	robotsTTL      time.Duration               // -robots-ttl
+	directives     webcrawler.DirectivePolicy  // -robots-directives
+	extractors     *webcrawler.LinkExtractors  // -extract-*
+	retryTime      *int                        // -retry
+	retryBackoff   time.Duration               // -retry-backoff
+	errorPolicy    webcrawler.ErrorPolicy      // -on-error
+	userAgent      *string                     // -ua
+	auth           *webcrawler.Auth            // -auth
+	authFile       string                      // -auth
+	transport      *webcrawler.TransportConfig // -proxies, -ca-file, -client-cert, -client-key, -insecure, -*timeout, -http2
+	variants       []*webcrawler.Variant       // -variants
+	variantsFile   string                      // -variants
+	variant        string                      // export; -variant
+	record         string                      // -record
+	replay         string                      // -replay
+	updateHrefs    bool                        // -update-hrefs
+	runserver      bool                        // serve; -server, set with -daemon
+	crawlDefs      []*crawlDefinition          // -daemon
+	schedule       string                      // name of the crawl definition run by the daemon
+	verbose        bool                        // -verbose
+	noTUI          bool                        // -no-tui; set when stdout is not a terminal
+	quiet          bool                        // -quiet
+	logLevel       slog.Level                  // -log-level
+	logFormat      string                      // -log-format
+	logDir         string                      // -log-dir
+	metricsAddr    string                      // -metrics-addr
+	controlAddr    string                      // -control-addr
+	resume         bool                        // -resume
+	webhooks       []*webhook.Webhook          // -webhooks
+	configFile     string                      // -config
+	profile        string                      // -profile
+	serverAddr     string                      // -server-addr
+}
+
+// flagValues holds the values of cmd flags as given, before validation.
+// Flags of a command are defined on its flag set by the define* methods;
+// flags which are not defined keep their default values.
+type flagValues struct {
+	printVersion   bool
+	nCrawlers      int
+	idleTimeout    string
+	baseURL        string
+	vhost          string
+	seeds          string
+	hosts          string
+	scheme         string
+	userAgent      string
+	authFile       string
+	variantsFile   string
+	record         string
+	replay         string
+	proxies        string
+	caFile         string
+	clientCert     string
+	clientKey      string
+	insecure       bool
+	connectTimeout string
+	readTimeout    string
+	timeout        string
+	http2          bool
+	reqDelay       string
+	hostRate       float64
+	hostConns      int
+	adaptiveDelay  bool
+	robotsTTL      string
+	directives     string
+	extract        map[string]*bool // -extract-* by link extractor name
+	dbDSN          string
+	updateDaysPast int
+	markedURLs     string
+	ignorePatterns string
+	retry          int
+	retryBackoff   string
+	onError        string
+	dbToDisk       bool
+	savePath       string
+	cutOffDate     string
+	variant        string
+	updateHrefs    bool
+	server         bool
+	serverAddr     string
+	daemonFile     string
+	verbose        bool
+	noTUI          bool
+	quiet          bool
+	logLevel       string
+	logFormat      string
+	logDir         string
+	metricsAddr    string
+	controlAddr    string
+	resume         bool
+	webhooksFile   string
+	configFile     string
+	profile        string
+}
+
+// newFlagValues returns flagValues set to the defaults of cmd flags
+func newFlagValues() *flagValues {
+	return &flagValues{
+		nCrawlers:      defaultCrawlers,
+		idleTimeout:    defaultIdleTime,
+		userAgent:      defaultUserAgent,
+		connectTimeout: defaultConnectTimeout,
+		readTimeout:    defaultReadTimeout,
+		timeout:        defaultRequestTimeout,
+		http2:          defaultHTTP2,
+		reqDelay:       defaultReqDelay,
+		adaptiveDelay:  defaultAdaptiveDelay,
+		robotsTTL:      defaultRobotsTTL,
+		directives:     defaultDirectives,
+		extract:        newExtractFlags(),
+		updateDaysPast: defaultUpdateDays,
+		retry:          defaultRetry,
+		retryBackoff:   defaultRetryBackoff,
+		onError:        defaultOnError,
+		savePath:       defaultSavePath,
+		cutOffDate:     defaultCutOffDate,
+		serverAddr:     defaultServerAddr,
+		logLevel:       "info",
+		logFormat:      "text",
+		logDir:         defaultLogDir,
+	}
+}
+
+// usage message of flags is formatted for better visibility on standard
+// terminal width of 80 chars
+
+// defineDBFlags defines the flags of the database and config file
+func (fv *flagValues) defineDBFlags(fs *flag.FlagSet) {
+	fs.StringVar(
+		&fv.dbDSN,
 		"db-dsn",
-		"",
+		fv.dbDSN,
 		"DSN string to database.\nSupported DSN: PostgreSQL DSN (optional)."+`
 When empty crawler will use sqlite3 driver.`,
 	)
-	updateDaysPast := flag.Int(
-		"days",
-		1,
-		"Days past which monitored URLs should be updated",
+	fs.StringVar(
+		&fv.configFile,
+		"config",
+		fv.configFile,
+		`Path to a YAML, TOML or JSON file of flag values, optionally
+grouped in named profiles. Flags take precedence over WEBCRAWLERGO_*
+environment variables, which take precedence over the file.
+See README for the format.`,
+	)
+	fs.StringVar(&fv.profile, "profile", fv.profile, "Name of the profile to use from the 'config' file")
+}
+
+// defineLogFlags defines the flags of logging and output
+func (fv *flagValues) defineLogFlags(fs *flag.FlagSet) {
+	fs.BoolVar(
+		&fv.verbose,
+		"verbose",
+		fv.verbose,
+		"Prints additional info while logging (adds source file and line to log records)",
+	)
+	fs.BoolVar(&fv.quiet, "quiet", fv.quiet, "Print only the final summary and errors. Implies 'no-tui'.")
+	fs.StringVar(&fv.logLevel, "log-level", fv.logLevel, "Minimum log level: debug, info, warn, error")
+	fs.StringVar(
+		&fv.logFormat,
+		"log-format",
+		fv.logFormat,
+		"Format of log lines written to log file and stdout: text, json",
+	)
+	fs.StringVar(&fv.logDir, "log-dir", fv.logDir, "Directory to write log files to")
+}
+
+// defineTargetFlags defines the flags selecting the site and its pages
+func (fv *flagValues) defineTargetFlags(fs *flag.FlagSet) {
+	fs.StringVar(
+		&fv.baseURL,
+		"baseurl",
+		fv.baseURL,
+		`Absolute base URL to crawl (required).
+E.g. <http/https>://<domain-name>
+A local directory of HTML files, e.g. the build output of a static
+site, is crawled with dir:<path> or file://<path>, and a WARC file
+with file://<path>.`,
 	)
-	markedURLs := flag.String(
+	fs.StringVar(
+		&fv.vhost,
+		"vhost",
+		fv.vhost,
+		`Base URL the files of a dir: or file:// baseurl are served under.
+Default: http://localhost, or for WARC files the site of the
+first archived response.`,
+	)
+	fs.StringVar(
+		&fv.markedURLs,
 		"murls",
-		"",
+		fv.markedURLs,
 		`Comma ',' seperated string of marked url paths to save/update.
 If the marked path is unmonitored in the database, the crawler
 will mark the URL as monitored.
 When empty, crawler will update monitored URLs from the model.`,
 	)
-	ignorePatternList := flag.String(
+}
+
+// defineCrawlFlags defines the flags of a crawl
+func (fv *flagValues) defineCrawlFlags(fs *flag.FlagSet) {
+	fs.IntVar(&fv.nCrawlers, "n", fv.nCrawlers, "Number of crawlers to invoke")
+	fs.StringVar(
+		&fv.idleTimeout,
+		"idle-time",
+		fv.idleTimeout,
+		"Deprecated: crawlers quit when the queue is empty and no\nURL is being processed. Min: 1s",
+	)
+	fs.StringVar(&fv.userAgent, "ua", fv.userAgent, "User-Agent string to use while crawling\n")
+	fs.StringVar(
+		&fv.authFile,
+		"auth",
+		fv.authFile,
+		`Path to a JSON file of headers, basic/bearer auth, cookie file
+and form login to crawl pages behind a login. See README for the format.`,
+	)
+	fs.StringVar(
+		&fv.variantsFile,
+		"variants",
+		fv.variantsFile,
+		`Path to a JSON file of fetch variants: named sets of headers and
+cookies, e.g. mobile User-Agent, which saved pages of matching URLs
+are also fetched and saved with. See README for the format.`,
+	)
+	fs.StringVar(
+		&fv.record,
+		"record",
+		fv.record,
+		`Record the requests and responses of the crawl to a cassette:
+a directory, or a HAR file when the path ends with '.har'.`,
+	)
+	fs.StringVar(
+		&fv.replay,
+		"replay",
+		fv.replay,
+		`Serve the responses of a cassette written by -record, or of a HAR
+file, instead of fetching from the network.`,
+	)
+	fs.StringVar(
+		&fv.proxies,
+		"proxies",
+		fv.proxies,
+		`Comma ',' seperated http, https, socks5 or socks5h proxy URLs.
+Requests are sent through the proxies in turn. When empty, the
+proxy of HTTP_PROXY/HTTPS_PROXY env variables is used, if any.`,
+	)
+	fs.StringVar(&fv.caFile, "ca-file", fv.caFile, "PEM file of CA certificates to trust along with the system roots")
+	fs.StringVar(&fv.clientCert, "client-cert", fv.clientCert, "PEM file of the client certificate for mTLS.\nRequires -client-key")
+	fs.StringVar(&fv.clientKey, "client-key", fv.clientKey, "PEM file of the key of -client-cert")
+	fs.BoolVar(
+		&fv.insecure,
+		"insecure",
+		fv.insecure,
+		"Skip verification of server certificates. Only for development.",
+	)
+	fs.StringVar(
+		&fv.connectTimeout,
+		"connect-timeout",
+		fv.connectTimeout,
+		"Timeout of connecting to a host, including the TLS handshake",
+	)
+	fs.StringVar(
+		&fv.readTimeout,
+		"read-timeout",
+		fv.readTimeout,
+		"Timeout of waiting for the response headers of a request.\nNo timeout when 0",
+	)
+	fs.StringVar(
+		&fv.timeout,
+		"timeout",
+		fv.timeout,
+		"Timeout of a request, including reading the response body",
+	)
+	fs.BoolVar(&fv.http2, "http2", fv.http2, "Use HTTP/2 with hosts supporting it.\nUse -http2=false for HTTP/1.1 only")
+	fs.StringVar(&fv.reqDelay, "req-delay", fv.reqDelay, "Delay between subsequent requests.\nMin: 1ms")
+	fs.Float64Var(
+		&fv.hostRate,
+		"host-rps",
+		fv.hostRate,
+		`Max requests per second to a host, shared by all crawlers.
+The robots.txt Crawl-delay for the user agent, if any, is kept
+when longer.`,
+	)
+	fs.IntVar(
+		&fv.hostConns,
+		"host-conns",
+		fv.hostConns,
+		"Max concurrent requests to a host. No limit when 0.",
+	)
+	fs.BoolVar(
+		&fv.adaptiveDelay,
+		"adaptive-delay",
+		fv.adaptiveDelay,
+		`Slow down requests to a host on rising latency, HTTP 429 and 5xx
+responses and speed back up when the host is healthy.`,
+	)
+	fs.StringVar(
+		&fv.robotsTTL,
+		"robots-ttl",
+		fv.robotsTTL,
+		`Time after which robots.txt of a host is fetched again.
+Versions of robots.txt are kept in the database.`,
+	)
+	fs.StringVar(
+		&fv.directives,
+		"robots-directives",
+		fv.directives,
+		`Action to take on noindex/nofollow of meta robots tags and
+X-Robots-Tag headers, and on rel="nofollow" links.
+One of: record (only record the skip reason of the URL), obey
+(skip following and saving), skip-follow (only skip following),
+skip-save (only skip saving).`,
+	)
+	for _, name := range webcrawler.BuiltinExtractorNames() {
+		fs.BoolVar(fv.extract[name], "extract-"+name, *fv.extract[name], extractFlagUsages[name])
+	}
+	fs.IntVar(
+		&fv.updateDaysPast,
+		"days",
+		fv.updateDaysPast,
+		"Days past which monitored URLs should be updated",
+	)
+	fs.StringVar(
+		&fv.ignorePatterns,
 		"ignore",
-		"",
+		fv.ignorePatterns,
 		"Comma ',' seperated string of url patterns to ignore.",
 	)
-	retryFailedReq := flag.Int(
+	fs.StringVar(
+		&fv.seeds,
+		"seeds",
+		fv.seeds,
+		`Comma ',' seperated string of absolute URLs to crawl along with
+baseurl. URLs on the hosts of baseurl and seeds are crawled.`,
+	)
+	fs.StringVar(
+		&fv.hosts,
+		"hosts",
+		fv.hosts,
+		`Comma ',' seperated string of other hosts to crawl. Either a host
+e.g. 'docs.example.com' or '*.example.com' for all its subdomains.`,
+	)
+	fs.StringVar(
+		&fv.scheme,
+		"scheme",
+		fv.scheme,
+		`Rewrite URLs of the crawled hosts to scheme, so that http and
+https URLs are crawled once: http or https. Disabled when empty.`,
+	)
+	fs.IntVar(
+		&fv.retry,
 		"retry",
-		2,
+		fv.retry,
 		`Number of times to retry failed GET requests.
 With retry=2, crawlers will retry the failed GET urls
 twice after initial failure.`,
 	)
-	dbToDisk := flag.Bool(
-		"db2disk",
-		false,
-		`Use this flag to write the latest crawled content to disk.
-Customise using arguments 'path' and 'date'.
-Crawler will exit after saving to disk.`,
+	fs.StringVar(
+		&fv.retryBackoff,
+		"retry-backoff",
+		fv.retryBackoff,
+		"Delay before a failed URL is pushed back to the queue.",
+	)
+	fs.StringVar(
+		&fv.onError,
+		"on-error",
+		fv.onError,
+		`Action to take when crawlers fail to read/write the database.
+One of: abort (stop the crawl), skip (skip the URL),
+retry (retry the URL after 'retry-backoff', upto 'retry' times).`,
+	)
+	fs.BoolVar(
+		&fv.updateHrefs,
+		"update-hrefs",
+		fv.updateHrefs,
+		`Use this flag to update embedded HREFs in all saved and alive URLs
+in the crawl scope.`,
+	)
+	fs.BoolVar(
+		&fv.noTUI,
+		"no-tui",
+		fv.noTUI,
+		`Print plain log lines instead of the interactive terminal UI.
+Enabled automatically when stdout is not a terminal.`,
+	)
+	fs.StringVar(
+		&fv.metricsAddr,
+		"metrics-addr",
+		fv.metricsAddr,
+		`Address to serve Prometheus metrics on at /metrics while
+crawling. E.g. ':9100'. Disabled when empty.
+With 'serve', metrics are served on the server address.`,
+	)
+	fs.StringVar(
+		&fv.controlAddr,
+		"control-addr",
+		fv.controlAddr,
+		`Address to serve crawl control endpoints on at /control while
+crawling. E.g. '127.0.0.1:8101'. Disabled when empty.`,
 	)
-	savePath := flag.String(
+	fs.BoolVar(
+		&fv.resume,
+		"resume",
+		fv.resume,
+		fmt.Sprintf(`Push the URLs left in queue by a drained crawl (saved in
+'%s') back to the queue.`, pendingQueueFile),
+	)
+}
+
+// defineWebhookFlags defines the flags of webhook notifications
+func (fv *flagValues) defineWebhookFlags(fs *flag.FlagSet) {
+	fs.StringVar(
+		&fv.webhooksFile,
+		"webhooks",
+		fv.webhooksFile,
+		`Path to a JSON file of webhooks to notify when monitored pages
+change, go dead or come back alive and when a run fails.
+See README for the format.`,
+	)
+}
+
+// defineExportFlags defines the flags of saving pages to disk
+func (fv *flagValues) defineExportFlags(fs *flag.FlagSet) {
+	fs.StringVar(
+		&fv.savePath,
 		"path",
-		defaultSavePath,
-		"Output path to save the content of crawled web pages.\nApplicable only with 'db2disk' flag.",
+		fv.savePath,
+		"Output path to save the content of crawled web pages.",
 	)
-	cutOffDate := flag.String(
+	fs.StringVar(
+		&fv.cutOffDate,
 		"date",
-		defaultCutOffDate,
-		"Cut-off date upto which the latest crawled pages will be saved to disk.\nFormat: YYYY-MM-DD. Applicable only with 'db2disk' flag.\n",
+		fv.cutOffDate,
+		"Cut-off date upto which the latest crawled pages will be saved to disk.\nFormat: YYYY-MM-DD.\n",
 	)
-	updateHrefs := flag.Bool(
-		"update-hrefs",
-		false,
-		`Use this flag to update embedded HREFs in all saved and alive URLs
-belonging to the baseurl.`,
+	fs.StringVar(
+		&fv.variant,
+		"variant",
+		fv.variant,
+		`Name of the fetch variant of the pages to save. When empty, pages
+of the default fetch are saved. Use '*' to save the pages of all
+variants side by side, suffixed with the variant name.`,
+	)
+}
+
+// defineServeFlags defines the flags of the local server
+func (fv *flagValues) defineServeFlags(fs *flag.FlagSet) {
+	fs.StringVar(
+		&fv.serverAddr,
+		"server-addr",
+		fv.serverAddr,
+		"Address to serve the API on",
+	)
+	fs.StringVar(
+		&fv.daemonFile,
+		"daemon",
+		fv.daemonFile,
+		`Path to a JSON file of crawl definitions to run on schedule
+while serving the API. See README for the format.`,
+	)
+}
+
+// defineLegacyFlags defines all the flags of the single flag set used
+// before commands, which selects the mode with -server and -db2disk
+func (fv *flagValues) defineLegacyFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&fv.printVersion, "v", fv.printVersion, "Display app version")
+	fv.defineDBFlags(fs)
+	fv.defineLogFlags(fs)
+	fv.defineTargetFlags(fs)
+	fv.defineCrawlFlags(fs)
+	fv.defineWebhookFlags(fs)
+	fv.defineExportFlags(fs)
+	fv.defineServeFlags(fs)
+	fs.BoolVar(
+		&fv.dbToDisk,
+		"db2disk",
+		fv.dbToDisk,
+		`Use this flag to write the latest crawled content to disk.
+Customise using arguments 'path' and 'date'.
+Crawler will exit after saving to disk.`,
 	)
-	server := flag.Bool(
+	fs.BoolVar(
+		&fv.server,
 		"server",
-		false,
-		`Open a local server on port 8100 to manage db. If provided, all other
-options will be ignored (except db-dsn and verbose).`,
+		fv.server,
+		`Open a local server on 'server-addr' to manage db. If provided, all
+other options will be ignored (except db-dsn and verbose).`,
 	)
-	verbose := flag.Bool("verbose", false, "Prints additional info while logging")
+}
+
+// parseFlagSet parses args with fs and applies the config file and environment
+// variables to the flags not given in args. Exits when args cannot be parsed.
+func parseFlagSet(fs *flag.FlagSet, args []string, v *internal.Validator) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(exitCodeOK)
+		}
+		os.Exit(exitCodeError)
+	}
+	applyConfig(fs, v)
+}
 
-	flag.Parse()
+// parseLegacyCmdFlags parses args with the flag set used before commands
+// and validates the flags of the mode selected. Validation failure will
+// exit the program.
+func parseLegacyCmdFlags(args []string, v *internal.Validator) *cmdFlags {
+	fv := newFlagValues()
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fv.defineLegacyFlags(fs)
+	fs.Usage = func() {
+		printUsage(fs.Output())
+		fmt.Fprintf(fs.Output(), "\nFlags without a command (deprecated):\n")
+		fs.PrintDefaults()
+	}
+	parseFlagSet(fs, args, v)
 
-	if *printVersion {
+	if fv.printVersion {
 		fmt.Printf("Version %s\n", version)
 		os.Exit(0)
 	}
 
-	if *server {
-		// validate db-dsn
-		v.Check(
-			strings.Contains(*dbDSN, "postgres") || *dbDSN == "",
-			"db-dsn",
-			"only postgres dsn are supported, when empty will use sqlite3 driver",
-		)
-		if !v.Valid() {
-			printInvalidFlagErrors(v)
-		}
-		return &cmdFlags{
-			dbDSN:     dbDSN,
-			runserver: *server,
-			verbose:   *verbose,
-		}
+	switch {
+	case fv.server || fv.daemonFile != "":
+		return fv.serveFlags(fs, v)
+	default:
+		return fv.crawlFlags(fs, v)
 	}
+}
 
-	// trim whitespace and drop trailing '/'
-	*baseURL = strings.TrimSpace(*baseURL)
-	*baseURL = strings.TrimRight(*baseURL, "/")
+// logFlags validates the flags of logging
+func (fv *flagValues) logFlags(v *internal.Validator) slog.Level {
+	var pLogLevel slog.Level
+	if err := pLogLevel.UnmarshalText([]byte(fv.logLevel)); err != nil {
+		v.AddError("log-level", "must be one of: debug, info, warn, error")
+	}
+	v.Check(
+		internal.PermittedValue(fv.logFormat, "text", "json"),
+		"log-format",
+		"must be one of: text, json",
+	)
+	v.Check(fv.logDir != "", "log-dir", "must be provided")
+	return pLogLevel
+}
 
-	parsedBaseURL, err := url.Parse(*baseURL)
+// loadWebhooks reads the webhooks of -webhooks, if given
+func (fv *flagValues) loadWebhooks(v *internal.Validator) []*webhook.Webhook {
+	if fv.webhooksFile == "" {
+		return nil
+	}
+	webhooks, err := loadWebhooks(fv.webhooksFile)
 	if err != nil {
-		fmt.Printf("error: could not parse base URL: %s\n", err.Error())
-		os.Exit(1)
+		v.AddError("webhooks", err.Error())
 	}
+	return webhooks
+}
+
+// serveFlags returns the cmdFlags of the local server.
+// Validation failure will exit the program.
+func (fv *flagValues) serveFlags(fs *flag.FlagSet, v *internal.Validator) *cmdFlags {
+	logLevel := fv.logFlags(v)
+	webhooks := fv.loadWebhooks(v)
 
-	markedURLSlice := getMarkedURLS(*markedURLs)
+	// validate db-dsn
+	v.Check(
+		strings.Contains(fv.dbDSN, "postgres") || fv.dbDSN == "",
+		"db-dsn",
+		"only postgres dsn are supported, when empty will use sqlite3 driver",
+	)
+	v.Check(fv.serverAddr != "", "server-addr", "must be provided")
+
+	var crawlDefs []*crawlDefinition
+	if fv.daemonFile != "" {
+		var err error
+		crawlDefs, err = loadCrawlDefinitions(fv.daemonFile, fv.logDir)
+		if err != nil {
+			v.AddError("daemon", err.Error())
+		}
+	}
+	if !v.Valid() {
+		printInvalidFlagErrors(fs, v)
+	}
+	return &cmdFlags{
+		dbDSN:      &fv.dbDSN,
+		runserver:  true,
+		crawlDefs:  crawlDefs,
+		webhooks:   webhooks,
+		verbose:    fv.verbose,
+		noTUI:      true,
+		quiet:      fv.quiet,
+		logLevel:   logLevel,
+		logFormat:  fv.logFormat,
+		logDir:     fv.logDir,
+		configFile: fv.configFile,
+		profile:    fv.profile,
+		serverAddr: fv.serverAddr,
+	}
+}
+
+// crawlFlags returns the cmdFlags of a crawl, or of saving pages to
+// disk with -db2disk. Validation failure will exit the program.
+func (fv *flagValues) crawlFlags(fs *flag.FlagSet, v *internal.Validator) *cmdFlags {
+	logLevel := fv.logFlags(v)
+	webhooks := fv.loadWebhooks(v)
+
+	var auth *webcrawler.Auth
+	if fv.authFile != "" {
+		var err error
+		auth, err = loadAuth(fv.authFile)
+		if err != nil {
+			v.AddError("auth", err.Error())
+		}
+	}
+
+	var variants []*webcrawler.Variant
+	if fv.variantsFile != "" {
+		var err error
+		variants, err = loadVariants(fv.variantsFile)
+		if err != nil {
+			v.AddError("variants", err.Error())
+		}
+	}
+
+	parsedBaseURL, source := parseBaseURL(v, fv.baseURL, fv.vhost)
+
+	markedURLSlice := getMarkedURLS(fv.markedURLs)
+	seeds := parseSeeds(v, seperateCmdArgs(fv.seeds))
 
 	// validate request delay and idle-time
-	pRequestDelay, err := time.ParseDuration(*reqDelay)
+	pRequestDelay, err := time.ParseDuration(fv.reqDelay)
 	if err != nil {
 		v.AddError("req-delay", err.Error())
 	}
-	pIdleTime, err := time.ParseDuration(*idleTimeout)
+	pIdleTime, err := time.ParseDuration(fv.idleTimeout)
 	if err != nil {
 		v.AddError("idle-time", err.Error())
 	}
+	pRetryBackoff, err := time.ParseDuration(fv.retryBackoff)
+	if err != nil {
+		v.AddError("retry-backoff", err.Error())
+	}
+	pRobotsTTL, err := time.ParseDuration(fv.robotsTTL)
+	if err != nil {
+		v.AddError("robots-ttl", err.Error())
+	}
+	errorPolicy, err := webcrawler.ParseErrorPolicy(fv.onError)
+	if err != nil {
+		v.AddError("on-error", err.Error())
+	}
+	directives, err := webcrawler.ParseDirectivePolicy(fv.directives)
+	if err != nil {
+		v.AddError("robots-directives", err.Error())
+	}
+	extractors := enabledExtractors(v, func(name string) bool { return *fv.extract[name] })
+
+	transport := &webcrawler.TransportConfig{
+		Proxies:            seperateCmdArgs(fv.proxies),
+		CAFile:             strings.TrimSpace(fv.caFile),
+		CertFile:           strings.TrimSpace(fv.clientCert),
+		KeyFile:            strings.TrimSpace(fv.clientKey),
+		InsecureSkipVerify: fv.insecure,
+		DisableHTTP2:       !fv.http2,
+	}
+	transport.ConnectTimeout, err = time.ParseDuration(fv.connectTimeout)
+	if err != nil {
+		v.AddError("connect-timeout", err.Error())
+	}
+	transport.ReadTimeout, err = time.ParseDuration(fv.readTimeout)
+	if err != nil {
+		v.AddError("read-timeout", err.Error())
+	}
+	transport.Timeout, err = time.ParseDuration(fv.timeout)
+	if err != nil {
+		v.AddError("timeout", err.Error())
+	}
 
-	parsedCutOffDate, err := time.Parse(dateLayout, *cutOffDate)
+	parsedCutOffDate, err := time.Parse(dateLayout, fv.cutOffDate)
 	if err != nil {
 		fmt.Printf("error: could not parse cut-off date: %s\n", err.Error())
 		os.Exit(1)
@@ -166,63 +718,160 @@ options will be ignored (except db-dsn and verbose).`,
 	parsedCutOffDate = parsedCutOffDate.Add(24*time.Hour - 1*time.Second)
 
 	cmdArgs := cmdFlags{
-		nCrawlers:      nCrawlers,
+		nCrawlers:      &fv.nCrawlers,
 		baseURL:        parsedBaseURL,
-		updateDaysPast: updateDaysPast,
+		source:         source,
+		seeds:          seeds,
+		hostRules:      seperateCmdArgs(fv.hosts),
+		scheme:         strings.TrimSpace(fv.scheme),
+		updateDaysPast: &fv.updateDaysPast,
 		markedURLs:     markedURLSlice,
-		ignorePattern:  seperateCmdArgs(*ignorePatternList),
-		dbDSN:          dbDSN,
-		userAgent:      userAgent,
+		ignorePattern:  seperateCmdArgs(fv.ignorePatterns),
+		dbDSN:          &fv.dbDSN,
+		userAgent:      &fv.userAgent,
+		auth:           auth,
+		authFile:       fv.authFile,
+		variants:       variants,
+		variantsFile:   fv.variantsFile,
+		record:         strings.TrimSpace(fv.record),
+		replay:         strings.TrimSpace(fv.replay),
+		transport:      transport,
 		reqDelay:       pRequestDelay,
+		hostRate:       fv.hostRate,
+		hostConns:      fv.hostConns,
+		adaptiveDelay:  fv.adaptiveDelay,
+		robotsTTL:      pRobotsTTL,
+		directives:     directives,
+		extractors:     extractors,
 		idleTimeout:    pIdleTime,
-		retryTime:      retryFailedReq,
-		dbToDisk:       *dbToDisk,
-		savePath:       *savePath,
+		retryTime:      &fv.retry,
+		retryBackoff:   pRetryBackoff,
+		errorPolicy:    errorPolicy,
+		dbToDisk:       fv.dbToDisk,
+		savePath:       fv.savePath,
 		cutOffDate:     parsedCutOffDate,
-		updateHrefs:    *updateHrefs,
-		verbose:        *verbose,
+		variant:        strings.TrimSpace(fv.variant),
+		updateHrefs:    fv.updateHrefs,
+		verbose:        fv.verbose,
+		noTUI:          fv.noTUI || fv.quiet || !isTerminal(os.Stdout),
+		quiet:          fv.quiet,
+		logLevel:       logLevel,
+		logFormat:      fv.logFormat,
+		logDir:         fv.logDir,
+		metricsAddr:    fv.metricsAddr,
+		controlAddr:    fv.controlAddr,
+		resume:         fv.resume,
+		webhooks:       webhooks,
+		configFile:     fv.configFile,
+		profile:        fv.profile,
 	}
 
 	validateFlags(v, &cmdArgs)
 	if !v.Valid() {
-		printInvalidFlagErrors(v)
+		printInvalidFlagErrors(fs, v)
 	}
 
 	return &cmdArgs
 }
 
-func printInvalidFlagErrors(v *internal.Validator) {
+func printInvalidFlagErrors(fs *flag.FlagSet, v *internal.Validator) {
 	fmt.Println(redStyle.Render("Invalid flag values:"))
 	for k, v := range v.Errors {
 		fmt.Printf("%-9s : %s\n", k, v)
 	}
 	fmt.Println("")
-	flag.Usage()
+	fs.Usage()
 	os.Exit(1)
 }
 
-func logCmdArgs(cmdArgs *cmdFlags, f *os.File) {
-	printAndLog(printRed, f, "Running crawler with the following options:")
-	printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "Log file", f.Name()))
-	printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "Base URL", cmdArgs.baseURL.String()))
-	printAndLog(printCyan, f, fmt.Sprintf("%-16s: %t", "DB-2-Disk", cmdArgs.dbToDisk))
+func logCmdArgs(cmdArgs *cmdFlags, f *os.File, logger *log.Logger) {
+	printAndLog(printRed, logger, "Running crawler with the following options:")
+	printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Log file", f.Name()))
+	if cmdArgs.configFile != "" {
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Config file", cmdArgs.configFile))
+		if cmdArgs.profile != "" {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Profile", cmdArgs.profile))
+		}
+	}
+	printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Base URL", cmdArgs.baseURL.String()))
+	if cmdArgs.source != nil {
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Source", cmdArgs.source.path))
+	}
+	printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %t", "DB-2-Disk", cmdArgs.dbToDisk))
 	if cmdArgs.dbToDisk {
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "Save path", cmdArgs.savePath))
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "Cutoff date", cmdArgs.cutOffDate))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Save path", cmdArgs.savePath))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Cutoff date", cmdArgs.cutOffDate))
+		if cmdArgs.variant != "" {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Variant", cmdArgs.variant))
+		}
 		printAndLog(
 			printCyan,
-			f,
+			logger,
 			fmt.Sprintf("%-16s: %s", "Marked URL(s)", strings.Join(cmdArgs.markedURLs, " ")),
 		)
 	} else {
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "User-Agent", *cmdArgs.userAgent))
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %t", "Updating HREFs", cmdArgs.updateHrefs))
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %d day(s)", "Update interval", *cmdArgs.updateDaysPast))
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "Marked URL(s)", strings.Join(cmdArgs.markedURLs, " ")))
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "Ignored Pattern", strings.Join(cmdArgs.ignorePattern, " ")))
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %d", "Crawler count", *cmdArgs.nCrawlers))
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "Idle time", cmdArgs.idleTimeout))
-		printAndLog(printCyan, f, fmt.Sprintf("%-16s: %s", "Request delay", cmdArgs.reqDelay))
+		if len(cmdArgs.seeds) > 0 {
+			seeds := make([]string, len(cmdArgs.seeds))
+			for i, seed := range cmdArgs.seeds {
+				seeds[i] = seed.String()
+			}
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Seed URL(s)", strings.Join(seeds, " ")))
+		}
+		if len(cmdArgs.hostRules) > 0 {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Hosts", strings.Join(cmdArgs.hostRules, " ")))
+		}
+		if cmdArgs.scheme != "" {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Scheme", cmdArgs.scheme))
+		}
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "User-Agent", *cmdArgs.userAgent))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %t", "Updating HREFs", cmdArgs.updateHrefs))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %d day(s)", "Update interval", *cmdArgs.updateDaysPast))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Marked URL(s)", strings.Join(cmdArgs.markedURLs, " ")))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Ignored Pattern", strings.Join(cmdArgs.ignorePattern, " ")))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %d", "Crawler count", *cmdArgs.nCrawlers))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Idle time", cmdArgs.idleTimeout))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Request delay", cmdArgs.reqDelay))
+		hostRate := "robots.txt Crawl-delay"
+		if cmdArgs.hostRate > 0 {
+			hostRate = fmt.Sprintf("%g req/s, or robots.txt Crawl-delay", cmdArgs.hostRate)
+		}
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Host rate", hostRate))
+		if cmdArgs.hostConns > 0 {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %d", "Host conns", cmdArgs.hostConns))
+		}
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %t", "Adaptive delay", cmdArgs.adaptiveDelay))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Robots TTL", cmdArgs.robotsTTL))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Directives", cmdArgs.directives))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Extractors", strings.Join(extractorNames(cmdArgs.extractors), " ")))
+		if cmdArgs.authFile != "" {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Auth", cmdArgs.authFile))
+		}
+		if len(cmdArgs.variants) > 0 {
+			names := make([]string, len(cmdArgs.variants))
+			for i, variant := range cmdArgs.variants {
+				names[i] = variant.Name
+			}
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Variants", strings.Join(names, " ")))
+		}
+		logTransport(cmdArgs.transport, logger)
+		if cmdArgs.record != "" {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Record to", cmdArgs.record))
+		}
+		if cmdArgs.replay != "" {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Replay from", cmdArgs.replay))
+		}
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "On error", cmdArgs.errorPolicy))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %t", "Terminal UI", !cmdArgs.noTUI))
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Log level", cmdArgs.logLevel))
+		if cmdArgs.metricsAddr != "" {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Metrics address", cmdArgs.metricsAddr))
+		}
+		if cmdArgs.controlAddr != "" {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Control address", cmdArgs.controlAddr))
+		}
+		if len(cmdArgs.webhooks) > 0 {
+			printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %d", "Webhooks", len(cmdArgs.webhooks)))
+		}
 	}
 
 	if len(cmdArgs.markedURLs) < 1 {
@@ -235,3 +884,35 @@ func logCmdArgs(cmdArgs *cmdFlags, f *os.File) {
 		printYellow(message)
 	}
 }
+
+// scope returns the crawl scope of the base URL, seeds and host rules
+func (cmdArgs *cmdFlags) scope() *webcrawler.Scope {
+	return &webcrawler.Scope{
+		Seeds:  append([]*url.URL{cmdArgs.baseURL}, cmdArgs.seeds...),
+		Hosts:  cmdArgs.hostRules,
+		Scheme: cmdArgs.scheme,
+	}
+}
+
+// logTransport logs the transport options which are set,
+// and the timeouts. Credentials of proxies are redacted.
+func logTransport(tc *webcrawler.TransportConfig, logger *log.Logger) {
+	if len(tc.Proxies) > 0 {
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Proxies", strings.Join(redactProxies(tc.Proxies), " ")))
+	}
+	if tc.CAFile != "" {
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "CA file", tc.CAFile))
+	}
+	if tc.CertFile != "" {
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %s", "Client cert", tc.CertFile))
+	}
+	if tc.InsecureSkipVerify {
+		printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %t", "Insecure TLS", tc.InsecureSkipVerify))
+	}
+	printAndLog(
+		printCyan,
+		logger,
+		fmt.Sprintf("%-16s: connect %s, read %s, total %s", "Timeouts", tc.ConnectTimeout, tc.ReadTimeout, tc.Timeout),
+	)
+	printAndLog(printCyan, logger, fmt.Sprintf("%-16s: %t", "HTTP/2", !tc.DisableHTTP2))
+}
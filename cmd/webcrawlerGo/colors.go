@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -31,13 +30,3 @@ func printYellow(msg string) {
 func printLine(style lipgloss.Style, msg string) {
 	fmt.Println(style.Render(msg))
 }
-
-// colorAroundTexts concats color around texts
-func colorAroundTexts(s string, textColorMap map[string]*lipgloss.Style) string {
-	for k, v := range textColorMap {
-		if strings.Contains(s, k) {
-			return strings.ReplaceAll(s, k, v.Render(k))
-		}
-	}
-	return s
-}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// command is a command of the cli, run as 'webcrawlerGo <name> [arguments]'
+type command struct {
+	name    string
+	args    string // synopsis of the arguments
+	summary string
+	run     func(c *command, args []string) error
+	actions []*command // sub-commands, run as 'webcrawlerGo <name> <action> [arguments]'
+}
+
+var commands = []*command{
+	{
+		name:    "crawl",
+		args:    "-baseurl <url> [flags]",
+		summary: "Crawl a site and save the pages of monitored and marked URLs",
+		run:     runCrawl,
+	},
+	{
+		name:    "serve",
+		args:    "[flags]",
+		summary: "Serve the web API to manage the database and run crawls,\nand run scheduled crawls with -daemon",
+		run:     runServe,
+	},
+	{
+		name:    "export",
+		args:    "-baseurl <url> [flags]",
+		summary: "Save the latest crawled pages of a site to disk",
+		run:     runExport,
+	},
+	{
+		name:    "urls",
+		summary: "List and manage the URLs in the database",
+		actions: []*command{
+			{name: "list", args: "[flags]", summary: "List URLs", run: runURLsList},
+			{name: "add", args: "[flags] <url>...", summary: "Add URLs, monitored by default", run: runURLsAdd},
+			{name: "monitor", args: "[flags] <id|url>...", summary: "Mark URLs as monitored", run: runURLsUpdate},
+			{name: "unmonitor", args: "[flags] <id|url>...", summary: "Mark URLs as not monitored", run: runURLsUpdate},
+			{name: "revive", args: "[flags] <id|url>...", summary: "Mark dead URLs as alive to crawl them again", run: runURLsUpdate},
+		},
+	},
+	{
+		name:    "pages",
+		summary: "List, show and compare the saved pages",
+		actions: []*command{
+			{name: "list", args: "[flags] [<url-id|url>]", summary: "List the pages of a URL or of a crawl run", run: runPagesList},
+			{name: "show", args: "[flags] <id>", summary: "Print the content of a page", run: runPagesShow},
+			{
+				name:    "diff",
+				args:    "[flags] <id> [<other-id>]",
+				summary: "Print the changes between two pages; between the page\nand the previous page of its URL when other-id is not given",
+				run:     runPagesDiff,
+			},
+		},
+	},
+	{
+		name:    "stats",
+		args:    "[flags]",
+		summary: "Print the counts of URLs, pages and crawl runs",
+		run:     runStats,
+	},
+	{
+		name:    "migrate",
+		args:    "[flags]",
+		summary: "Create or upgrade the database tables",
+		run:     runMigrate,
+	},
+}
+
+// runCommand runs the command named by the first of args. Args starting with
+// a flag are parsed with the flag set used before commands.
+func runCommand(args []string) {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		exitCode = exitCodeError
+		return
+	}
+
+	switch name := args[0]; name {
+	case "-h", "-help", "--help", "help":
+		printUsage(os.Stdout)
+		return
+	case "version":
+		fmt.Printf("Version %s\n", version)
+		return
+	default:
+		if strings.HasPrefix(name, "-") {
+			run(parseLegacyCmdFlags(args, internal.NewValidator()))
+			return
+		}
+	}
+
+	c := findCommand(commands, args[0])
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printUsage(os.Stderr)
+		exitCode = exitCodeError
+		return
+	}
+	args = args[1:]
+
+	if len(c.actions) > 0 {
+		if len(args) == 0 || findCommand(c.actions, args[0]) == nil {
+			switch {
+			case len(args) == 0:
+				exitCode = exitCodeError
+			case args[0] != "-h" && args[0] != "-help" && args[0] != "--help":
+				fmt.Fprintf(os.Stderr, "unknown command %q\n\n", c.name+" "+args[0])
+				exitCode = exitCodeError
+			}
+			printActionsUsage(os.Stderr, c)
+			return
+		}
+		action := *findCommand(c.actions, args[0])
+		action.name = c.name + " " + action.name
+		c, args = &action, args[1:]
+	}
+
+	if err := c.run(c, args); err != nil {
+		fmt.Fprintln(os.Stderr, redStyle.Render("error: ")+err.Error())
+		if exitCode == exitCodeOK {
+			exitCode = exitCodeError
+		}
+	}
+}
+
+// findCommand returns the command of commands named name or nil
+func findCommand(commands []*command, name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// printUsage prints the commands of the cli to w
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [arguments]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		names := c.name
+		if len(c.actions) > 0 {
+			actionNames := make([]string, len(c.actions))
+			for i, action := range c.actions {
+				actionNames[i] = action.name
+			}
+			names += " " + strings.Join(actionNames, "|")
+		}
+		fmt.Fprintf(w, "  %s\n", names)
+		fmt.Fprintf(w, "        %s\n", strings.ReplaceAll(c.summary, "\n", "\n        "))
+	}
+	fmt.Fprintf(w, "  version\n        Display app version\n")
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
+
+// printActionsUsage prints the actions of c to w
+func printActionsUsage(w io.Writer, c *command) {
+	fmt.Fprintf(w, "Usage: %s %s <command> [arguments]\n\n%s\n\nCommands:\n", os.Args[0], c.name, c.summary)
+	for _, action := range c.actions {
+		fmt.Fprintf(w, "  %s %s\n", action.name, action.args)
+		fmt.Fprintf(w, "        %s\n", strings.ReplaceAll(action.summary, "\n", "\n        "))
+	}
+}
+
+// newFlagSet returns the flag set of c printing the usage of c
+func newFlagSet(c *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s %s\n\n%s\n", os.Args[0], c.name, c.args, c.summary)
+		var hasFlags bool
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+func runCrawl(c *command, args []string) error {
+	v := internal.NewValidator()
+	fv := newFlagValues()
+	fs := newFlagSet(c)
+	fv.defineDBFlags(fs)
+	fv.defineLogFlags(fs)
+	fv.defineTargetFlags(fs)
+	fv.defineCrawlFlags(fs)
+	fv.defineWebhookFlags(fs)
+	parseFlagSet(fs, args, v)
+	checkNoArgs(fs, v)
+
+	run(fv.crawlFlags(fs, v))
+	return nil
+}
+
+func runServe(c *command, args []string) error {
+	v := internal.NewValidator()
+	fv := newFlagValues()
+	fs := newFlagSet(c)
+	fv.defineDBFlags(fs)
+	fv.defineLogFlags(fs)
+	fv.defineServeFlags(fs)
+	fv.defineWebhookFlags(fs)
+	parseFlagSet(fs, args, v)
+	checkNoArgs(fs, v)
+
+	run(fv.serveFlags(fs, v))
+	return nil
+}
+
+func runExport(c *command, args []string) error {
+	v := internal.NewValidator()
+	fv := newFlagValues()
+	fs := newFlagSet(c)
+	fv.defineDBFlags(fs)
+	fv.defineLogFlags(fs)
+	fv.defineTargetFlags(fs)
+	fv.defineExportFlags(fs)
+	parseFlagSet(fs, args, v)
+	checkNoArgs(fs, v)
+
+	fv.dbToDisk = true
+	run(fv.crawlFlags(fs, v))
+	return nil
+}
+
+// checkNoArgs exits when fs was given arguments after the flags
+func checkNoArgs(fs *flag.FlagSet, v *internal.Validator) {
+	if fs.NArg() > 0 {
+		v.AddError("args", fmt.Sprintf("unexpected arguments: %s", strings.Join(fs.Args(), " ")))
+		printInvalidFlagErrors(fs, v)
+	}
+}
+
+// dbCommand is a command managing the database, printing its results
+// to os.Stdout
+type dbCommand struct {
+	fs     *flag.FlagSet
+	fv     *flagValues
+	v      *internal.Validator
+	asJSON bool // -json
+}
+
+// newDBCommand returns a dbCommand of c with the database flags defined.
+// The -json flag is defined when withJSON is true.
+func newDBCommand(c *command, withJSON bool) *dbCommand {
+	dc := &dbCommand{
+		fs: newFlagSet(c),
+		fv: newFlagValues(),
+		v:  internal.NewValidator(),
+	}
+	dc.fv.defineDBFlags(dc.fs)
+	if withJSON {
+		dc.fs.BoolVar(&dc.asJSON, "json", false, "Print the output as JSON")
+	}
+	return dc
+}
+
+// parse parses args and validates the database flags and the number
+// of arguments, between minArgs and maxArgs; maxArgs < 0 for no limit.
+// Validation failure will exit the program.
+func (dc *dbCommand) parse(args []string, minArgs, maxArgs int) {
+	parseFlagSet(dc.fs, args, dc.v)
+
+	dc.v.Check(
+		strings.Contains(dc.fv.dbDSN, "postgres") || dc.fv.dbDSN == "",
+		"db-dsn",
+		"only postgres dsn are supported, when empty will use sqlite3 driver",
+	)
+	switch n := dc.fs.NArg(); {
+	case n < minArgs:
+		dc.v.AddError("args", "missing arguments")
+	case maxArgs >= 0 && n > maxArgs:
+		dc.v.AddError("args", fmt.Sprintf("unexpected arguments: %s", strings.Join(dc.fs.Args()[maxArgs:], " ")))
+	}
+	if !dc.v.Valid() {
+		printInvalidFlagErrors(dc.fs, dc.v)
+	}
+}
+
+// openModels opens the database of -db-dsn
+func (dc *dbCommand) openModels(ctx context.Context) (*models.Models, func(), error) {
+	return openModels(ctx, dc.fv.dbDSN, stderrLoggers())
+}
+
+// printJSON prints data to os.Stdout as indented JSON
+func printJSON(data any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	return enc.Encode(data)
+}
+
+// printTable prints rows to os.Stdout in aligned columns
+// under the column names of header
+func printTable(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// formatTime formats t for tables; "-" when t is zero
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func runStats(c *command, args []string) error {
+	dc := newDBCommand(c, true)
+	baseURL := dc.fs.String("baseurl", "", "Count only the URLs, pages and runs of URLs containing baseurl")
+	dc.parse(args, 0, 0)
+
+	ctx := context.Background()
+	m, closeDB, err := dc.openModels(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	stats, err := m.Stats.Get(ctx, *baseURL)
+	if err != nil {
+		return err
+	}
+
+	cf := models.CommonFilters{Page: 1, PageSize: 1, Sort: "-id", SortSafeList: []string{"id"}}
+	runs, err := m.Runs.GetAll(ctx, models.RunFilter{BaseURL: *baseURL}, cf)
+	if err != nil {
+		return err
+	}
+	var lastRun *models.CrawlRun
+	if len(runs) > 0 {
+		lastRun = runs[0]
+	}
+
+	if dc.asJSON {
+		return printJSON(map[string]any{"stats": stats, "last_run": lastRun})
+	}
+
+	fmt.Printf("%-16s: %d\n", "URLs", stats.URLs)
+	fmt.Printf("%-16s: %d\n", "Monitored URLs", stats.MonitoredURLs)
+	fmt.Printf("%-16s: %d\n", "Dead URLs", stats.DeadURLs)
+	fmt.Printf("%-16s: %d\n", "Pages", stats.Pages)
+	fmt.Printf("%-16s: %d\n", "Crawl runs", stats.Runs)
+	if lastRun != nil {
+		fmt.Printf(
+			"%-16s: #%d of %s at %s, %s\n",
+			"Last run",
+			lastRun.ID,
+			lastRun.BaseURL,
+			formatTime(lastRun.StartedAt),
+			lastRun.ExitReason,
+		)
+	}
+	return nil
+}
+
+func runMigrate(c *command, args []string) error {
+	dc := newDBCommand(c, false)
+	dc.parse(args, 0, 0)
+
+	// the tables are created and upgraded when the database is opened
+	_, closeDB, err := dc.openModels(context.Background())
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	fmt.Println("Database is up to date.")
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix of the environment variables setting flags,
+// e.g. WEBCRAWLERGO_REQ_DELAY sets -req-delay
+const envPrefix = "WEBCRAWLERGO_"
+
+// flags which cannot be set from the config file
+var nonConfigFlags = []string{"config", "profile"}
+
+// isSettingFlag tells if the flag name is a setting of crawls, exports
+// or the server, which can be set by environment variables and the
+// config file. Flags of the db management commands are not settings.
+func isSettingFlag(name string) bool {
+	allFlags := flag.NewFlagSet("", flag.ContinueOnError)
+	newFlagValues().defineLegacyFlags(allFlags)
+	return allFlags.Lookup(name) != nil && name != "v"
+}
+
+// applyConfig sets the flags of fs not given on the command line from the
+// WEBCRAWLERGO_* environment variables and then from the config file
+// of -config, so that flags take precedence over environment variables,
+// environment variables over the config file and the config file over
+// the defaults. Settings of the file for flags of other commands are
+// ignored. Invalid values are added to v.
+func applyConfig(fs *flag.FlagSet, v *internal.Validator) {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	fromEnv := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) {
+		if explicit[f.Name] || !isSettingFlag(f.Name) {
+			return
+		}
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			v.AddError(f.Name, fmt.Sprintf("invalid value %q of %s: %s", value, envName(f.Name), err))
+			return
+		}
+		fromEnv[f.Name] = true
+	})
+
+	config := fs.Lookup("config")
+	if config == nil || config.Value.String() == "" {
+		return
+	}
+	path := config.Value.String()
+	settings, profile, err := loadConfig(path, fs.Lookup("profile").Value.String())
+	if err != nil {
+		v.AddError("config", err.Error())
+		return
+	}
+	// record the profile chosen by the file
+	_ = fs.Set("profile", profile)
+
+	for name, value := range settings {
+		if fs.Lookup(name) == nil || explicit[name] || fromEnv[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			v.AddError(name, fmt.Sprintf("invalid value %q in %s: %s", value, path, err))
+		}
+	}
+}
+
+// envName returns the environment variable setting flag name
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// loadConfig reads the config file at path and returns the flag values
+// of profile by flag name and the name of the profile used. Top-level
+// settings of the file are shared by all profiles and overridden by the
+// settings of the profile. When profile is empty, the profile named by
+// the top-level "profile" key is used, if any.
+//
+// The format of the file is chosen by its extension: .yaml/.yml, .toml or .json.
+func loadConfig(path, profile string) (map[string]string, string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var doc map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &doc)
+	case ".toml":
+		err = toml.Unmarshal(b, &doc)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		err = dec.Decode(&doc)
+	default:
+		return nil, "", fmt.Errorf("%s: unsupported config format, use .yaml, .yml, .toml or .json", path)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	var profiles map[string]any
+	if p, ok := doc["profiles"]; ok {
+		profiles, ok = p.(map[string]any)
+		if !ok {
+			return nil, "", fmt.Errorf("%s: profiles must be a map of profile name to settings", path)
+		}
+		delete(doc, "profiles")
+	}
+	if p, ok := doc["profile"]; ok {
+		if profile == "" {
+			profile, _ = p.(string)
+		}
+		delete(doc, "profile")
+	}
+
+	settings, err := configSettings(doc)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	if profile == "" {
+		return settings, profile, nil
+	}
+	p, ok := profiles[profile]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, "", fmt.Errorf("%s: profile %q not found; profiles: %s", path, profile, strings.Join(names, ", "))
+	}
+	profileDoc, ok := p.(map[string]any)
+	if !ok {
+		return nil, "", fmt.Errorf("%s: profile %q must be a map of settings", path, profile)
+	}
+	profileSettings, err := configSettings(profileDoc)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: profile %q: %w", path, profile, err)
+	}
+	for name, value := range profileSettings {
+		settings[name] = value
+	}
+	return settings, profile, nil
+}
+
+// configSettings converts the settings of doc to flag values by flag name.
+// Keys are flag names of any command, '_' may be used in place of '-'.
+func configSettings(doc map[string]any) (map[string]string, error) {
+	settings := make(map[string]string, len(doc))
+	for key, value := range doc {
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		if !isSettingFlag(name) || slices.Contains(nonConfigFlags, name) {
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+		s, err := configValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("setting %q: %w", key, err)
+		}
+		settings[name] = s
+	}
+	return settings, nil
+}
+
+// configValue formats value as a flag value; lists are joined with ','
+func configValue(value any) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case json.Number:
+		return value.String(), nil
+	case time.Time:
+		return value.Format(dateLayout), nil
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			s, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any:
+		return "", errors.New("must be a value or a list of values")
+	case fmt.Stringer: // e.g. TOML local dates
+		return value.String(), nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
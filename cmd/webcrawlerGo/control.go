@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/queue"
+	"github.com/julienschmidt/httprouter"
+)
+
+// serveControl serves the runtime control endpoints of engine and
+// its events on addr until ctx is done. Errors are written to logger.
+func serveControl(
+	ctx context.Context,
+	addr string,
+	engine *webcrawler.Engine,
+	events *webcrawler.EventBus,
+	logger *log.Logger,
+) {
+	app := webapp{
+		Engine: engine,
+		Events: events,
+		Logger: logger,
+	}
+
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      app.controlRoutes(),
+		ErrorLog:     logger,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(events.Close)
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	logger.Printf("serving crawl control on %s/control", addr)
+	err := srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		logger.Printf("control server error: %v", err)
+	}
+}
+
+func (app *webapp) controlRoutes() http.Handler {
+	router := httprouter.New()
+	router.NotFound = http.HandlerFunc(app.notFoundResponse)
+	router.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowedResponse)
+
+	router.HandlerFunc(http.MethodGet, "/control", app.controlStatusHandler)
+	router.HandlerFunc(http.MethodPost, "/control/pause", app.controlOpHandler(webcrawler.OpPause))
+	router.HandlerFunc(http.MethodPost, "/control/resume", app.controlOpHandler(webcrawler.OpResume))
+	router.HandlerFunc(http.MethodPost, "/control/drain", app.controlOpHandler(webcrawler.OpDrain))
+	router.HandlerFunc(http.MethodPost, "/control/delay", app.controlDelayHandler)
+	router.HandlerFunc(http.MethodPost, "/control/crawlers", app.controlCrawlersHandler)
+
+	router.HandlerFunc(http.MethodGet, "/v1/events", app.listEventsHandler)
+
+	return app.logRequestMiddleware(router)
+}
+
+// controlStatusHandler writes the live snapshot of the engine
+func (app *webapp) controlStatusHandler(w http.ResponseWriter, r *http.Request) {
+	err := app.writeJSON(w, http.StatusOK, envelope{"status": app.Engine.Snapshot()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// controlOpHandler returns a handler sending op to the engine
+func (app *webapp) controlOpHandler(op webcrawler.ControlOp) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		app.sendControl(w, r, webcrawler.Command{Op: op})
+	}
+}
+
+// controlDelayHandler changes the request delay of crawlers.
+// Body: {"delay": "200ms"}
+func (app *webapp) controlDelayHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Delay string `json:"delay"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := internal.NewValidator()
+	delay, err := time.ParseDuration(input.Delay)
+	v.Check(err == nil, "delay", "must be a duration e.g. 200ms")
+	v.Check(err != nil || delay >= time.Millisecond, "delay", "must be at least 1ms")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	app.sendControl(w, r, webcrawler.Command{Op: webcrawler.OpSetDelay, Delay: delay})
+}
+
+// controlCrawlersHandler adds or removes crawlers.
+// Body: {"add": n} or {"remove": n}
+func (app *webapp) controlCrawlersHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Add    int `json:"add"`
+		Remove int `json:"remove"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := internal.NewValidator()
+	v.Check(input.Add >= 0, "add", "must not be negative")
+	v.Check(input.Remove >= 0, "remove", "must not be negative")
+	v.Check((input.Add > 0) != (input.Remove > 0), "crawlers", "provide exactly one of add or remove")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	cmd := webcrawler.Command{Op: webcrawler.OpAddCrawlers, N: input.Add}
+	if input.Remove > 0 {
+		cmd = webcrawler.Command{Op: webcrawler.OpRemoveCrawlers, N: input.Remove}
+	}
+	app.sendControl(w, r, cmd)
+}
+
+// sendControl sends cmd to the engine and writes the resulting snapshot
+func (app *webapp) sendControl(w http.ResponseWriter, r *http.Request, cmd webcrawler.Command) {
+	err := app.Engine.Control(cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, webcrawler.ErrEngineNotRunning):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		default:
+			app.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	app.Logger.Printf("applied crawl control: %s", cmd.Op)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"status": app.Engine.Snapshot()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// savePendingQueue writes the URLs left in q to pendingQueueFile,
+// one "<url>\t<fetch content>" per line.
+// Returns the number of URLs written.
+func savePendingQueue(q *queue.UniqueQueue) (int, error) {
+	items := q.Items()
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	f, err := os.Create(pendingQueueFile)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, item := range items {
+		fetchContent, _ := q.GetMapValue(item)
+		fmt.Fprintf(w, "%s\t%t\n", item, fetchContent)
+	}
+	if err = w.Flush(); err != nil {
+		return 0, err
+	}
+	return len(items), f.Close()
+}
+
+// loadPendingQueue pushes the URLs saved by savePendingQueue to q
+// and removes pendingQueueFile. Returns the number of URLs pushed.
+func loadPendingQueue(q *queue.UniqueQueue) (int, error) {
+	f, err := os.Open(pendingQueueFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer f.Close()
+
+	// URLs already pushed from model are not pushed again
+	queued := make(map[string]bool)
+	for _, item := range q.Items() {
+		queued[item] = true
+	}
+
+	var pushed int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		item, value, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok || item == "" || queued[item] {
+			continue
+		}
+		fetchContent, _ := strconv.ParseBool(value)
+		q.InsertForce(item)
+		q.SetMapValue(item, fetchContent)
+		pushed++
+	}
+	if err = scanner.Err(); err != nil {
+		return pushed, err
+	}
+
+	f.Close()
+	return pushed, os.Remove(pendingQueueFile)
+}
@@ -2,10 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"log/slog"
 	"os"
-	"sync"
 	"time"
 
 	webcrawler "github.com/0x00f00bar/webcrawlerGo"
@@ -14,98 +14,400 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func beginCrawl(
+// errCrawlerConfig is wrapped by the errors of an invalid crawler config
+var errCrawlerConfig = errors.New("invalid crawler config")
+
+// crawlSession holds the engine and the recorded run
+// of a crawl prepared from cmdArgs
+type crawlSession struct {
+	cmdArgs *cmdFlags
+	queue   *queue.UniqueQueue
+	models  *models.Models
+	loggers *loggers
+	engine  *webcrawler.Engine
+	run     *models.CrawlRun
+	events  *webcrawler.EventBus // nil when events are not published
+	rec     *webcrawler.Recorder // nil when not recording
+}
+
+// newCrawlSession pushes the seed URLs and the URLs due from model to q,
+// creates the engine and records the crawl run. Crawlers log to logger
+// and to prettyLogger when not nil and publish events to events when not nil.
+// Returns error wrapping errCrawlerConfig when the crawler config is invalid.
+func newCrawlSession(
 	ctx context.Context,
 	cmdArgs *cmdFlags,
-	quit chan os.Signal,
 	q *queue.UniqueQueue,
 	m *models.Models,
 	loggers *loggers,
-) error {
-	// insert base URL to URL model if not present
+	logger *slog.Logger,
+	prettyLogger webcrawler.PrettyLogger,
+	events *webcrawler.EventBus,
+) (*crawlSession, error) {
+	// insert seed URLs to URL model if not present
 	// when present will throw unique constraint error, which can be ignored
-	q.Insert(cmdArgs.baseURL.String())
-	var t time.Time
-	u := models.NewURL(cmdArgs.baseURL.String(), t, t, false)
-	_ = m.URLs.Insert(u)
+	scope := cmdArgs.scope()
+	for _, seed := range scope.CanonicalSeeds() {
+		q.Insert(seed.String())
+		var t time.Time
+		u := models.NewURL(seed.String(), t, t, false)
+		_ = m.URLs.Insert(ctx, u)
+	}
 
 	// get all urls from db, put all in queue's map
 	loadedURLs, err := loadUrlsToQueue(ctx, q, m.URLs, cmdArgs, loggers)
 	if err != nil {
-		exitCode = 1
-		// loggers.multiLogger.Println(err)
-		return err
+		return nil, err
 	}
 	loggers.multiLogger.Printf("Loaded %d URLs from model\n", loadedURLs)
 
-	// if retry == 0, don't init request stats map
-	var retryRequestStats map[string]int
-	if *cmdArgs.retryTime > 0 {
-		retryRequestStats = map[string]int{}
-	} else {
-		retryRequestStats = nil
+	// push URLs left by a drained crawl
+	if cmdArgs.resume {
+		pending, err := loadPendingQueue(q)
+		if err != nil {
+			return nil, fmt.Errorf("could not load pending queue: %w", err)
+		}
+		loggers.multiLogger.Printf("Loaded %d pending URLs from %s\n", pending, pendingQueueFile)
 	}
-	// display min of 5 log messages
-	numMsgs := max(int(float32(*cmdArgs.nCrawlers)*float32(1.5)), 5)
-	teaProg := tea.NewProgram(newteaProgModel(numMsgs, quit))
 
-	prettyLogger := &crawLogger{
-		teaProgram:   teaProg,
-		crawlerCount: *cmdArgs.nCrawlers,
+	// robots.txt is fetched through the same proxies, TLS and timeouts as pages
+	robots := webcrawler.NewRobotsCache(m.Robots, cmdArgs.robotsTTL)
+	robots.Client, err = cmdArgs.transport.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCrawlerConfig, err)
 	}
 
 	crawlerCfg := &webcrawler.CrawlerConfig{
 		Queue:          q,
 		Models:         m,
 		BaseURL:        cmdArgs.baseURL,
+		Scope:          scope,
 		UserAgent:      *cmdArgs.userAgent,
 		MarkedURLs:     cmdArgs.markedURLs,
 		IgnorePatterns: cmdArgs.ignorePattern,
 		RequestDelay:   cmdArgs.reqDelay,
+		HostRate:       cmdArgs.hostRate,
+		HostConns:      cmdArgs.hostConns,
+		AdaptiveDelay:  cmdArgs.adaptiveDelay,
+		Robots:         robots,
 		IdleTimeout:    cmdArgs.idleTimeout,
-		Logger:         loggers.fileLogger,
+		Logger:         logger,
+		LogDir:         cmdArgs.logDir,
 		RetryTimes:     *cmdArgs.retryTime,
-		FailedRequests: retryRequestStats,
-		Ctx:            ctx,
+		RetryBackoff:   cmdArgs.retryBackoff,
+		ErrorPolicy:    cmdArgs.errorPolicy,
+		Directives:     cmdArgs.directives,
+		Extractors:     cmdArgs.extractors,
+		Auth:           cmdArgs.auth,
+		Transport:      cmdArgs.transport,
+		Variants:       cmdArgs.variants,
 		PrettyLogger:   prettyLogger,
+		Events:         events,
+	}
+
+	// init engine with n crawlers
+	engine, err := webcrawler.NewEngine(*cmdArgs.nCrawlers, "crawler", crawlerCfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCrawlerConfig, err)
+	}
+
+	// pages and robots.txt of local sources are read from their files
+	var fetcher webcrawler.Fetcher = &webcrawler.HTTPFetcher{Client: engine.Client}
+	if cmdArgs.source != nil {
+		fetcher = cmdArgs.source.fetcher
+		crawlerCfg.Fetcher = fetcher
+		robots.Fetcher = fetcher
+		loggers.multiLogger.Printf("Crawling %s as %s\n", cmdArgs.source.path, cmdArgs.baseURL)
+	}
+
+	// pages and robots.txt are recorded to or replayed from a cassette
+	var rec *webcrawler.Recorder
+	switch {
+	case cmdArgs.record != "":
+		rec, err = webcrawler.NewRecorder(fetcher, cmdArgs.record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errCrawlerConfig, err)
+		}
+		if cmdArgs.auth != nil {
+			rec.Redact = cmdArgs.auth.HeaderNames()
+		}
+		crawlerCfg.Fetcher = rec
+		robots.Fetcher = rec
+		loggers.multiLogger.Printf("Recording responses to %s\n", cmdArgs.record)
+	case cmdArgs.replay != "":
+		replayer, err := webcrawler.NewReplayer(cmdArgs.replay)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errCrawlerConfig, err)
+		}
+		crawlerCfg.Fetcher = replayer
+		robots.Fetcher = replayer
+		loggers.multiLogger.Printf("Replaying %d responses from %s\n", replayer.Len(), cmdArgs.replay)
+	}
+
+	// record the run; crawlers tag saved pages and fetched URLs with its id
+	run := newCrawlRun(cmdArgs)
+	if err = m.Runs.Insert(ctx, run); err != nil {
+		return nil, fmt.Errorf("could not record crawl run: %w", err)
 	}
+	crawlerCfg.RunID = run.ID
+	loggers.fileLogger.Printf("Recorded crawl run #%d", run.ID)
+
+	return &crawlSession{
+		cmdArgs: cmdArgs,
+		queue:   q,
+		models:  m,
+		loggers: loggers,
+		engine:  engine,
+		run:     run,
+		events:  events,
+		rec:     rec,
+	}, nil
+}
 
-	// init n crawlers
-	crawlerArmy, err := webcrawler.NNewCrawlers(*cmdArgs.nCrawlers, "crawler", crawlerCfg)
+// finish logs the summary, saves the queue left by a drained crawl
+// and records the summary and exit reason of the run
+func (s *crawlSession) finish(ctx context.Context, summary webcrawler.Summary, runErr error) {
+	logSummary(summary, s.loggers)
+
+	if s.rec != nil {
+		if err := s.rec.Close(); err != nil {
+			s.loggers.multiLogger.Printf("Could not write cassette: %v", err)
+		}
+	}
+
+	// keep the URLs left in queue for the next crawl with -resume
+	if summary.Drained {
+		pending, err := savePendingQueue(s.queue)
+		if err != nil {
+			s.loggers.multiLogger.Printf("Could not save pending queue: %v", err)
+		} else if pending > 0 {
+			s.loggers.multiLogger.Printf(
+				"Saved %d pending URLs to %s; continue with -resume",
+				pending,
+				pendingQueueFile,
+			)
+		}
+	}
+
+	// record the run even when ctx is cancelled
+	err := finishCrawlRun(context.WithoutCancel(ctx), s.models.Runs, s.run, summary, runErr)
 	if err != nil {
-		exitCode = 2
-		// loggers.multiLogger.Println(err)
+		s.loggers.multiLogger.Printf("Could not update crawl run #%d: %v", s.run.ID, err)
+	}
+
+	if s.events != nil && (s.run.ExitReason == models.RunFailed || s.run.ExitReason == models.RunAborted) {
+		e := webcrawler.Event{
+			Type:  webcrawler.EventRunFailed,
+			Time:  time.Now(),
+			URL:   s.run.BaseURL,
+			RunID: s.run.ID,
+			Error: s.run.ExitReason,
+		}
+		if runErr != nil {
+			e.Error = fmt.Sprintf("%s: %v", s.run.ExitReason, runErr)
+		}
+		s.events.Publish(e)
+	}
+}
+
+func beginCrawl(
+	ctx context.Context,
+	cmdArgs *cmdFlags,
+	quit chan os.Signal,
+	q *queue.UniqueQueue,
+	m *models.Models,
+	loggers *loggers,
+) error {
+	// write log lines to stdout without TUI, unless quiet
+	logger := loggers.crawlerLogger(cmdArgs.noTUI && !cmdArgs.quiet)
+
+	var prettyLogger *crawLogger
+	var pl webcrawler.PrettyLogger
+	if !cmdArgs.noTUI {
+		prettyLogger = &crawLogger{}
+		pl = prettyLogger
+	}
+
+	// events are streamed by the control server and sent to webhooks
+	var events *webcrawler.EventBus
+	if cmdArgs.controlAddr != "" || len(cmdArgs.webhooks) > 0 {
+		events = webcrawler.NewEventBus()
+	}
+
+	if len(cmdArgs.webhooks) > 0 {
+		stopWebhooks, err := startWebhooks(cmdArgs.webhooks, events, m.Deliveries, logger)
+		if err != nil {
+			return err
+		}
+		// deliver the events of the crawl before exit
+		defer stopWebhooks()
+	}
+
+	session, err := newCrawlSession(ctx, cmdArgs, q, m, loggers, logger, pl, events)
+	if err != nil {
+		exitCode = exitCodeError
+		if errors.Is(err, errCrawlerConfig) {
+			exitCode = exitCodeCrawlerConfig
+		}
 		return err
 	}
+	engine := session.engine
 
-	modifiedTransport := http.DefaultTransport.(*http.Transport).Clone()
-	modifiedTransport.MaxIdleConnsPerHost = 50
+	// dashboard displays the engine stats
+	var teaProg *tea.Program
+	if prettyLogger != nil {
+		teaProg = tea.NewProgram(newteaProgModel(engine, quit))
+		prettyLogger.teaProgram = teaProg
+	}
 
-	httpClient := &http.Client{
-		Timeout:   defaultTimeout,
-		Transport: modifiedTransport,
+	if cmdArgs.metricsAddr != "" {
+		metricsCtx, stopMetrics := context.WithCancel(ctx)
+		defer stopMetrics()
+		go serveMetrics(metricsCtx, cmdArgs.metricsAddr, loggers.fileLogger)
 	}
 
-	// init waitgroup
-	var wg sync.WaitGroup
+	if cmdArgs.controlAddr != "" {
+		controlCtx, stopControl := context.WithCancel(ctx)
+		defer stopControl()
+		go serveControl(controlCtx, cmdArgs.controlAddr, engine, events, loggers.fileLogger)
+	}
 
-	for _, crawler := range crawlerArmy {
-		wg.Add(1)
+	var summary webcrawler.Summary
+	var runErr error
+
+	if teaProg == nil {
+		summary, runErr = engine.Run(ctx)
+	} else {
+		engineDone := make(chan struct{})
 
 		go func() {
-			defer wg.Done()
-			crawler.Crawl(httpClient)
+			defer close(engineDone)
+			summary, runErr = engine.Run(ctx)
 		}()
 
+		if _, err := teaProg.Run(); err != nil {
+			fmt.Println("Error running program:", err)
+		}
+
+		// wait for crawlers
+		<-engineDone
 	}
 
-	if _, err := teaProg.Run(); err != nil {
-		fmt.Println("Error running program:", err)
+	session.finish(ctx, summary, runErr)
+
+	switch {
+	case errors.Is(runErr, webcrawler.ErrCrawlAborted):
+		exitCode = exitCodeCrawlAborted
+		return runErr
+	case errors.Is(runErr, webcrawler.ErrLoginFailed):
+		exitCode = exitCodeCrawlerConfig
+		return runErr
+	case summary.Interrupted:
+		exitCode = exitCodeInterrupted
 	}
 
-	// wait for crawlers
-	wg.Wait()
 	loggers.fileLogger.Println("Done")
-	fmt.Println(redStyle.Margin(0, 0, 1, 2).Render("Done"))
+	if !cmdArgs.noTUI {
+		fmt.Println(redStyle.Margin(0, 0, 1, 2).Render("Done"))
+	}
 	return nil
 }
+
+// logSummary writes the crawl summary to stdout and log file
+// and the errors encountered to log file
+func logSummary(summary webcrawler.Summary, loggers *loggers) {
+	loggers.multiLogger.Printf(
+		"Crawled %d URLs in %s; discovered: %d, saved: %d, failed: %d, dead: %d, "+
+			"fetch errors: %d, storage errors: %d, skipped: %d, interrupted: %t, drained: %t",
+		summary.URLsCrawled,
+		summary.Duration().Round(time.Millisecond),
+		summary.URLsDiscovered,
+		summary.PagesSaved,
+		summary.FailedRequests,
+		summary.DeadURLs,
+		summary.FetchErrors,
+		summary.StorageErrors,
+		summary.SkippedURLs,
+		summary.Interrupted,
+		summary.Drained,
+	)
+	for _, err := range summary.Errors {
+		loggers.fileLogger.Println(err)
+	}
+}
+
+// newCrawlRun returns a new crawl run with the effective options of cmdArgs
+func newCrawlRun(cmdArgs *cmdFlags) *models.CrawlRun {
+	seeds := make([]string, len(cmdArgs.seeds))
+	for i, seed := range cmdArgs.seeds {
+		seeds[i] = seed.String()
+	}
+	return models.NewCrawlRun(cmdArgs.baseURL.String(), models.RunOptions{
+		Seeds:          seeds,
+		Hosts:          cmdArgs.hostRules,
+		Scheme:         cmdArgs.scheme,
+		MarkedURLs:     cmdArgs.markedURLs,
+		IgnorePatterns: cmdArgs.ignorePattern,
+		Crawlers:       *cmdArgs.nCrawlers,
+		RequestDelay:   cmdArgs.reqDelay.String(),
+		HostRate:       cmdArgs.hostRate,
+		HostConns:      cmdArgs.hostConns,
+		AdaptiveDelay:  cmdArgs.adaptiveDelay,
+		RobotsTTL:      cmdArgs.robotsTTL.String(),
+		RetryTimes:     *cmdArgs.retryTime,
+		RetryBackoff:   cmdArgs.retryBackoff.String(),
+		ErrorPolicy:    cmdArgs.errorPolicy.String(),
+		Directives:     cmdArgs.directives.String(),
+		Extractors:     extractorNames(cmdArgs.extractors),
+		AuthFile:       cmdArgs.authFile,
+		VariantsFile:   cmdArgs.variantsFile,
+		Source:         sourcePath(cmdArgs.source),
+		Record:         cmdArgs.record,
+		Replay:         cmdArgs.replay,
+		Proxies:        redactProxies(cmdArgs.transport.Proxies),
+		CAFile:         cmdArgs.transport.CAFile,
+		ClientCert:     cmdArgs.transport.CertFile,
+		ClientKey:      cmdArgs.transport.KeyFile,
+		Insecure:       cmdArgs.transport.InsecureSkipVerify,
+		ConnectTimeout: cmdArgs.transport.ConnectTimeout.String(),
+		ReadTimeout:    cmdArgs.transport.ReadTimeout.String(),
+		Timeout:        cmdArgs.transport.Timeout.String(),
+		HTTP2:          !cmdArgs.transport.DisableHTTP2,
+		UserAgent:      *cmdArgs.userAgent,
+		UpdateDaysPast: *cmdArgs.updateDaysPast,
+		UpdateHrefs:    cmdArgs.updateHrefs,
+		Schedule:       cmdArgs.schedule,
+	})
+}
+
+// finishCrawlRun writes the summary and exit reason of run to model
+func finishCrawlRun(
+	ctx context.Context,
+	runs models.RunModel,
+	run *models.CrawlRun,
+	summary webcrawler.Summary,
+	runErr error,
+) error {
+	switch {
+	case errors.Is(runErr, webcrawler.ErrCrawlAborted):
+		run.ExitReason = models.RunAborted
+	case summary.Interrupted:
+		run.ExitReason = models.RunInterrupted
+	case runErr != nil:
+		run.ExitReason = models.RunFailed
+	case summary.Drained:
+		run.ExitReason = models.RunDrained
+	default:
+		run.ExitReason = models.RunCompleted
+	}
+
+	finishedAt := summary.FinishedAt
+	run.FinishedAt = &finishedAt
+	run.URLsCrawled = summary.URLsCrawled
+	run.URLsDiscovered = summary.URLsDiscovered
+	run.PagesSaved = summary.PagesSaved
+	run.Errors = summary.FetchErrors + summary.StorageErrors
+	run.DeadURLs = summary.DeadURLs
+
+	return runs.Update(ctx, run)
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+	"github.com/0x00f00bar/webcrawlerGo/queue"
+)
+
+// errCrawlRunning is returned when a crawl of the base URL is in progress
+var errCrawlRunning = errors.New("a crawl of this base URL is already running")
+
+// errJobsStopped is returned when starting a crawl after crawl jobs are stopped
+var errJobsStopped = errors.New("crawl jobs are stopped")
+
+// crawlJob is a crawl started from the web API
+type crawlJob struct {
+	session *crawlSession
+	cancel  context.CancelFunc
+	done    chan struct{} // closed when the crawl has finished and the run is recorded
+
+	mu sync.Mutex // guards session.run; updated when the crawl finishes
+}
+
+// crawlJobView is the JSON representation of a crawl job
+type crawlJobView struct {
+	Run      models.CrawlRun      `json:"run"`
+	Progress *webcrawler.Snapshot `json:"progress,omitempty"` // live stats; only while running
+}
+
+// view returns the current state of job
+func (job *crawlJob) view() crawlJobView {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	view := crawlJobView{Run: *job.session.run}
+	if job.running() {
+		snap := job.session.engine.Snapshot()
+		view.Progress = &snap
+	}
+	return view
+}
+
+// running tells if the crawl of job is in progress
+func (job *crawlJob) running() bool {
+	select {
+	case <-job.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// crawlJobs runs crawls in-process for the web API;
+// at most one crawl per base URL at a time
+type crawlJobs struct {
+	ctx     context.Context // parent of all crawls
+	cancel  context.CancelFunc
+	models  *models.Models
+	events  *webcrawler.EventBus // events of all crawls
+	loggers *loggers
+	logDir  string
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[uint]*crawlJob   // by run id
+	running map[string]*crawlJob // by base URL; nil while the crawl is being prepared
+}
+
+// newCrawlJobs returns pointer to new crawlJobs.
+// Crawls are cancelled when ctx is done or on stop.
+func newCrawlJobs(
+	ctx context.Context,
+	m *models.Models,
+	events *webcrawler.EventBus,
+	loggers *loggers,
+	logDir string,
+) *crawlJobs {
+	ctx, cancel := context.WithCancel(ctx)
+	return &crawlJobs{
+		ctx:     ctx,
+		cancel:  cancel,
+		models:  m,
+		events:  events,
+		loggers: loggers,
+		logDir:  logDir,
+		jobs:    make(map[uint]*crawlJob),
+		running: make(map[string]*crawlJob),
+	}
+}
+
+// start prepares a crawl with cmdArgs and runs it in background.
+// Returns errCrawlRunning when a crawl of the same base URL is running.
+func (cj *crawlJobs) start(cmdArgs *cmdFlags) (*crawlJob, error) {
+	baseURL := cmdArgs.baseURL.String()
+
+	// reserve base URL while the crawl is being prepared
+	cj.mu.Lock()
+	if cj.ctx.Err() != nil {
+		cj.mu.Unlock()
+		return nil, errJobsStopped
+	}
+	if _, ok := cj.running[baseURL]; ok {
+		cj.mu.Unlock()
+		return nil, errCrawlRunning
+	}
+	cj.running[baseURL] = nil
+	cj.wg.Add(1)
+	cj.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(cj.ctx)
+	logger := cj.loggers.crawlerLogger(false).With("base_url", baseURL)
+
+	session, err := newCrawlSession(ctx, cmdArgs, queue.NewQueue(), cj.models, cj.loggers, logger, nil, cj.events)
+	if err != nil {
+		cancel()
+		cj.mu.Lock()
+		delete(cj.running, baseURL)
+		cj.mu.Unlock()
+		cj.wg.Done()
+		return nil, err
+	}
+
+	job := &crawlJob{
+		session: session,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	cj.mu.Lock()
+	cj.jobs[session.run.ID] = job
+	cj.running[baseURL] = job
+	cj.mu.Unlock()
+
+	go func() {
+		defer cj.wg.Done()
+		defer cancel()
+
+		summary, runErr := session.engine.Run(ctx)
+
+		job.mu.Lock()
+		session.finish(ctx, summary, runErr)
+		job.mu.Unlock()
+
+		cj.mu.Lock()
+		delete(cj.running, baseURL)
+		cj.mu.Unlock()
+		close(job.done)
+	}()
+
+	return job, nil
+}
+
+// get returns the job with run id
+func (cj *crawlJobs) get(id uint) (*crawlJob, bool) {
+	cj.mu.Lock()
+	defer cj.mu.Unlock()
+	job, ok := cj.jobs[id]
+	return job, ok
+}
+
+// stop cancels all crawls and waits for their runs to be recorded.
+// Crawls cannot be started after stop.
+func (cj *crawlJobs) stop() {
+	cj.mu.Lock()
+	cj.cancel()
+	cj.mu.Unlock()
+	cj.wg.Wait()
+}
+
+// crawlJobInput holds the crawl options accepted by POST /v1/crawl.
+// Keys are the same as the options recorded with a crawl run,
+// except extract_* which are recorded as the names of extractors.
+type crawlJobInput struct {
+	BaseURL           string   `json:"baseurl"`
+	Seeds             []string `json:"seeds"`
+	Hosts             []string `json:"hosts"`
+	Scheme            string   `json:"scheme"`
+	MarkedURLs        []string `json:"murls"`
+	IgnorePatterns    []string `json:"ignore"`
+	Crawlers          *int     `json:"n"`
+	RequestDelay      string   `json:"req_delay"`
+	HostRate          float64  `json:"host_rps"`
+	HostConns         int      `json:"host_conns"`
+	AdaptiveDelay     *bool    `json:"adaptive_delay"`
+	RobotsTTL         string   `json:"robots_ttl"`
+	RetryTimes        *int     `json:"retry"`
+	RetryBackoff      string   `json:"retry_backoff"`
+	ErrorPolicy       string   `json:"on_error"`
+	Directives        string   `json:"robots_directives"`
+	ExtractHTML       *bool    `json:"extract_html"`
+	ExtractSrcset     bool     `json:"extract_srcset"`
+	ExtractForm       bool     `json:"extract_form"`
+	ExtractJSONLD     bool     `json:"extract_jsonld"`
+	ExtractLinkHeader bool     `json:"extract_link_header"`
+	ExtractFeed       bool     `json:"extract_feed"`
+	ExtractSitemap    bool     `json:"extract_sitemap"`
+	AuthFile          string   `json:"auth"`
+	VariantsFile      string   `json:"variants"`
+	Proxies           []string `json:"proxies"`
+	CAFile            string   `json:"ca_file"`
+	ClientCert        string   `json:"client_cert"`
+	ClientKey         string   `json:"client_key"`
+	Insecure          bool     `json:"insecure"`
+	ConnectTimeout    string   `json:"connect_timeout"`
+	ReadTimeout       string   `json:"read_timeout"`
+	Timeout           string   `json:"timeout"`
+	HTTP2             *bool    `json:"http2"`
+	UserAgent         string   `json:"ua"`
+	UpdateDaysPast    *int     `json:"days"`
+	UpdateHrefs       bool     `json:"update_hrefs"`
+}
+
+// cmdFlags returns the cmdFlags of input with defaults of the cmd flags
+// for missing options. Validation errors are added to v, keyed by flag name.
+func (input crawlJobInput) cmdFlags(v *internal.Validator, logDir string) *cmdFlags {
+	orDefault := func(s, defaultValue string) string {
+		if s == "" {
+			return defaultValue
+		}
+		return s
+	}
+	intOrDefault := func(i *int, defaultValue int) *int {
+		if i == nil {
+			return &defaultValue
+		}
+		return i
+	}
+	boolOrDefault := func(b *bool, defaultValue bool) bool {
+		if b == nil {
+			return defaultValue
+		}
+		return *b
+	}
+	parseDuration := func(key, s string) time.Duration {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			v.AddError(key, err.Error())
+		}
+		return d
+	}
+
+	// files on the host of the API or daemon are not crawled for their clients
+	var parsedBaseURL *url.URL
+	var source *localSource
+	if isLocalSource(input.BaseURL) {
+		v.AddError("baseurl", "dir: and file:// base URLs can only be crawled with the crawl command")
+		parsedBaseURL = &url.URL{}
+	} else {
+		parsedBaseURL, source = parseBaseURL(v, input.BaseURL, "")
+	}
+	// nor are the files of auth, variants and certificates, and
+	// verification of certificates cannot be disabled
+	serverOptions := []struct {
+		key string
+		set bool
+	}{
+		{key: "auth", set: input.AuthFile != ""},
+		{key: "variants", set: input.VariantsFile != ""},
+		{key: "ca-file", set: input.CAFile != ""},
+		{key: "client-cert", set: input.ClientCert != ""},
+		{key: "client-key", set: input.ClientKey != ""},
+		{key: "insecure", set: input.Insecure},
+	}
+	for _, opt := range serverOptions {
+		v.Check(!opt.set, opt.key, "can only be used with the crawl command")
+	}
+
+	errorPolicy, err := webcrawler.ParseErrorPolicy(orDefault(input.ErrorPolicy, defaultOnError))
+	if err != nil {
+		v.AddError("on-error", err.Error())
+	}
+	directives, err := webcrawler.ParseDirectivePolicy(orDefault(input.Directives, defaultDirectives))
+	if err != nil {
+		v.AddError("robots-directives", err.Error())
+	}
+	extract := map[string]bool{
+		"html":        boolOrDefault(input.ExtractHTML, defaultExtractHTML),
+		"srcset":      input.ExtractSrcset,
+		"form":        input.ExtractForm,
+		"jsonld":      input.ExtractJSONLD,
+		"link-header": input.ExtractLinkHeader,
+		"feed":        input.ExtractFeed,
+		"sitemap":     input.ExtractSitemap,
+	}
+	extractors := enabledExtractors(v, func(name string) bool { return extract[name] })
+
+	transport := &webcrawler.TransportConfig{
+		Proxies:        input.Proxies,
+		ConnectTimeout: parseDuration("connect-timeout", orDefault(input.ConnectTimeout, defaultConnectTimeout)),
+		ReadTimeout:    parseDuration("read-timeout", orDefault(input.ReadTimeout, defaultReadTimeout)),
+		Timeout:        parseDuration("timeout", orDefault(input.Timeout, defaultRequestTimeout)),
+		DisableHTTP2:   !boolOrDefault(input.HTTP2, defaultHTTP2),
+	}
+
+	dbDSN := ""
+	userAgent := orDefault(input.UserAgent, defaultUserAgent)
+
+	cmdArgs := &cmdFlags{
+		baseURL:        parsedBaseURL,
+		source:         source,
+		seeds:          parseSeeds(v, input.Seeds),
+		hostRules:      input.Hosts,
+		scheme:         input.Scheme,
+		nCrawlers:      intOrDefault(input.Crawlers, defaultCrawlers),
+		updateDaysPast: intOrDefault(input.UpdateDaysPast, defaultUpdateDays),
+		markedURLs:     getMarkedURLS(strings.Join(input.MarkedURLs, ",")),
+		ignorePattern:  seperateCmdArgs(strings.Join(input.IgnorePatterns, ",")),
+		dbDSN:          &dbDSN,
+		userAgent:      &userAgent,
+		transport:      transport,
+		reqDelay:       parseDuration("req-delay", orDefault(input.RequestDelay, defaultReqDelay)),
+		hostRate:       input.HostRate,
+		hostConns:      input.HostConns,
+		adaptiveDelay:  boolOrDefault(input.AdaptiveDelay, defaultAdaptiveDelay),
+		robotsTTL:      parseDuration("robots-ttl", orDefault(input.RobotsTTL, defaultRobotsTTL)),
+		idleTimeout:    parseDuration("idle-time", defaultIdleTime),
+		retryTime:      intOrDefault(input.RetryTimes, defaultRetry),
+		retryBackoff:   parseDuration("retry-backoff", orDefault(input.RetryBackoff, defaultRetryBackoff)),
+		errorPolicy:    errorPolicy,
+		directives:     directives,
+		extractors:     extractors,
+		updateHrefs:    input.UpdateHrefs,
+		noTUI:          true,
+		quiet:          true,
+		logDir:         logDir,
+	}
+
+	if v.Valid() {
+		validateFlags(v, cmdArgs)
+	}
+	return cmdArgs
+}
+
+func (app *webapp) createCrawlHandler(w http.ResponseWriter, r *http.Request) {
+	var input crawlJobInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := internal.NewValidator()
+	cmdArgs := input.cmdFlags(v, app.Jobs.logDir)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	job, err := app.Jobs.start(cmdArgs)
+	if err != nil {
+		switch {
+		case errors.Is(err, errCrawlRunning):
+			app.errorResponse(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, errJobsStopped):
+			app.errorResponse(w, r, http.StatusServiceUnavailable, err.Error())
+		case errors.Is(err, errCrawlerConfig):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.Logger.Printf("started crawl #%d of %s", job.session.run.ID, cmdArgs.baseURL)
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/crawl/%d", job.session.run.ID))
+
+	err = app.writeJSON(w, http.StatusAccepted, envelope{"crawl": job.view()}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *webapp) getCrawlHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	if job, ok := app.Jobs.get(uint(id)); ok {
+		err = app.writeJSON(w, http.StatusOK, envelope{"crawl": job.view()}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// crawls not started by this server are shown from their recorded run
+	run, err := app.Models.Runs.GetById(r.Context(), int(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"crawl": crawlJobView{Run: *run}}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// cancelCrawlHandler cancels a running crawl and waits for its run to be recorded
+func (app *webapp) cancelCrawlHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	job, ok := app.Jobs.get(uint(id))
+	if !ok {
+		app.notFoundResponse(w, r)
+		return
+	}
+	if !job.running() {
+		app.errorResponse(w, r, http.StatusConflict, "crawl is not running")
+		return
+	}
+
+	job.cancel()
+	select {
+	case <-job.done:
+	case <-r.Context().Done():
+		return
+	}
+	app.Logger.Printf("cancelled crawl #%d", id)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"crawl": job.view()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+	"github.com/0x00f00bar/webcrawlerGo/schedule"
+)
+
+// crawlDefinition is a crawl run by the daemon on schedule
+type crawlDefinition struct {
+	name       string
+	spec       string            // schedule as written in the definition file
+	schedule   schedule.Schedule // activation times of the crawl
+	quietHours *schedule.Window  // daily window in which crawls are not run; nil when not set
+	cmdArgs    *cmdFlags
+}
+
+// crawlDefinitionInput is a crawl in the daemon definition file.
+// Crawl options are the same as the options accepted by POST /v1/crawl.
+type crawlDefinitionInput struct {
+	Name       string `json:"name"`
+	Schedule   string `json:"schedule"`    // cron expression, macro or interval
+	QuietHours string `json:"quiet_hours"` // HH:MM-HH:MM in local time
+	crawlJobInput
+}
+
+// loadCrawlDefinitions reads and validates the crawl definitions in
+// the JSON file at path, of the form {"crawls": [...]}
+func loadCrawlDefinitions(path, logDir string) ([]*crawlDefinition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var input struct {
+		Crawls []crawlDefinitionInput `json:"crawls"`
+	}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&input); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(input.Crawls) == 0 {
+		return nil, fmt.Errorf("%s: no crawls defined", path)
+	}
+
+	var defs []*crawlDefinition
+	for i, in := range input.Crawls {
+		if in.Name == "" {
+			return nil, fmt.Errorf("%s: crawl #%d: name must be provided", path, i+1)
+		}
+		if slices.ContainsFunc(defs, func(def *crawlDefinition) bool { return def.name == in.Name }) {
+			return nil, fmt.Errorf("%s: crawl %q: name must be unique", path, in.Name)
+		}
+
+		v := internal.NewValidator()
+		def := &crawlDefinition{name: in.Name, spec: in.Schedule}
+
+		def.schedule, err = schedule.Parse(in.Schedule)
+		if err != nil {
+			v.AddError("schedule", err.Error())
+		}
+		if in.QuietHours != "" {
+			window, err := schedule.ParseWindow(in.QuietHours)
+			if err != nil {
+				v.AddError("quiet_hours", err.Error())
+			}
+			def.quietHours = &window
+		}
+
+		def.cmdArgs = in.crawlJobInput.cmdFlags(v, logDir)
+		def.cmdArgs.schedule = in.Name
+
+		if !v.Valid() {
+			return nil, fmt.Errorf("%s: crawl %q: %s", path, in.Name, validationErrors(v))
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// validationErrors returns the errors of v as a single line sorted by key
+func validationErrors(v *internal.Validator) string {
+	keys := make([]string, 0, len(v.Errors))
+	for key := range v.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var s string
+	for i, key := range keys {
+		if i > 0 {
+			s += "; "
+		}
+		s += key + ": " + v.Errors[key]
+	}
+	return s
+}
+
+// scheduledCrawl is the state of a crawl definition in the daemon
+type scheduledCrawl struct {
+	def *crawlDefinition
+
+	mu        sync.Mutex
+	next      time.Time // next activation; zero while running
+	job       *crawlJob // running or last crawl started by the daemon
+	lastRunID uint
+}
+
+// scheduledCrawlView is the JSON representation of a scheduled crawl
+type scheduledCrawlView struct {
+	Name       string     `json:"name"`
+	BaseURL    string     `json:"base_url"`
+	Schedule   string     `json:"schedule"`
+	QuietHours string     `json:"quiet_hours,omitempty"`
+	NextRun    *time.Time `json:"next_run"` // nil while running
+	LastRunID  uint       `json:"last_run_id,omitempty"`
+	Running    bool       `json:"running"`
+}
+
+func (sc *scheduledCrawl) view() scheduledCrawlView {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	view := scheduledCrawlView{
+		Name:      sc.def.name,
+		BaseURL:   sc.def.cmdArgs.baseURL.String(),
+		Schedule:  sc.def.spec,
+		LastRunID: sc.lastRunID,
+		Running:   sc.job != nil && sc.job.running(),
+	}
+	if sc.def.quietHours != nil {
+		view.QuietHours = sc.def.quietHours.String()
+	}
+	if !sc.next.IsZero() {
+		next := sc.next
+		view.NextRun = &next
+	}
+	return view
+}
+
+// daemon starts the crawl definitions on schedule as crawl jobs.
+// A crawl is not started again until its previous run has finished.
+type daemon struct {
+	jobs   *crawlJobs
+	runs   models.RunModel
+	logger *log.Logger
+	crawls []*scheduledCrawl
+	wg     sync.WaitGroup
+}
+
+// newDaemon returns pointer to a new daemon running defs with jobs
+func newDaemon(defs []*crawlDefinition, jobs *crawlJobs, runs models.RunModel, logger *log.Logger) *daemon {
+	d := &daemon{jobs: jobs, runs: runs, logger: logger}
+	for _, def := range defs {
+		d.crawls = append(d.crawls, &scheduledCrawl{def: def})
+	}
+	return d
+}
+
+// start runs the schedule of each crawl in background
+// until the crawl jobs are stopped
+func (d *daemon) start() {
+	for _, sc := range d.crawls {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.run(d.jobs.ctx, sc)
+		}()
+	}
+}
+
+// wait waits for the schedules to return
+func (d *daemon) wait() {
+	d.wg.Wait()
+}
+
+// run starts the crawl of sc at each activation until ctx is done
+func (d *daemon) run(ctx context.Context, sc *scheduledCrawl) {
+	def := sc.def
+	next := d.firstRun(ctx, sc)
+
+	for {
+		// runs due in quiet hours are deferred to the end of quiet hours
+		if def.quietHours != nil && def.quietHours.Contains(next) {
+			next = def.quietHours.End(next)
+		}
+		if next.IsZero() {
+			d.logger.Printf("crawl %q: schedule %q has no next run", def.name, def.spec)
+			return
+		}
+
+		sc.mu.Lock()
+		sc.next = next
+		sc.mu.Unlock()
+		d.logger.Printf("crawl %q: next run at %s", def.name, next.Format(time.DateTime))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		job, err := d.jobs.start(def.cmdArgs)
+		if err != nil {
+			if errors.Is(err, errJobsStopped) {
+				return
+			}
+			d.logger.Printf("crawl %q: skipped run: %v", def.name, err)
+			next = def.schedule.Next(time.Now())
+			continue
+		}
+
+		sc.mu.Lock()
+		sc.next = time.Time{}
+		sc.job = job
+		sc.lastRunID = job.session.run.ID
+		sc.mu.Unlock()
+		d.logger.Printf("crawl %q: started run #%d of %s", def.name, job.session.run.ID, def.cmdArgs.baseURL)
+
+		d.waitForJob(sc, job)
+
+		job.mu.Lock()
+		exitReason := job.session.run.ExitReason
+		job.mu.Unlock()
+		d.logger.Printf("crawl %q: run #%d finished: %s", def.name, job.session.run.ID, exitReason)
+
+		next = def.schedule.Next(time.Now())
+	}
+}
+
+// firstRun returns the first activation of sc. Interval schedules continue
+// from the last run recorded for the crawl, so that restarting the daemon
+// does not crawl again before the interval has passed.
+func (d *daemon) firstRun(ctx context.Context, sc *scheduledCrawl) time.Time {
+	now := time.Now()
+	interval, ok := sc.def.schedule.(schedule.Interval)
+	if !ok {
+		return sc.def.schedule.Next(now)
+	}
+
+	var safeSortList []string
+	safeSortList = append(safeSortList, models.RunColumns...)
+	safeSortList = append(safeSortList, internal.PrefixString(models.RunColumns, "-")...)
+
+	runs, err := d.runs.GetAll(
+		ctx,
+		models.RunFilter{BaseURL: sc.def.cmdArgs.baseURL.String()},
+		models.CommonFilters{Page: 1, PageSize: 20, Sort: "-id", SortSafeList: safeSortList},
+	)
+	if err != nil {
+		d.logger.Printf("crawl %q: could not get last run: %v", sc.def.name, err)
+		return now
+	}
+
+	// base URL filter matches URLs containing the base URL
+	for _, run := range runs {
+		if run.BaseURL != sc.def.cmdArgs.baseURL.String() || run.Options.Schedule != sc.def.name {
+			continue
+		}
+		sc.mu.Lock()
+		sc.lastRunID = run.ID
+		sc.mu.Unlock()
+		if next := interval.Next(run.StartedAt); next.After(now) {
+			return next
+		}
+		break
+	}
+	return now
+}
+
+// waitForJob waits for job to finish. The crawl is paused
+// while in the quiet hours of sc.
+func (d *daemon) waitForJob(sc *scheduledCrawl, job *crawlJob) {
+	quiet := sc.def.quietHours
+	if quiet == nil {
+		<-job.done
+		return
+	}
+
+	paused := false
+	for {
+		now := time.Now()
+		var at time.Time
+		switch {
+		case paused:
+			at = quiet.End(now)
+		case quiet.Contains(now):
+			at = now
+		default:
+			at = quiet.Start(now)
+		}
+
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-job.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if paused {
+			err := job.session.engine.Resume()
+			if err == nil {
+				d.logger.Printf("crawl %q: quiet hours ended, resumed run #%d", sc.def.name, job.session.run.ID)
+			}
+		} else {
+			err := job.session.engine.Pause()
+			if err == nil {
+				d.logger.Printf("crawl %q: quiet hours began, paused run #%d", sc.def.name, job.session.run.ID)
+			}
+		}
+		paused = !paused
+	}
+}
+
+// runDaemon runs the crawls of defs on schedule while serving the web API
+func (app *webapp) runDaemon(ctx context.Context, defs []*crawlDefinition, quitChan chan os.Signal) error {
+	app.Daemon = newDaemon(defs, app.Jobs, app.Models.Runs, app.Logger)
+	app.Daemon.start()
+
+	err := app.serve(ctx, quitChan)
+
+	// stop crawls when the server failed to start
+	app.Jobs.stop()
+	app.Daemon.wait()
+	return err
+}
+
+func (app *webapp) listScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	crawls := []scheduledCrawlView{}
+	for _, sc := range app.Daemon.crawls {
+		crawls = append(crawls, sc.view())
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"schedule": crawls}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
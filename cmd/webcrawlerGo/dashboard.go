@@ -0,0 +1,488 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+)
+
+const (
+	maxLogRecords       = 1000                   // log records kept for the log pane
+	defaultLogPaneLines = 10                     // log pane height before window size is known
+	minLogPaneLines     = 5                      // minimum log pane height
+	dashboardRefresh    = 500 * time.Millisecond // interval to refresh engine snapshot
+)
+
+var (
+	spinnerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Margin(1, 0)
+	appStyle     = lipgloss.NewStyle().Margin(1, 2, 0, 2)
+	labelStyle   = grayStyle
+	paneStyle    = lipgloss.NewStyle().Bold(true)
+
+	crawlerStateStyleMap = map[webcrawler.CrawlerState]*lipgloss.Style{
+		webcrawler.CrawlerIdle:       &grayStyle,
+		webcrawler.CrawlerFetching:   &cyanStyle,
+		webcrawler.CrawlerProcessing: &yellowStyle,
+		webcrawler.CrawlerSaving:     &greenStyle,
+		webcrawler.CrawlerWaiting:    &grayStyle,
+		webcrawler.CrawlerPaused:     &yellowStyle,
+		webcrawler.CrawlerStopped:    &redStyle,
+	}
+
+	// log levels cycled through by the level filter
+	filterLevels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+)
+
+// tickMsg is sent every dashboardRefresh to refresh the snapshot
+type tickMsg time.Time
+
+// controlMsg is the result of a control command sent to the engine
+type controlMsg struct {
+	text string // what was done
+	err  error
+}
+
+// sendControl returns a [tea.Cmd] sending cmd to engine
+func sendControl(engine *webcrawler.Engine, cmd webcrawler.Command, text string) tea.Cmd {
+	return func() tea.Msg {
+		return controlMsg{text: text, err: engine.Control(cmd)}
+	}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(dashboardRefresh, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// teaProgModel for [tea.Program]; renders the crawl dashboard
+// with stats, per-crawler rows and a scrollable log pane
+type teaProgModel struct {
+	spinner  spinner.Model
+	engine   *webcrawler.Engine
+	snapshot webcrawler.Snapshot
+	records  []slog.Record // last maxLogRecords log records
+	minLevel slog.Level    // level filter of log pane
+	search   string        // search filter of log pane
+	// true while search string is being typed
+	searching bool
+	scroll    int // log lines scrolled up from the latest
+	width     int
+	height    int
+	control   controlMsg // result of the last control command
+	quitting  bool       // quit by user; crawlers are interrupted
+	finished  bool       // all crawlers have exited
+	quitChan  chan os.Signal
+}
+
+// newteaProgModel returns new teaProgModel displaying stats of engine
+func newteaProgModel(engine *webcrawler.Engine, sigChan chan os.Signal) teaProgModel {
+	s := spinner.New()
+	s.Style = spinnerStyle
+	return teaProgModel{
+		spinner:  s,
+		engine:   engine,
+		snapshot: engine.Snapshot(),
+		minLevel: slog.LevelInfo,
+		quitChan: sigChan,
+	}
+}
+
+func (m teaProgModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, tick())
+}
+
+func (m teaProgModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case logMsg:
+		m.records = append(m.records, slog.Record(msg))
+		if len(m.records) > maxLogRecords {
+			m.records = m.records[len(m.records)-maxLogRecords:]
+		}
+		// keep the view still while scrolled up
+		if m.scroll > 0 && m.matches(slog.Record(msg)) {
+			m.scroll++
+		}
+		return m, nil
+	case tickMsg:
+		m.snapshot = m.engine.Snapshot()
+		return m, tick()
+	case controlMsg:
+		m.control = msg
+		m.snapshot = m.engine.Snapshot()
+		return m, nil
+	case engineDoneMsg:
+		m.finished = true
+		m.snapshot = m.engine.Snapshot()
+		return m, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	default:
+		return m, nil
+	}
+}
+
+// handleKey handles key presses for scrolling, filtering, searching
+// and controlling the engine
+func (m teaProgModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		m.quitting = true
+		return m, tea.Quit
+	}
+
+	if m.searching {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.searching = false
+		case tea.KeyEsc:
+			m.searching = false
+			m.search = ""
+		case tea.KeyBackspace:
+			if len(m.search) > 0 {
+				runes := []rune(m.search)
+				m.search = string(runes[:len(runes)-1])
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.search += string(msg.Runes)
+		}
+		m.scroll = 0
+		return m, nil
+	}
+
+	pageLines := m.logPaneLines()
+
+	switch msg.String() {
+	case "q":
+		m.quitting = true
+		return m, tea.Quit
+	case "up", "k":
+		m.scroll++
+	case "down", "j":
+		m.scroll--
+	case "pgup":
+		m.scroll += pageLines
+	case "pgdown":
+		m.scroll -= pageLines
+	case "home", "g":
+		m.scroll = len(m.records)
+	case "end", "G":
+		m.scroll = 0
+	case "l":
+		i := slices.Index(filterLevels, m.minLevel)
+		m.minLevel = filterLevels[(i+1)%len(filterLevels)]
+		m.scroll = 0
+	case "/":
+		m.searching = true
+		m.search = ""
+	case "esc":
+		m.search = ""
+		m.scroll = 0
+	default:
+		return m, m.controlKey(msg.String())
+	}
+
+	m.scroll = max(0, min(m.scroll, len(m.filteredRecords())-pageLines))
+	return m, nil
+}
+
+// controlKey returns the [tea.Cmd] sending the control command bound
+// to key; nil when key is not bound
+func (m teaProgModel) controlKey(key string) tea.Cmd {
+	snap := m.snapshot
+
+	switch key {
+	case "p":
+		if snap.Paused {
+			return sendControl(m.engine, webcrawler.Command{Op: webcrawler.OpResume}, "resumed")
+		}
+		return sendControl(m.engine, webcrawler.Command{Op: webcrawler.OpPause}, "paused")
+	case "+", "=":
+		delay := snap.RequestDelay * 2
+		return sendControl(
+			m.engine,
+			webcrawler.Command{Op: webcrawler.OpSetDelay, Delay: delay},
+			"request delay set to "+delay.String(),
+		)
+	case "-":
+		delay := max(time.Millisecond, snap.RequestDelay/2)
+		return sendControl(
+			m.engine,
+			webcrawler.Command{Op: webcrawler.OpSetDelay, Delay: delay},
+			"request delay set to "+delay.String(),
+		)
+	case "a":
+		return sendControl(m.engine, webcrawler.Command{Op: webcrawler.OpAddCrawlers, N: 1}, "added a crawler")
+	case "x":
+		return sendControl(
+			m.engine,
+			webcrawler.Command{Op: webcrawler.OpRemoveCrawlers, N: 1},
+			"removing a crawler",
+		)
+	case "d":
+		return sendControl(
+			m.engine,
+			webcrawler.Command{Op: webcrawler.OpDrain},
+			"draining; remaining queue will be saved",
+		)
+	}
+	return nil
+}
+
+// matches tells if r passes the level and search filter
+func (m teaProgModel) matches(r slog.Record) bool {
+	if r.Level < m.minLevel {
+		return false
+	}
+	if m.search == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(recordText(r)), strings.ToLower(m.search))
+}
+
+// filteredRecords returns the records passing the filters
+func (m teaProgModel) filteredRecords() []slog.Record {
+	var records []slog.Record
+	for _, r := range m.records {
+		if m.matches(r) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// logPaneLines returns the number of log lines which fit the window
+func (m teaProgModel) logPaneLines() int {
+	if m.height == 0 {
+		return defaultLogPaneLines
+	}
+	// header, crawler rows, pane titles, control, help and margins
+	used := 17 + len(m.snapshot.Crawlers)
+	return max(minLogPaneLines, m.height-used)
+}
+
+func (m teaProgModel) View() string {
+	var b strings.Builder
+
+	if m.quitting || m.finished {
+		b.WriteString(cyanStyle.Render("Aaand... we're done!"))
+	} else {
+		b.WriteString(m.spinner.View() + redStyle.Render(" Crawlers be crawling..."))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString(m.statsView())
+	b.WriteString("\n")
+	b.WriteString(m.crawlersView())
+	b.WriteString("\n")
+	b.WriteString(m.logPaneView())
+
+	if !m.quitting && !m.finished {
+		b.WriteString(m.controlView())
+		b.WriteString(helpStyle.Render(
+			"↑/↓ pgup/pgdn scroll • l level • / search • esc clear search • q quit\n" +
+				"p pause/resume • +/- request delay • a add crawler • x remove crawler • d drain",
+		))
+	}
+
+	if m.quitting {
+		m.quitChan <- syscall.SIGINT
+		b.WriteString("\n" + redStyle.Render("Waiting for crawlers to quit... ") + "\n\n")
+	}
+
+	return appStyle.Render(b.String())
+}
+
+// controlView renders the control state and the result
+// of the last control command
+func (m teaProgModel) controlView() string {
+	snap := m.snapshot
+
+	state := greenStyle.Render("running")
+	switch {
+	case snap.Draining:
+		state = redStyle.Render("draining")
+	case snap.Paused:
+		state = yellowStyle.Render("paused")
+	}
+	line := statLine(
+		"Control", state,
+		"Crawlers", fmt.Sprintf("%d/%d", snap.ActiveCrawlers, len(snap.Crawlers)),
+		"Request delay", snap.RequestDelay.String(),
+	)
+
+	switch {
+	case m.control.err != nil:
+		line += "  " + redStyle.Render(m.control.err.Error())
+	case m.control.text != "":
+		line += "  " + cyanStyle.Render(m.control.text)
+	}
+	return "\n" + line
+}
+
+// statsView renders the engine stats
+func (m teaProgModel) statsView() string {
+	snap := m.snapshot
+
+	eta := "-"
+	if d := snap.ETA(); d >= 0 {
+		eta = d.Round(time.Second).String()
+	}
+
+	lines := []string{
+		statLine(
+			"Elapsed", snap.Elapsed().Round(time.Second).String(),
+			"ETA", eta,
+			"Queue", fmt.Sprint(snap.QueueSize),
+			"In flight", fmt.Sprint(snap.InFlight),
+		),
+		statLine(
+			"Crawled", fmt.Sprintf("%d (%.1f/s)", snap.URLsCrawled, snap.Rate()),
+			"Discovered", fmt.Sprint(snap.URLsDiscovered),
+			"Saved", fmt.Sprint(snap.PagesSaved),
+			"Dead", fmt.Sprint(snap.DeadURLs),
+		),
+		statLine(
+			"Errors", fmt.Sprintf(
+				"%d (%.1f%%)",
+				snap.FetchErrors+snap.StorageErrors,
+				snap.ErrorRate()*100,
+			),
+			"Failed requests", fmt.Sprint(snap.FailedRequests),
+			"Skipped", fmt.Sprint(snap.SkippedURLs),
+		),
+	}
+
+	codes := make([]int, 0, len(snap.StatusCodes))
+	for code := range snap.StatusCodes {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	var statuses []string
+	for _, code := range codes {
+		statuses = append(statuses, fmt.Sprintf(
+			"%s=%d",
+			statusCodeStyle(code).Render(fmt.Sprint(code)),
+			snap.StatusCodes[code],
+		))
+	}
+	if len(statuses) == 0 {
+		statuses = append(statuses, "-")
+	}
+	lines = append(lines, labelStyle.Render("Status: ")+strings.Join(statuses, "  "))
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// statLine renders label and value pairs on a single line
+func statLine(pairs ...string) string {
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, labelStyle.Render(pairs[i]+": ")+pairs[i+1])
+	}
+	return strings.Join(parts, "  ")
+}
+
+// statusCodeStyle returns the style of an HTTP status code
+func statusCodeStyle(code int) lipgloss.Style {
+	switch {
+	case code < 300:
+		return greenStyle
+	case code < 400:
+		return cyanStyle
+	case code < 500:
+		return yellowStyle
+	default:
+		return redStyle
+	}
+}
+
+// crawlersView renders a row per crawler with its state and URL
+func (m teaProgModel) crawlersView() string {
+	var b strings.Builder
+	b.WriteString(paneStyle.Render("Crawlers") + "\n")
+
+	urlWidth := 0
+	if m.width > 0 {
+		urlWidth = max(10, m.width-40)
+	}
+
+	for _, status := range m.snapshot.Crawlers {
+		style, ok := crawlerStateStyleMap[status.State]
+		if !ok {
+			style = &grayStyle
+		}
+		url := status.URL
+		if urlWidth > 0 && len(url) > urlWidth {
+			url = url[:urlWidth-3] + "..."
+		}
+		fmt.Fprintf(
+			&b,
+			"%s %s %6s %s\n",
+			cyanStyle.Render(status.Name),
+			style.Render(fmt.Sprintf("%-10s", status.State)),
+			time.Since(status.Since).Round(100*time.Millisecond),
+			url,
+		)
+	}
+	return b.String()
+}
+
+// logPaneView renders the filtered log records as per scroll
+func (m teaProgModel) logPaneView() string {
+	var b strings.Builder
+
+	title := paneStyle.Render("Logs") + labelStyle.Render(" level>="+m.minLevel.String())
+	switch {
+	case m.searching:
+		title += labelStyle.Render(" search: ") + m.search + "█"
+	case m.search != "":
+		title += labelStyle.Render(" search: ") + m.search
+	}
+	if m.scroll > 0 {
+		title += yellowStyle.Render(fmt.Sprintf(" (scrolled up %d)", m.scroll))
+	}
+	b.WriteString(title + "\n")
+
+	records := m.filteredRecords()
+	lines := m.logPaneLines()
+	end := len(records) - m.scroll
+	start := max(0, end-lines)
+
+	for _, r := range records[start:end] {
+		b.WriteString(renderRecord(r) + "\n")
+	}
+	// keep pane height constant
+	for range lines - (end - start) {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// recordText returns the plain text of r used for searching
+func recordText(r slog.Record) string {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%s", a.Key, a.Value.String())
+		return true
+	})
+	return b.String()
+}
@@ -149,6 +149,61 @@ func getDBConnections(
 	return driverName, dbConns, nil
 }
 
+// openModels connects to the database of dsn, creates the tables
+// of the models and returns them. closeDB closes the connections.
+func openModels(
+	ctx context.Context,
+	dsn string,
+	loggers *loggers,
+) (m *models.Models, closeDB func(), err error) {
+	driverName, dbConns, err := getDBConnections(dsn, loggers)
+	if err != nil {
+		return nil, nil, err
+	}
+	loggers.multiLogger.Println("DB connection OK.")
+
+	closeDB = func() {
+		// if the driver used is sqlite3 consolidate the WAL journal to db
+		// before closing connection
+		sqlite.ExecWALCheckpoint(driverName, dbConns.writer)
+		dbConns.Close()
+	}
+
+	m = &models.Models{}
+
+	// get postgres models and initialise database tables
+	if driverName == psql.DriverNamePgSQL {
+		psqlModels := psql.NewPsqlDB(dbConns.writer)
+		err = psqlModels.InitDatabase(ctx, dbConns.writer)
+		if err != nil {
+			closeDB()
+			return nil, nil, err
+		}
+		m.URLs = psqlModels.URLModel
+		m.Pages = psqlModels.PageModel
+		m.Runs = psqlModels.RunModel
+		m.Deliveries = psqlModels.DeliveryModel
+		m.Stats = psqlModels.StatsModel
+		m.Robots = psqlModels.RobotsModel
+	}
+	// get sqlite3 models and initialise database tables
+	if driverName == sqlite.DriverNameSQLite {
+		sqliteModels := sqlite.NewSQLiteDB(dbConns.reader, dbConns.writer)
+		err = sqliteModels.InitDatabase(ctx, dbConns.writer)
+		if err != nil {
+			closeDB()
+			return nil, nil, err
+		}
+		m.URLs = sqliteModels.URLModel
+		m.Pages = sqliteModels.PageModel
+		m.Runs = sqliteModels.RunModel
+		m.Deliveries = sqliteModels.DeliveryModel
+		m.Stats = sqliteModels.StatsModel
+		m.Robots = sqliteModels.RobotsModel
+	}
+	return m, closeDB, nil
+}
+
 // saveDbContentToDisk copies page model's content field from DB to disk at path
 func saveDbContentToDisk(
 	ctx context.Context,
@@ -187,7 +242,7 @@ func saveDbContentToDisk(
 	// save pages for each marked path
 	for _, markedURL := range markedPaths {
 		// 5 Second timeout ctx to use with db query
-		recordCount, err := pageDB.GetLatestPageCount(ctx, baseurl, markedURL, cutOffDate)
+		recordCount, err := pageDB.GetLatestPageCount(ctx, baseurl, markedURL, cmdArgs.variant, cutOffDate)
 		if err != nil {
 			return err
 		}
@@ -205,6 +260,7 @@ func saveDbContentToDisk(
 				ctx,
 				baseurl,
 				markedURL,
+				cmdArgs.variant,
 				cutOffDate,
 				pageNum+1,
 				defaultPageSize,
@@ -224,7 +280,8 @@ func saveDbContentToDisk(
 	return nil
 }
 
-// savePageContent writes the fetched contents to disk
+// savePageContent writes the fetched contents to disk; pages of
+// a fetch variant are suffixed with the variant name
 func savePageContent(pageContents []*models.PageContent, basePath string) error {
 	// Unsafe filename characters regex
 	unsafeChars := regexp.MustCompile(`[<>:"/\\|?*\ ]`)
@@ -254,6 +311,9 @@ func savePageContent(pageContents []*models.PageContent, basePath string) error
 		basePath = strings.TrimRight(basePath, "/")
 
 		internal.CreateDirIfNotExists(basePath + filePath)
+		if pageContent.Variant != "" {
+			safeFileName += "_" + pageContent.Variant
+		}
 		completeFilePath := fmt.Sprintf(
 			"%s%s/%s_%s.html",
 			basePath,
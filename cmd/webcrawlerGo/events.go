@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+)
+
+const (
+	eventBufferSize   = 256              // events held for a slow client
+	eventKeepAlive    = 15 * time.Second // interval of SSE comments keeping the stream open
+	eventWriteTimeout = 10 * time.Second // timeout to write an event to client
+)
+
+// listEventsHandler streams crawl events as Server-Sent Events, or over
+// a WebSocket as JSON messages when the request is a WebSocket upgrade.
+// Events are filtered with query params: type (comma separated) and url.
+func (app *webapp) listEventsHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	v := internal.NewValidator()
+
+	var filter webcrawler.EventFilter
+	for _, name := range seperateCmdArgs(app.readString(qs, "type", "")) {
+		t, err := webcrawler.ParseEventType(name)
+		if err != nil {
+			v.AddError("type", err.Error())
+			continue
+		}
+		filter.Types = append(filter.Types, t)
+	}
+	filter.URLPattern = app.readString(qs, "url", "")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	sub, err := app.Events.Subscribe(filter, eventBufferSize)
+	if err != nil {
+		app.errorResponse(w, r, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			app.streamWebSocket(ws, sub)
+		}}.ServeHTTP(w, r)
+		return
+	}
+
+	app.streamSSE(w, r, sub)
+}
+
+// streamSSE writes events of sub to w until the client disconnects
+// or the subscription is closed
+func (app *webapp) streamSSE(w http.ResponseWriter, r *http.Request, sub *webcrawler.Subscription) {
+	rc := http.NewResponseController(w)
+	// stream outlives the server write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		app.serverErrorResponse(w, r, fmt.Errorf("streaming not supported: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				app.logError(r, err)
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// streamWebSocket sends events of sub to ws as JSON messages until
+// the client disconnects or the subscription is closed
+func (app *webapp) streamWebSocket(ws *websocket.Conn, sub *webcrawler.Subscription) {
+	defer ws.Close()
+
+	// reads only to notice the client going away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var msg []byte
+		for websocket.Message.Receive(ws, &msg) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case <-gone:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			ws.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		}
+	}
+}
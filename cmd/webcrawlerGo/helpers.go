@@ -3,11 +3,16 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 
+	"github.com/mattn/go-isatty"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/internal"
 	"github.com/0x00f00bar/webcrawlerGo/queue"
 )
 
@@ -28,6 +33,85 @@ func getMarkedURLS(mURLStr string) []string {
 	return markedURLs
 }
 
+// parseSeeds parses the seed URLs, dropping trailing '/'.
+// Seeds which cannot be parsed are added to v.
+func parseSeeds(v *internal.Validator, seeds []string) []*url.URL {
+	var parsed []*url.URL
+	for _, seed := range seeds {
+		u, err := url.Parse(strings.TrimRight(seed, "/"))
+		if err != nil {
+			v.AddError("seeds", fmt.Sprintf("could not parse seed URL '%s'", seed))
+			continue
+		}
+		parsed = append(parsed, u)
+	}
+	return parsed
+}
+
+// redactProxies returns proxies with their passwords replaced by "xxxxx"
+func redactProxies(proxies []string) []string {
+	redacted := make([]string, len(proxies))
+	for i, proxy := range proxies {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			redacted[i] = "<invalid proxy>"
+			continue
+		}
+		redacted[i] = u.Redacted()
+	}
+	return redacted
+}
+
+// extractFlagUsages are the usages of -extract-* flags by link extractor name
+var extractFlagUsages = map[string]string{
+	"html": `Discover URLs in a, area, link rel, iframe and frame tags
+and meta refresh of HTML pages.`,
+	"srcset":      "Discover URLs of the images in img/source srcset attributes.",
+	"form":        "Discover action URLs of GET forms, without their fields.",
+	"jsonld":      "Discover URLs of url fields of JSON-LD script blocks.",
+	"link-header": "Discover URLs of Link headers of followed relations.",
+	"feed":        "Discover item links of RSS/RDF feeds and links of Atom feeds.",
+	"sitemap":     "Discover URLs of sitemaps and sitemap indexes.",
+}
+
+// newExtractFlags returns the values of -extract-* flags by link
+// extractor name, set to their defaults
+func newExtractFlags() map[string]*bool {
+	extract := map[string]*bool{}
+	for _, name := range webcrawler.BuiltinExtractorNames() {
+		enabled := name == "html" && defaultExtractHTML
+		extract[name] = &enabled
+	}
+	return extract
+}
+
+// enabledExtractors returns LinkExtractors of the built-in extractors
+// enabled by -extract-* flags or extract_* options of crawl jobs.
+// Errors are added to v keyed by flag name.
+func enabledExtractors(v *internal.Validator, enabled func(name string) bool) *webcrawler.LinkExtractors {
+	var names []string
+	for _, name := range webcrawler.BuiltinExtractorNames() {
+		if enabled(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		v.AddError("extract-html", "at least one link extractor must be enabled")
+		return nil
+	}
+	// names are of built-in extractors
+	extractors, _ := webcrawler.DefaultLinkExtractors(names...)
+	return extractors
+}
+
+// extractorNames returns the names of the link extractors of le; nil when le is nil
+func extractorNames(le *webcrawler.LinkExtractors) []string {
+	if le == nil {
+		return nil
+	}
+	return le.Names()
+}
+
 // seperateCmdArgs returns string slice of comma seperated cmd args
 func seperateCmdArgs(args string) []string {
 	argList := []string{}
@@ -81,3 +165,8 @@ func printBanner() {
 	fmt.Println(redStyle.Margin(0, 0, 0, 2).Render(banner))
 	fmt.Println(grayStyle.Render("v" + version))
 }
+
+// isTerminal tells if f is a terminal
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
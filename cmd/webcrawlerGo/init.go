@@ -11,11 +11,6 @@ import (
 	"github.com/0x00f00bar/webcrawlerGo/queue"
 )
 
-func init() {
-	// make a folder to store logs
-	internal.CreateDirIfNotExists(logFolderName)
-}
-
 // loadUrlsToQueue fetches all urls from URL model and loads them to queue.
 // Returns the number of URLs pushed to queue
 func loadUrlsToQueue(
@@ -32,12 +27,13 @@ func loadUrlsToQueue(
 		Sort:         "is_monitored",
 		SortSafeList: models.URLColumns,
 	}
-	dburls, err := m.GetAll(uf, cf)
+	dburls, err := m.GetAll(ctx, uf, cf)
 	if err != nil {
 		return 0, err
 	}
 	intervalDuration, _ := time.ParseDuration(fmt.Sprintf("%dh", *cmdArgs.updateDaysPast*24))
 	currentTime := time.Now()
+	scope := cmdArgs.scope()
 	var urlsPushedToQ int = 0
 	// if isMonitored true and timestamp after updateInterval in db, set them as true, others false to not process
 	for _, urlDB := range dburls {
@@ -46,9 +42,10 @@ func loadUrlsToQueue(
 			return urlsPushedToQ, nil
 		default:
 			// skip dead urls
-			// crawler will never crawl a dead url,
-			// manually set the URL alive if the URL is back online
-			if !urlDB.IsAlive {
+			// crawler will not crawl a dead url, except monitored urls which are
+			// re-checked when due to notice when they are back online
+			if !urlDB.IsAlive &&
+				(!urlDB.IsMonitored || currentTime.Before(urlDB.LastChecked.Add(intervalDuration))) {
 				q.SetMapValue(urlDB.URL, false)
 				continue
 			}
@@ -61,9 +58,10 @@ func loadUrlsToQueue(
 			parsedUrlDB, err := url.Parse(urlDB.URL)
 			if err != nil {
 				loggers.multiLogger.Printf("Unable to parse url '%s' from db\n", urlDB.URL)
+				continue
 			}
-			// only process URLs belonging to baseURL
-			if parsedUrlDB.Hostname() == cmdArgs.baseURL.Hostname() {
+			// only process URLs in crawl scope
+			if scope.Contains(parsedUrlDB) {
 				expiryTime := urlDB.LastSaved.Add(intervalDuration)
 
 				var fetchContent bool
@@ -79,7 +77,7 @@ func loadUrlsToQueue(
 					fetchContent = true
 					// mark url as monitored as if marked
 					urlDB.IsMonitored = true
-					err := m.Update(urlDB)
+					err := m.Update(ctx, urlDB)
 					if err != nil {
 						return 0, fmt.Errorf("unable to update url '%s': %v", urlDB.URL, err)
 					}
@@ -1,177 +1,201 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
-	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
 )
 
 var (
-	spinnerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
-	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Margin(1, 0)
-	dotStyle     = helpStyle.UnsetMargins()
-	appStyle     = lipgloss.NewStyle().Margin(1, 2, 0, 2)
-
-	textColorMap = map[string]*lipgloss.Style{
-		"Error":   &redStyle,
-		"Invalid": &redStyle,
-		"FATAL":   &redStyle,
-		"Added":   &greenStyle,
-		"Saved":   &greenStyle,
+	levelStyleMap = map[slog.Level]*lipgloss.Style{
+		slog.LevelDebug: &grayStyle,
+		slog.LevelInfo:  &greenStyle,
+		slog.LevelWarn:  &yellowStyle,
+		slog.LevelError: &redStyle,
 	}
 )
 
 // loggers stores multiple loggers
 type loggers struct {
-	fileLogger  *log.Logger
-	multiLogger *log.Logger
+	fileLogger    *log.Logger  // writes records to log file
+	multiLogger   *log.Logger  // writes records to os.Stdout & log file
+	fileHandler   slog.Handler // handler writing to log file
+	stdoutHandler slog.Handler // handler writing to os.Stdout
 }
 
-// crawLogger sends the events received to
-// [tea.Program]
-type crawLogger struct {
-	teaProgram   *tea.Program
-	mu           sync.Mutex
-	crawlerCount int
+// crawlerLogger returns the [slog.Logger] to be used by crawlers.
+// Crawlers log to stdout only when toStdout is true.
+func (l *loggers) crawlerLogger(toStdout bool) *slog.Logger {
+	if toStdout {
+		return slog.New(multiHandler{l.fileHandler, l.stdoutHandler})
+	}
+	return slog.New(l.fileHandler)
 }
 
-// Log sends msg to [tea.Program]
-func (cl *crawLogger) Log(msg string) {
-	// cl.mu.Lock()
-	// defer cl.mu.Unlock()
-	msgSlice := strings.Split(msg, ":")
-	msgSlice[0] = cyanStyle.Render(msgSlice[0])
-
-	msg = strings.Join(msgSlice, ":")
+// multiHandler sends records to all of its handlers
+type multiHandler []slog.Handler
 
-	cl.teaProgram.Send(colorAroundTexts(msg, textColorMap))
+func (mh multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range mh {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
 }
 
-// Quit will quit [tea.Program] when last crawler quits
-func (cl *crawLogger) Quit() {
-	cl.mu.Lock()
-	defer cl.mu.Unlock()
-	cl.crawlerCount--
-	// quit when last crawler exits
-	if cl.crawlerCount == 0 {
-		ctrlC := tea.KeyMsg{
-			Type: tea.KeyCtrlC,
+func (mh multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, h := range mh {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				return err
+			}
 		}
-		cl.teaProgram.Send(ctrlC)
 	}
+	return nil
 }
 
-// initialiseLoggers returns a log file handle f and a MultiWriter logger (os.Stdout & f)
-func initialiseLoggers(verbose bool) (*os.File, *loggers) {
-	logFileName := fmt.Sprintf(
-		"./%s/logfile-%s.log",
-		logFolderName,
-		time.Now().Format("02-01-2006-15-04-05"),
-	)
-	f, err := os.OpenFile(logFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		panic(err)
-	}
-	logFlags := log.LstdFlags
-	if verbose {
-		logFlags = log.LstdFlags | log.Lshortfile
+func (mh multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(mh))
+	for i, h := range mh {
+		handlers[i] = h.WithAttrs(attrs)
 	}
-	loggers := &loggers{
-		multiLogger: log.New(io.MultiWriter(os.Stdout, f), "", logFlags),
-		fileLogger:  log.New(f, "", logFlags),
+	return handlers
+}
+
+func (mh multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(mh))
+	for i, h := range mh {
+		handlers[i] = h.WithGroup(name)
 	}
-	return f, loggers
+	return handlers
 }
 
-// teaProgModel for [tea.Program]
-type teaProgModel struct {
-	spinner  spinner.Model
-	messages []string
-	quitting bool
-	quitChan chan os.Signal
+// logMsg is the [tea.Msg] carrying a crawler log record
+type logMsg slog.Record
+
+// engineDoneMsg is sent when all crawlers have exited
+type engineDoneMsg struct{}
+
+// crawLogger sends the events received to
+// [tea.Program]
+type crawLogger struct {
+	teaProgram *tea.Program
+	quitOnce   sync.Once
 }
 
-// newteaProgModel returns new teaProgModel
-func newteaProgModel(numMsgs int, sigChan chan os.Signal) teaProgModel {
-	s := spinner.New()
-	s.Style = spinnerStyle
-	messages := make([]string, numMsgs)
-	dots := dotStyle.Render(strings.Repeat(".", 45))
-	for i := range messages {
-		messages[i] = dots
-	}
-	return teaProgModel{
-		spinner:  s,
-		messages: messages,
-		quitChan: sigChan,
-	}
+// Log sends record r to [tea.Program]
+func (cl *crawLogger) Log(r slog.Record) {
+	cl.teaProgram.Send(logMsg(r.Clone()))
 }
 
-func (m teaProgModel) Init() tea.Cmd {
-	return m.spinner.Tick
+// Quit will quit [tea.Program]; called by the engine
+// when all crawlers have exited
+func (cl *crawLogger) Quit() {
+	cl.quitOnce.Do(func() {
+		cl.teaProgram.Send(engineDoneMsg{})
+	})
 }
 
-func (m teaProgModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			m.quitting = true
-			return m, tea.Quit
+// renderRecord renders crawler log record r as a single colored line
+func renderRecord(r slog.Record) string {
+	var crawlerName string
+	var attrs []string
+
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "crawler" {
+			crawlerName = a.Value.String()
+			return true
 		}
-		return m, nil
-	case string:
-		m.messages = append(m.messages[1:], msg)
-		return m, nil
-	case spinner.TickMsg:
-		var cmd tea.Cmd
-		m.spinner, cmd = m.spinner.Update(msg)
-		return m, cmd
-	default:
-		return m, nil
+		attrs = append(attrs, grayStyle.Render(a.Key+"=")+a.Value.String())
+		return true
+	})
+
+	levelStyle, ok := levelStyleMap[r.Level]
+	if !ok {
+		levelStyle = &grayStyle
 	}
-}
 
-func (m teaProgModel) View() string {
-	var s string
+	line := fmt.Sprintf(
+		"%s %s %s",
+		cyanStyle.Render(crawlerName),
+		levelStyle.Render(fmt.Sprintf("%-5s", r.Level.String())),
+		r.Message,
+	)
+	if len(attrs) > 0 {
+		line += " " + strings.Join(attrs, " ")
+	}
+	return line
+}
 
-	if m.quitting {
-		s += cyanStyle.Render("Aaand... we're done!")
-	} else {
-		s += m.spinner.View() + redStyle.Render(" Crawlers be crawling...")
+// newLogHandler returns a text or json [slog.Handler] writing to w
+// as per format
+func newLogHandler(w io.Writer, format string, opts *slog.HandlerOptions) slog.Handler {
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
 	}
+	return slog.NewTextHandler(w, opts)
+}
 
-	s += "\n\n"
+// initialiseLoggers returns a log file handle f created in cmdArgs.logDir and loggers
+// writing in cmdArgs.logFormat to f and os.Stdout
+func initialiseLoggers(cmdArgs *cmdFlags) (*os.File, *loggers) {
+	// make a folder to store logs
+	internal.CreateDirIfNotExists(cmdArgs.logDir)
 
-	for _, msg := range m.messages {
-		s += msg + "\n"
+	logFileName := filepath.Join(
+		cmdArgs.logDir,
+		fmt.Sprintf("logfile-%s.log", time.Now().Format("02-01-2006-15-04-05")),
+	)
+	f, err := os.OpenFile(logFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		panic(err)
 	}
 
-	if !m.quitting {
-		s += helpStyle.Render("Press Ctrl + c or q to quit")
+	opts := &slog.HandlerOptions{
+		Level:     cmdArgs.logLevel,
+		AddSource: cmdArgs.verbose,
 	}
+	fileHandler := newLogHandler(f, cmdArgs.logFormat, opts)
+	stdoutHandler := newLogHandler(os.Stdout, cmdArgs.logFormat, opts)
 
-	if m.quitting {
-		m.quitChan <- syscall.SIGINT
-		s += "\n" + redStyle.Render("Waiting for crawlers to quit... ") + "\n\n"
+	loggers := &loggers{
+		multiLogger:   slog.NewLogLogger(multiHandler{fileHandler, stdoutHandler}, slog.LevelInfo),
+		fileLogger:    slog.NewLogLogger(fileHandler, slog.LevelInfo),
+		fileHandler:   fileHandler,
+		stdoutHandler: stdoutHandler,
 	}
+	return f, loggers
+}
 
-	return appStyle.Render(s)
+// stderrLoggers returns loggers writing warnings and errors to os.Stderr
+// only, for the commands printing their results to os.Stdout
+func stderrLoggers() *loggers {
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})
+	logger := slog.NewLogLogger(handler, slog.LevelInfo)
+	return &loggers{
+		multiLogger:   logger,
+		fileLogger:    logger,
+		fileHandler:   handler,
+		stdoutHandler: handler,
+	}
 }
 
 // printAndLog will print msg to [os.Stdout] using printFunc
-// and write to logFile. printFunc will print on new line and
-// new line will be added to msg while writing to logFile
-func printAndLog(printFunc func(string), logFile *os.File, msg string) {
+// and write to logger
+func printAndLog(printFunc func(string), logger *log.Logger, msg string) {
 	printFunc(msg)
-	logFile.Write([]byte(msg + "\n"))
+	logger.Println(msg)
 }
@@ -7,10 +7,7 @@ import (
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 
-	"github.com/0x00f00bar/webcrawlerGo/internal"
-	"github.com/0x00f00bar/webcrawlerGo/models"
-	"github.com/0x00f00bar/webcrawlerGo/models/psql"
-	"github.com/0x00f00bar/webcrawlerGo/models/sqlite"
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
 	"github.com/0x00f00bar/webcrawlerGo/queue"
 )
 
@@ -28,70 +25,40 @@ var (
 )
 
 func main() {
-	printBanner()
-
 	defer func() {
 		os.Exit(exitCode)
 	}()
 
-	v := internal.NewValidator()
+	runCommand(os.Args[1:])
+}
 
-	// parse cmd flags; exit if flags invalid
-	cmdArgs := parseCmdFlags(v)
+// run runs the crawl, the local server or the export to disk of cmdArgs
+func run(cmdArgs *cmdFlags) {
+	if !cmdArgs.quiet {
+		printBanner()
+	}
 
 	// init file and os.Stdout logger
-	f, loggers := initialiseLoggers(cmdArgs.verbose)
+	f, loggers := initialiseLoggers(cmdArgs)
 	defer f.Close()
-	f.Write([]byte(banner + "\n" + "v" + version + "\n\n"))
+	loggers.fileLogger.Printf("webcrawlerGo v%s", version)
 
-	if !cmdArgs.runserver {
-		logCmdArgs(cmdArgs, f)
+	if !cmdArgs.runserver && !cmdArgs.quiet {
+		logCmdArgs(cmdArgs, f, loggers.fileLogger)
 	}
 
-	// init and test db
-	driverName, dbConns, err := getDBConnections(*cmdArgs.dbDSN, loggers)
-	if err != nil {
-		exitCode = 1
-		loggers.fileLogger.Println(err)
-		return
-	}
-	loggers.multiLogger.Println("DB connection OK.")
-	defer dbConns.Close()
-
-	// if the driver used is sqlite3 consolidate the WAL journal to db
-	// before closing connection
-	defer sqlite.ExecWALCheckpoint(driverName, dbConns.writer)
-
 	// create cancel context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// init models
-	var m models.Models
-
-	// get postgres models and initialise database tables
-	if driverName == psql.DriverNamePgSQL {
-		psqlModels := psql.NewPsqlDB(dbConns.writer)
-		err = psqlModels.InitDatabase(ctx, dbConns.writer)
-		if err != nil {
-			exitCode = 1
-			loggers.multiLogger.Println(err)
-			return
-		}
-		m.URLs = psqlModels.URLModel
-		m.Pages = psqlModels.PageModel
-	}
-	// get sqlite3 models and initialise database tables
-	if driverName == sqlite.DriverNameSQLite {
-		sqliteModels := sqlite.NewSQLiteDB(dbConns.reader, dbConns.writer)
-		err = sqliteModels.InitDatabase(ctx, dbConns.writer)
-		if err != nil {
-			exitCode = 1
-			loggers.multiLogger.Println(err)
-			return
-		}
-		m.URLs = sqliteModels.URLModel
-		m.Pages = sqliteModels.PageModel
+	// init and test db, get models
+	m, closeDB, err := openModels(ctx, *cmdArgs.dbDSN, loggers)
+	if err != nil {
+		cancel()
+		exitCode = exitCodeError
+		loggers.multiLogger.Println(err)
+		return
 	}
+	defer closeDB()
 
 	// init queue & push base url
 	q := queue.NewQueue()
@@ -102,14 +69,32 @@ func main() {
 	go listenForSignals(cancel, quit, q, loggers)
 
 	if cmdArgs.runserver {
+		events := webcrawler.NewEventBus()
 		app := webapp{
-			Models: &m,
+			Addr:   cmdArgs.serverAddr,
+			Models: m,
+			Jobs:   newCrawlJobs(ctx, m, events, loggers, cmdArgs.logDir),
+			Events: events,
 			Logger: loggers.multiLogger,
 		}
 
-		err = app.serve(ctx, quit)
+		if len(cmdArgs.webhooks) > 0 {
+			stopWebhooks, err := startWebhooks(cmdArgs.webhooks, events, m.Deliveries, loggers.crawlerLogger(false))
+			if err != nil {
+				exitCode = exitCodeError
+				app.Logger.Println(err)
+				return
+			}
+			defer stopWebhooks()
+		}
+
+		if len(cmdArgs.crawlDefs) > 0 {
+			err = app.runDaemon(ctx, cmdArgs.crawlDefs, quit)
+		} else {
+			err = app.serve(ctx, quit)
+		}
 		if err != nil {
-			exitCode = 3
+			exitCode = exitCodeServer
 			app.Logger.Println(err)
 		}
 		return
@@ -118,7 +103,7 @@ func main() {
 	if cmdArgs.dbToDisk {
 		err = saveDbContentToDisk(ctx, m.Pages, cmdArgs, cmdArgs.markedURLs, loggers)
 		if err != nil {
-			exitCode = 1
+			exitCode = exitCodeError
 			loggers.multiLogger.Printf("Error while saving to disk: %v\n", err)
 		} else {
 			loggers.multiLogger.Println("Transfer completed")
@@ -126,7 +111,7 @@ func main() {
 		return
 	}
 
-	err = beginCrawl(ctx, cmdArgs, quit, q, &m, loggers)
+	err = beginCrawl(ctx, cmdArgs, quit, q, m, loggers)
 	if err != nil {
 		loggers.multiLogger.Println(err)
 		return
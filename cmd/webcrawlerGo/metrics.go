@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/0x00f00bar/webcrawlerGo/metrics"
+)
+
+// API server metrics exported on metrics.DefaultRegistry
+var (
+	apiRequestsTotal = metrics.NewCounterVec(
+		"webcrawlergo_api_requests_total",
+		"Number of requests served by the API server by method and status code.",
+		"method",
+		"code",
+	)
+	apiRequestDurationSeconds = metrics.NewHistogram(
+		"webcrawlergo_api_request_duration_seconds",
+		"Time taken to serve API requests.",
+		nil,
+	)
+)
+
+func init() {
+	metrics.MustRegister(apiRequestsTotal, apiRequestDurationSeconds)
+}
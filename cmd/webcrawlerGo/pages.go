@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/0x00f00bar/webcrawlerGo/internal"
@@ -15,7 +16,7 @@ func (app *webapp) getPageByIdHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	page, err := app.Models.Pages.GetById(int(id))
+	page, err := app.Models.Pages.GetById(r.Context(), int(id))
 	if err != nil {
 		switch {
 		case errors.Is(err, models.ErrRecordNotFound):
@@ -35,6 +36,8 @@ func (app *webapp) getPageByIdHandler(w http.ResponseWriter, r *http.Request) {
 func (app *webapp) listPageHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		URLId int
+		RunId int
+		models.PageFilter
 		models.CommonFilters
 	}
 
@@ -42,6 +45,15 @@ func (app *webapp) listPageHandler(w http.ResponseWriter, r *http.Request) {
 	qs := r.URL.Query()
 
 	input.URLId = app.readInt(qs, "url_id", 0, v)
+	input.RunId = app.readInt(qs, "run_id", 0, v)
+	v.Check(
+		(input.URLId > 0) != (input.RunId > 0),
+		"url_id",
+		"exactly one of url_id or run_id must be provided",
+	)
+	// empty variant selects the pages of the default fetch
+	input.Variant = qs.Get("variant")
+	input.VariantPresent = qs.Has("variant")
 
 	input.CommonFilters.Page = app.readInt(qs, "page", 1, v)
 	input.CommonFilters.PageSize = app.readInt(qs, "page_size", 10, v)
@@ -56,7 +68,13 @@ func (app *webapp) listPageHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pages, err := app.Models.Pages.GetAllByURL(uint(input.URLId), input.CommonFilters)
+	var pages []*models.Page
+	var err error
+	if input.RunId > 0 {
+		pages, err = app.Models.Pages.GetAllByRun(r.Context(), uint(input.RunId), input.PageFilter, input.CommonFilters)
+	} else {
+		pages, err = app.Models.Pages.GetAllByURL(r.Context(), uint(input.URLId), input.PageFilter, input.CommonFilters)
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, models.ErrInvalidOrderBy):
@@ -76,3 +94,78 @@ func (app *webapp) listPageHandler(w http.ResponseWriter, r *http.Request) {
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// diffPageHandler writes the unified diff of a page against the page of
+// id 'with', the latest page of its URL fetched with 'variant' or, by
+// default, the previous page of its URL and variant
+func (app *webapp) diffPageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := internal.NewValidator()
+	qs := r.URL.Query()
+	withID := app.readInt(qs, "with", 0, v)
+	v.Check(withID >= 0, "with", "must be a positive integer")
+	variant, variantPresent := qs.Get("variant"), qs.Has("variant")
+	v.Check(!(withID > 0 && variantPresent), "with", "only one of with or variant can be provided")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	page, err := app.Models.Pages.GetById(r.Context(), int(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// page is compared as the new page against the previous page;
+	// as the old page against others
+	oldPage, newPage := page, page
+	switch {
+	case withID > 0:
+		newPage, err = app.Models.Pages.GetById(r.Context(), withID)
+	case variantPresent:
+		newPage, err = app.Models.Pages.GetLatestByURL(r.Context(), page.URLID, variant)
+	default:
+		oldPage, err = getPreviousPage(r.Context(), app.Models.Pages, page)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound) && withID > 0:
+			app.notFoundResponse(w, r)
+		case errors.Is(err, models.ErrRecordNotFound) && variantPresent:
+			app.badRequestResponse(w, r, fmt.Errorf("url #%d has no page of variant %q", page.URLID, variant))
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.badRequestResponse(w, r, fmt.Errorf("page #%d is the first page of its URL", page.ID))
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	diff := internal.UnifiedDiff(
+		pageLabel(r.Context(), app.Models.URLs, oldPage),
+		pageLabel(r.Context(), app.Models.URLs, newPage),
+		oldPage.Content,
+		newPage.Content,
+		3,
+	)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"from": oldPage.ID,
+		"to":   newPage.ID,
+		"diff": diff,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
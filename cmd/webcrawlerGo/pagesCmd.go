@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+func runPagesList(c *command, args []string) error {
+	dc := newDBCommand(c, true)
+	runID := dc.fs.Int("run", 0, "List the pages saved by the crawl run of id instead of the pages of a URL")
+	page := dc.fs.Int("page", 1, "Page number of the list")
+	pageSize := dc.fs.Int("page-size", 20, "Number of pages per page")
+	sort := dc.fs.String("sort", "id", "Column to sort by; prefix with '-' for descending order.\n"+
+		"One of: id, url_id, added_at, run_id, variant")
+	var pf models.PageFilter
+	dc.fs.Func("variant", "List only the pages of the fetch variant of name; use '' for the default fetch", func(s string) error {
+		pf.Variant, pf.VariantPresent = s, true
+		return nil
+	})
+	dc.parse(args, 0, 1)
+
+	dc.v.Check(
+		(*runID > 0) != (dc.fs.NArg() > 0),
+		"args",
+		"exactly one of url-id/url or -run must be provided",
+	)
+
+	safeColumns := []string{"id", "url_id", "added_at", "run_id", "variant"}
+	var safeSortList []string
+	safeSortList = append(safeSortList, safeColumns...)
+	safeSortList = append(safeSortList, internal.PrefixString(safeColumns, "-")...)
+	cf := models.CommonFilters{
+		Page:         *page,
+		PageSize:     *pageSize,
+		Sort:         *sort,
+		SortSafeList: safeSortList,
+	}
+	if models.ValidateCommonFilters(dc.v, cf); !dc.v.Valid() {
+		printInvalidFlagErrors(dc.fs, dc.v)
+	}
+
+	ctx := context.Background()
+	m, closeDB, err := dc.openModels(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	var pages []*models.Page
+	if *runID > 0 {
+		pages, err = m.Pages.GetAllByRun(ctx, uint(*runID), pf, cf)
+	} else {
+		var url *models.URL
+		url, err = getURLByIdOrURL(ctx, m.URLs, dc.fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		pages, err = m.Pages.GetAllByURL(ctx, url.ID, pf, cf)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dc.asJSON {
+		if pages == nil {
+			pages = []*models.Page{}
+		}
+		return printJSON(pages)
+	}
+
+	rows := make([][]string, len(pages))
+	for i, p := range pages {
+		rows[i] = []string{
+			strconv.FormatUint(uint64(p.ID), 10),
+			strconv.FormatUint(uint64(p.URLID), 10),
+			strconv.FormatUint(uint64(p.RunID), 10),
+			p.Variant,
+			formatTime(p.AddedAt),
+		}
+	}
+	return printTable([]string{"ID", "URL ID", "RUN ID", "VARIANT", "ADDED AT"}, rows)
+}
+
+func runPagesShow(c *command, args []string) error {
+	dc := newDBCommand(c, true)
+	dc.parse(args, 1, 1)
+
+	ctx := context.Background()
+	m, closeDB, err := dc.openModels(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	page, err := getPageById(ctx, m.Pages, dc.fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if dc.asJSON {
+		return printJSON(page)
+	}
+	fmt.Print(page.Content)
+	if !strings.HasSuffix(page.Content, "\n") {
+		fmt.Println()
+	}
+	return nil
+}
+
+func runPagesDiff(c *command, args []string) error {
+	dc := newDBCommand(c, false)
+	contextLines := dc.fs.Int("context", 3, "Number of unchanged lines to print around the changes")
+	var variant *string
+	dc.fs.Func(
+		"variant",
+		"Compare the page with the latest page of its URL fetched with the variant of name;\n"+
+			"use '' for the default fetch",
+		func(s string) error {
+			variant = &s
+			return nil
+		},
+	)
+	dc.parse(args, 1, 2)
+	dc.v.Check(*contextLines >= 0, "context", "cannot be negative")
+	dc.v.Check(variant == nil || dc.fs.NArg() == 1, "variant", "cannot be used with a second page id")
+	if !dc.v.Valid() {
+		printInvalidFlagErrors(dc.fs, dc.v)
+	}
+
+	ctx := context.Background()
+	m, closeDB, err := dc.openModels(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	page, err := getPageById(ctx, m.Pages, dc.fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	oldPage, newPage := page, page
+	switch {
+	case dc.fs.NArg() == 2:
+		newPage, err = getPageById(ctx, m.Pages, dc.fs.Arg(1))
+	case variant != nil:
+		newPage, err = m.Pages.GetLatestByURL(ctx, page.URLID, *variant)
+		if errors.Is(err, models.ErrRecordNotFound) {
+			err = fmt.Errorf("url #%d has no page of variant '%s'", page.URLID, *variant)
+		}
+	default:
+		oldPage, err = getPreviousPage(ctx, m.Pages, page)
+		if errors.Is(err, models.ErrRecordNotFound) {
+			err = fmt.Errorf("page #%d is the first page of its URL", page.ID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	diff := internal.UnifiedDiff(
+		pageLabel(ctx, m.URLs, oldPage),
+		pageLabel(ctx, m.URLs, newPage),
+		oldPage.Content,
+		newPage.Content,
+		*contextLines,
+	)
+	if diff == "" {
+		fmt.Printf("No changes between page #%d and page #%d\n", oldPage.ID, newPage.ID)
+		return nil
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+// getPageById fetches the page of id arg
+func getPageById(ctx context.Context, pages models.PageModel, arg string) (*models.Page, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page id '%s'", arg)
+	}
+	page, err := pages.GetById(ctx, id)
+	if errors.Is(err, models.ErrRecordNotFound) {
+		return nil, fmt.Errorf("page #%d not found", id)
+	}
+	return page, err
+}
+
+// getPreviousPage fetches the page of the URL and variant of page saved
+// before page. Returns models.ErrRecordNotFound when page is the first.
+func getPreviousPage(ctx context.Context, pages models.PageModel, page *models.Page) (*models.Page, error) {
+	pf := models.PageFilter{Variant: page.Variant, VariantPresent: true}
+	cf := models.CommonFilters{Page: 1, PageSize: 100, Sort: "-id", SortSafeList: []string{"id"}}
+	for {
+		list, err := pages.GetAllByURL(ctx, page.URLID, pf, cf)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range list {
+			if p.ID < page.ID {
+				return pages.GetById(ctx, int(p.ID))
+			}
+		}
+		if len(list) < cf.PageSize {
+			return nil, models.ErrRecordNotFound
+		}
+		cf.Page++
+	}
+}
+
+// pageLabel returns the label of page in diffs
+func pageLabel(ctx context.Context, urls models.URLModel, page *models.Page) string {
+	label := fmt.Sprintf("page #%d", page.ID)
+	if url, err := urls.GetById(ctx, int(page.URLID)); err == nil {
+		label += " " + url.URL
+	}
+	if page.Variant != "" {
+		label += " [" + page.Variant + "]"
+	}
+	return label + " " + formatTime(page.AddedAt)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+func (app *webapp) getRobotsByIdHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	robots, err := app.Models.Robots.GetById(r.Context(), int(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"robots": robots}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *webapp) listRobotsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		models.RobotsFilter
+		models.CommonFilters
+	}
+
+	v := internal.NewValidator()
+	qs := r.URL.Query()
+
+	input.RobotsFilter.Host = app.readString(qs, "host", "")
+
+	input.CommonFilters.Page = app.readInt(qs, "page", 1, v)
+	input.CommonFilters.PageSize = app.readInt(qs, "page_size", 10, v)
+	input.CommonFilters.Sort = app.readString(qs, "sort", "-id")
+	var safeSortList []string
+	safeSortList = append(safeSortList, models.RobotsColumns...)
+	safeSortList = append(safeSortList, internal.PrefixString(models.RobotsColumns, "-")...)
+	input.CommonFilters.SortSafeList = safeSortList
+
+	if models.ValidateCommonFilters(v, input.CommonFilters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	versions, err := app.Models.Robots.GetAll(r.Context(), input.RobotsFilter, input.CommonFilters)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidOrderBy):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"robots_list": versions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// diffRobotsHandler writes the unified diff of a version of robots.txt
+// against the version of id 'with' or, by default, the previous version
+// of the same host
+func (app *webapp) diffRobotsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := internal.NewValidator()
+	withID := app.readInt(r.URL.Query(), "with", 0, v)
+	v.Check(withID >= 0, "with", "must be a positive integer")
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	newVersion, err := app.Models.Robots.GetById(r.Context(), int(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var oldVersion *models.RobotsTxt
+	if withID > 0 {
+		oldVersion, err = app.Models.Robots.GetById(r.Context(), withID)
+	} else {
+		oldVersion, err = app.Models.Robots.GetPrevious(r.Context(), newVersion)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound) && withID > 0:
+			app.notFoundResponse(w, r)
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.badRequestResponse(w, r, fmt.Errorf("robots.txt #%d is the first version of %s", newVersion.ID, newVersion.Host))
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	diff := internal.UnifiedDiff(
+		robotsLabel(oldVersion),
+		robotsLabel(newVersion),
+		oldVersion.Content,
+		newVersion.Content,
+		3,
+	)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"from": oldVersion.ID,
+		"to":   newVersion.ID,
+		"diff": diff,
+	}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// robotsLabel returns the label of a version of robots.txt in diffs
+func robotsLabel(rt *models.RobotsTxt) string {
+	return fmt.Sprintf("%s/robots.txt #%d (HTTP %d, %s)", rt.Host, rt.ID, rt.StatusCode, rt.FetchedAt.Format(time.RFC3339))
+}
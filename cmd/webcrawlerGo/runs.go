@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+func (app *webapp) getRunByIdHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	run, err := app.Models.Runs.GetById(r.Context(), int(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"run": run}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *webapp) listRunHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		models.RunFilter
+		models.CommonFilters
+	}
+
+	v := internal.NewValidator()
+	qs := r.URL.Query()
+
+	input.RunFilter.BaseURL = app.readString(qs, "base_url", "")
+	input.RunFilter.ExitReason = app.readString(qs, "exit_reason", "")
+	if input.RunFilter.ExitReason != "" {
+		v.Check(
+			internal.PermittedValue(
+				input.RunFilter.ExitReason,
+				models.RunRunning,
+				models.RunCompleted,
+				models.RunInterrupted,
+				models.RunAborted,
+				models.RunFailed,
+				models.RunDrained,
+			),
+			"exit_reason",
+			"invalid exit reason",
+		)
+	}
+
+	input.CommonFilters.Page = app.readInt(qs, "page", 1, v)
+	input.CommonFilters.PageSize = app.readInt(qs, "page_size", 10, v)
+	input.CommonFilters.Sort = app.readString(qs, "sort", "-id")
+	var safeSortList []string
+	safeSortList = append(safeSortList, models.RunColumns...)
+	safeSortList = append(safeSortList, internal.PrefixString(models.RunColumns, "-")...)
+	input.CommonFilters.SortSafeList = safeSortList
+
+	if models.ValidateCommonFilters(v, input.CommonFilters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	runs, err := app.Models.Runs.GetAll(r.Context(), input.RunFilter, input.CommonFilters)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidOrderBy):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if runs == nil {
+		runs = []*models.CrawlRun{}
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"run_list": runs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
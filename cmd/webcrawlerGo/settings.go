@@ -6,15 +6,17 @@ import (
 )
 
 const (
-	logFolderName = "logs"
+	defaultLogDir = "logs"
 
 	sqliteDBName          = "crawler.db"
+	pendingQueueFile      = "pending-queue.tsv" // URLs left in queue by a drained crawl
 	dbMaxOpenConn         = 25
 	dbMaxIdleConn         = 25
 	dbMaxConnIdleDuration = 10 * time.Minute
 	defaultPageSize       = 20
+	defaultServerAddr     = ":8100"
 
-	// defaultTimeout is used in http.Client and timeout while shutting down.
+	// defaultTimeout is used as timeout while shutting down.
 	// DB queries are not aware of this timeout, db queries timeout at 5s,
 	// keeping defaultTimeout lower than 5s may result in program exiting while a query is being processed.
 	defaultTimeout  = 5 * time.Second
@@ -22,6 +24,35 @@ const (
 	timeStampLayout = dateLayout + "_15-04-05"
 )
 
+// default crawl options of cmd flags and crawl jobs
+const (
+	defaultCrawlers       = 10
+	defaultIdleTime       = "10s"
+	defaultReqDelay       = "50ms"
+	defaultAdaptiveDelay  = true
+	defaultRobotsTTL      = "24h"
+	defaultConnectTimeout = "10s"
+	defaultReadTimeout    = "0s"
+	defaultRequestTimeout = "5s"
+	defaultHTTP2          = true
+	defaultUpdateDays     = 1
+	defaultRetry          = 2
+	defaultRetryBackoff   = "1s"
+	defaultOnError        = "abort"
+	defaultDirectives     = "record"
+	defaultExtractHTML    = true // other link extractors are opt-in
+)
+
+// exit codes returned by the program
+const (
+	exitCodeOK            = 0
+	exitCodeError         = 1   // invalid flags, db or setup errors
+	exitCodeCrawlerConfig = 2   // invalid crawler config
+	exitCodeServer        = 3   // web server errors
+	exitCodeCrawlAborted  = 4   // crawl aborted as per error policy
+	exitCodeInterrupted   = 130 // crawl interrupted by SIGINT/SIGTERM
+)
+
 var (
 	sqliteDBWriterArgs = []string{
 		"_busy_timeout=5000",
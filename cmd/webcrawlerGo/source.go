@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+)
+
+// prefixes of base URLs of local directories and WARC files
+const (
+	dirSourcePrefix  = "dir:"
+	fileSourcePrefix = "file://"
+)
+
+// defaultVHost is the virtual host the files of local directories are served under
+const defaultVHost = "http://localhost"
+
+// localSource is a local directory or WARC file crawled in place of a site
+type localSource struct {
+	path    string             // path of the directory or WARC file
+	fetcher webcrawler.Fetcher // serves the files of path under the virtual host
+}
+
+// isLocalSource tells if baseURL is of a local directory or WARC file
+func isLocalSource(baseURL string) bool {
+	baseURL = strings.TrimSpace(baseURL)
+	return strings.HasPrefix(baseURL, dirSourcePrefix) || strings.HasPrefix(baseURL, fileSourcePrefix)
+}
+
+// parseBaseURL returns the base URL to crawl of baseURL given to -baseurl
+// or of crawl jobs. Base URLs dir:<path> and file://<path> crawl the
+// directory or WARC file at path: its source is returned along with vhost,
+// or for WARC files when vhost is empty the site of its first response,
+// as the base URL. Errors are added to v keyed by flag name.
+func parseBaseURL(v *internal.Validator, baseURL, vhost string) (*url.URL, *localSource) {
+	baseURL = strings.TrimSpace(baseURL)
+	var path string
+	switch {
+	case strings.HasPrefix(baseURL, dirSourcePrefix):
+		path = strings.TrimPrefix(baseURL, dirSourcePrefix)
+	case strings.HasPrefix(baseURL, fileSourcePrefix):
+		path = strings.TrimPrefix(baseURL, fileSourcePrefix)
+	default:
+		// drop trailing '/'
+		parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+		if err != nil {
+			v.AddError("baseurl", "could not parse base URL")
+			return &url.URL{}, nil
+		}
+		v.Check(vhost == "", "vhost", "can only be used with dir: and file:// base URLs")
+		return parsed, nil
+	}
+
+	source, parsed, err := openLocalSource(path, strings.TrimSpace(vhost))
+	if err != nil {
+		v.AddError("baseurl", err.Error())
+		return &url.URL{}, nil
+	}
+	return parsed, source
+}
+
+// openLocalSource returns the source of the directory or WARC file at path
+// and the base URL of its pages: vhost, or the default of the source when
+// empty. Returns error when vhost is not an absolute http/https URL.
+func openLocalSource(path, vhost string) (*localSource, *url.URL, error) {
+	if path == "" {
+		return nil, nil, errors.New("path of the directory or WARC file must be provided")
+	}
+	path = filepath.Clean(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.IsDir() {
+		baseURL, err := parseVHost(vhost, defaultVHost)
+		if err != nil {
+			return nil, nil, err
+		}
+		dir, err := webcrawler.NewDirFetcher(path, baseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &localSource{path: path, fetcher: dir}, baseURL, nil
+	}
+
+	warc, err := webcrawler.NewWARCFetcher(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	first, err := url.Parse(warc.FirstURL())
+	if err != nil || first.Host == "" {
+		return nil, nil, fmt.Errorf("%s: no responses in WARC file", path)
+	}
+	baseURL, err := parseVHost(vhost, first.Scheme+"://"+first.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := warc.SetVHost(baseURL); err != nil {
+		return nil, nil, err
+	}
+	return &localSource{path: path, fetcher: warc}, baseURL, nil
+}
+
+// parseVHost returns the URL of vhost, or of defaultValue when empty
+func parseVHost(vhost, defaultValue string) (*url.URL, error) {
+	if vhost == "" {
+		vhost = defaultValue
+	}
+	parsed, err := url.Parse(strings.TrimRight(vhost, "/"))
+	if err != nil || !internal.IsAbsoluteURL(parsed.String()) || !internal.IsValidScheme(parsed.Scheme) {
+		return nil, fmt.Errorf("vhost %q must be absolute http/https URL", vhost)
+	}
+	return parsed, nil
+}
+
+// sourcePath returns the path of source; empty when nil
+func sourcePath(source *localSource) string {
+	if source == nil {
+		return ""
+	}
+	return source.path
+}
@@ -32,7 +32,7 @@ func (app *webapp) createURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.Models.URLs.Insert(url)
+	err = app.Models.URLs.Insert(r.Context(), url)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
 			app.errorResponse(
@@ -63,7 +63,7 @@ func (app *webapp) getURLByIdHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	url, err := app.Models.URLs.GetById(int(id))
+	url, err := app.Models.URLs.GetById(r.Context(), int(id))
 	if err != nil {
 		switch {
 		case errors.Is(err, models.ErrRecordNotFound):
@@ -100,7 +100,7 @@ func (app *webapp) updateURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	url, err := app.Models.URLs.GetById(int(id))
+	url, err := app.Models.URLs.GetById(r.Context(), int(id))
 	if err != nil {
 		switch {
 		case errors.Is(err, models.ErrRecordNotFound):
@@ -122,7 +122,7 @@ func (app *webapp) updateURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.Models.URLs.Update(url)
+	err = app.Models.URLs.Update(r.Context(), url)
 	if err != nil {
 		switch {
 		case errors.Is(err, models.ErrEditConflict):
@@ -151,6 +151,7 @@ func (app *webapp) listURLHandler(w http.ResponseWriter, r *http.Request) {
 	input.URLFilter.URL = app.readString(qs, "url", "")
 	input.IsMonitored, input.IsMonitoredPresent = app.readBool(qs, "is_monitored", v)
 	input.IsAlive, input.IsAlivePresent = app.readBool(qs, "is_alive", v)
+	input.IsSkipped, input.IsSkippedPresent = app.readBool(qs, "is_skipped", v)
 
 	input.CommonFilters.Page = app.readInt(qs, "page", 1, v)
 	input.CommonFilters.PageSize = app.readInt(qs, "page_size", 10, v)
@@ -165,7 +166,7 @@ func (app *webapp) listURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	urls, err := app.Models.URLs.GetAll(input.URLFilter, input.CommonFilters)
+	urls, err := app.Models.URLs.GetAll(r.Context(), input.URLFilter, input.CommonFilters)
 	if err != nil {
 		switch {
 		case errors.Is(err, models.ErrInvalidOrderBy):
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+func runURLsList(c *command, args []string) error {
+	dc := newDBCommand(c, true)
+	urlFilter := dc.fs.String("url", "", "List only URLs containing url")
+	monitored := dc.fs.String("monitored", "", "List only monitored (true) or unmonitored (false) URLs")
+	alive := dc.fs.String("alive", "", "List only alive (true) or dead (false) URLs")
+	skipped := dc.fs.String("skipped", "", "List only URLs with (true) or without (false) a skip reason")
+	page := dc.fs.Int("page", 1, "Page number of the list")
+	pageSize := dc.fs.Int("page-size", 20, "Number of URLs per page")
+	sort := dc.fs.String("sort", "id", "Column to sort by; prefix with '-' for descending order.\n"+
+		"One of: "+strings.Join(models.URLColumns, ", "))
+	dc.parse(args, 0, 0)
+
+	uf := models.URLFilter{URL: *urlFilter}
+	uf.IsMonitored, uf.IsMonitoredPresent = parseBoolFilter(dc.v, "monitored", *monitored)
+	uf.IsAlive, uf.IsAlivePresent = parseBoolFilter(dc.v, "alive", *alive)
+	uf.IsSkipped, uf.IsSkippedPresent = parseBoolFilter(dc.v, "skipped", *skipped)
+
+	var safeSortList []string
+	safeSortList = append(safeSortList, models.URLColumns...)
+	safeSortList = append(safeSortList, internal.PrefixString(models.URLColumns, "-")...)
+	cf := models.CommonFilters{
+		Page:         *page,
+		PageSize:     *pageSize,
+		Sort:         *sort,
+		SortSafeList: safeSortList,
+	}
+	if models.ValidateCommonFilters(dc.v, cf); !dc.v.Valid() {
+		printInvalidFlagErrors(dc.fs, dc.v)
+	}
+
+	ctx := context.Background()
+	m, closeDB, err := dc.openModels(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	urls, err := m.URLs.GetAll(ctx, uf, cf)
+	if err != nil {
+		return err
+	}
+
+	if dc.asJSON {
+		if urls == nil {
+			urls = []*models.URL{}
+		}
+		return printJSON(urls)
+	}
+
+	rows := make([][]string, len(urls))
+	for i, u := range urls {
+		rows[i] = []string{
+			strconv.FormatUint(uint64(u.ID), 10),
+			u.URL,
+			strconv.FormatBool(u.IsMonitored),
+			strconv.FormatBool(u.IsAlive),
+			formatTime(u.LastChecked),
+			formatTime(u.LastSaved),
+			u.SkipReason,
+			u.LinkSource,
+		}
+	}
+	return printTable([]string{"ID", "URL", "MONITORED", "ALIVE", "LAST CHECKED", "LAST SAVED", "SKIP REASON", "SOURCE"}, rows)
+}
+
+// parseBoolFilter parses the value of a true/false filter flag; present
+// is false when value is empty. Invalid values are added to v.
+func parseBoolFilter(v *internal.Validator, key, value string) (b, present bool) {
+	if value == "" {
+		return false, false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		v.AddError(key, "must be true or false")
+	}
+	return b, true
+}
+
+func runURLsAdd(c *command, args []string) error {
+	dc := newDBCommand(c, false)
+	monitored := dc.fs.Bool("monitored", true, "Mark the added URLs as monitored")
+	dc.parse(args, 1, -1)
+
+	for _, u := range dc.fs.Args() {
+		var t time.Time
+		url := models.NewURL(u, t, t, *monitored)
+		if models.ValidateURL(dc.v, url); dc.v.Valid() {
+			dc.v.Check(internal.IsAbsoluteURL(u), "url", fmt.Sprintf("%q must be absolute URL", u))
+		}
+	}
+	if !dc.v.Valid() {
+		printInvalidFlagErrors(dc.fs, dc.v)
+	}
+
+	ctx := context.Background()
+	m, closeDB, err := dc.openModels(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	var errs []error
+	for _, u := range dc.fs.Args() {
+		_, err := m.URLs.GetByURL(ctx, u)
+		switch {
+		case err == nil:
+			errs = append(errs, fmt.Errorf("url '%s' is already present", u))
+			continue
+		case !errors.Is(err, models.ErrRecordNotFound):
+			return err
+		}
+
+		var t time.Time
+		url := models.NewURL(u, t, t, *monitored)
+		if err := m.URLs.Insert(ctx, url); err != nil {
+			return err
+		}
+		fmt.Printf("Added url #%d %s\n", url.ID, url.URL)
+	}
+	return errors.Join(errs...)
+}
+
+// runURLsUpdate runs the urls monitor, unmonitor and revive commands
+func runURLsUpdate(c *command, args []string) error {
+	dc := newDBCommand(c, false)
+	dc.parse(args, 1, -1)
+
+	ctx := context.Background()
+	m, closeDB, err := dc.openModels(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	var errs []error
+	for _, arg := range dc.fs.Args() {
+		url, err := getURLByIdOrURL(ctx, m.URLs, arg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var state string
+		switch c.name {
+		case "urls monitor":
+			url.IsMonitored, state = true, "monitored"
+		case "urls unmonitor":
+			url.IsMonitored, state = false, "not monitored"
+		case "urls revive":
+			url.IsAlive, state = true, "alive"
+		}
+
+		if err := m.URLs.Update(ctx, url); err != nil {
+			errs = append(errs, fmt.Errorf("url '%s': %w", url.URL, err))
+			continue
+		}
+		fmt.Printf("Marked url #%d %s as %s\n", url.ID, url.URL, state)
+	}
+	return errors.Join(errs...)
+}
+
+// getURLByIdOrURL fetches the URL identified by arg, an id or a URL
+func getURLByIdOrURL(ctx context.Context, urls models.URLModel, arg string) (*models.URL, error) {
+	var url *models.URL
+	var err error
+	if id, convErr := strconv.Atoi(arg); convErr == nil {
+		url, err = urls.GetById(ctx, id)
+	} else {
+		url, err = urls.GetByURL(ctx, arg)
+	}
+	if errors.Is(err, models.ErrRecordNotFound) {
+		return nil, fmt.Errorf("url '%s' not found", arg)
+	}
+	return url, err
+}
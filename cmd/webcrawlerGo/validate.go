@@ -2,10 +2,13 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
 	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
 )
 
 func validateFlags(v *internal.Validator, args *cmdFlags) {
@@ -14,6 +17,23 @@ func validateFlags(v *internal.Validator, args *cmdFlags) {
 	v.Check(internal.IsAbsoluteURL(args.baseURL.String()), "baseurl", "must be absolute URL")
 	v.Check(internal.IsValidScheme(args.baseURL.Scheme), "baseurl", "scheme must be http/https")
 
+	// validate crawl scope
+	for _, seed := range args.seeds {
+		v.Check(
+			internal.IsAbsoluteURL(seed.String()) && internal.IsValidScheme(seed.Scheme),
+			"seeds",
+			fmt.Sprintf("'%s' must be absolute http/https URL", seed),
+		)
+	}
+	for _, rule := range args.hostRules {
+		v.Check(webcrawler.ValidHostRule(rule), "hosts", fmt.Sprintf("invalid host '%s'", rule))
+	}
+	v.Check(
+		args.scheme == "" || internal.IsValidScheme(args.scheme),
+		"scheme",
+		"must be one of: http, https",
+	)
+
 	// validate crawler
 	v.Check(
 		*args.nCrawlers >= 1,
@@ -42,6 +62,36 @@ func validateFlags(v *internal.Validator, args *cmdFlags) {
 	v.Check(args.reqDelay >= time.Microsecond, "req-delay", "cannot be less than 1ms")
 	v.Check(args.idleTimeout >= time.Second, "idle-time", "cannot be less than 1s")
 
+	// validate politeness limits
+	v.Check(args.hostRate >= 0, "host-rps", "cannot be negative")
+	v.Check(args.hostConns >= 0, "host-conns", "cannot be negative")
+	v.Check(args.robotsTTL > 0, "robots-ttl", "must be greater than 0")
+
+	// validate transport
+	for _, proxy := range args.transport.Proxies {
+		v.Check(
+			webcrawler.ValidProxy(proxy),
+			"proxies",
+			"must be absolute URLs of scheme http, https, socks5 or socks5h",
+		)
+	}
+	v.Check(
+		(args.transport.CertFile == "") == (args.transport.KeyFile == ""),
+		"client-cert",
+		"-client-cert and -client-key must be provided together",
+	)
+	v.Check(args.transport.ConnectTimeout >= 0, "connect-timeout", "cannot be negative")
+	v.Check(args.transport.ReadTimeout >= 0, "read-timeout", "cannot be negative")
+	v.Check(args.transport.Timeout > 0, "timeout", "must be greater than 0")
+
+	v.Check(
+		args.record == "" || args.replay == "",
+		"record",
+		"-record and -replay cannot be used together",
+	)
+	v.Check(args.source == nil || args.replay == "", "replay", "cannot be used with a local baseurl")
+	v.Check(args.source == nil || args.auth == nil, "auth", "cannot be used with a local baseurl")
+
 	// validate retry times
 	v.Check(
 		*args.retryTime >= 0,
@@ -49,8 +99,32 @@ func validateFlags(v *internal.Validator, args *cmdFlags) {
 		fmt.Sprintf("invalid retry time: %d. Should be >= 0.", *args.retryTime),
 	)
 
+	v.Check(args.retryBackoff >= 0, "retry-backoff", "cannot be negative")
+
+	// validate metrics address
+	if args.metricsAddr != "" {
+		_, _, err := net.SplitHostPort(args.metricsAddr)
+		v.Check(err == nil, "metrics-addr", "must be of the form [host]:port")
+	}
+
+	// validate control address
+	if args.controlAddr != "" {
+		_, _, err := net.SplitHostPort(args.controlAddr)
+		v.Check(err == nil, "control-addr", "must be of the form [host]:port")
+		v.Check(
+			args.controlAddr != args.metricsAddr,
+			"control-addr",
+			"must differ from metrics-addr",
+		)
+	}
+
 	// validate path when save to disk flag is true
 	if args.dbToDisk {
-		v.Check(args.savePath != "", "path", "must be provided with 'db2disk' flag")
+		v.Check(args.savePath != "", "path", "must be provided")
+		v.Check(
+			args.variant == "" || args.variant == models.AllVariants || webcrawler.ValidVariantName(args.variant),
+			"variant",
+			"must be a variant name or '*'",
+		)
 	}
 }
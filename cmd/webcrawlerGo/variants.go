@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+)
+
+// loadVariants reads and validates the fetch variants in the
+// JSON file at path, of the form {"variants": [...]}
+func loadVariants(path string) ([]*webcrawler.Variant, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var input struct {
+		Variants []*webcrawler.Variant `json:"variants"`
+	}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&input); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(input.Variants) == 0 {
+		return nil, fmt.Errorf("%s: no variants defined", path)
+	}
+	if err := webcrawler.ValidateVariants(input.Variants); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return input.Variants, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+	"github.com/0x00f00bar/webcrawlerGo/webhook"
+)
+
+// webhookShutdownTimeout is the time to wait for pending
+// webhook deliveries before exit
+const webhookShutdownTimeout = 30 * time.Second
+
+// loadWebhooks reads and validates the webhooks in the JSON
+// file at path, of the form {"webhooks": [...]}
+func loadWebhooks(path string) ([]*webhook.Webhook, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var input struct {
+		Webhooks []*webhook.Webhook `json:"webhooks"`
+	}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&input); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(input.Webhooks) == 0 {
+		return nil, fmt.Errorf("%s: no webhooks defined", path)
+	}
+
+	for i, hook := range input.Webhooks {
+		if err := hook.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: webhook #%d: %w", path, i+1, err)
+		}
+		if slices.ContainsFunc(input.Webhooks[:i], func(h *webhook.Webhook) bool { return h.Name == hook.Name }) {
+			return nil, fmt.Errorf("%s: webhook %q: name must be unique", path, hook.Name)
+		}
+	}
+	return input.Webhooks, nil
+}
+
+// startWebhooks delivers the events of bus to hooks in background and
+// records the deliveries to deliveries. The returned func stops receiving
+// events and waits for pending deliveries, up to webhookShutdownTimeout.
+func startWebhooks(
+	hooks []*webhook.Webhook,
+	bus *webcrawler.EventBus,
+	deliveries models.DeliveryModel,
+	logger *slog.Logger,
+) (stop func(), err error) {
+	dispatcher := webhook.NewDispatcher(hooks, deliveries, logger)
+
+	// deliveries are slower than crawlers; hold events of a busy crawl
+	sub, err := bus.Subscribe(dispatcher.Filter(), 4096)
+	if err != nil {
+		return nil, err
+	}
+	dispatcher.Start(sub.C)
+
+	return func() {
+		sub.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		if err := dispatcher.Shutdown(ctx); err != nil {
+			logger.Warn("abandoned pending webhook deliveries", "err", err)
+		}
+		if dropped := sub.Dropped(); dropped > 0 {
+			logger.Warn("webhook events dropped", "count", dropped)
+		}
+	}, nil
+}
+
+func (app *webapp) getDeliveryByIdHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	delivery, err := app.Models.Deliveries.GetById(r.Context(), int(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"delivery": delivery}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *webapp) listDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		models.DeliveryFilter
+		models.CommonFilters
+	}
+
+	v := internal.NewValidator()
+	qs := r.URL.Query()
+
+	input.DeliveryFilter.Webhook = app.readString(qs, "webhook", "")
+	input.DeliveryFilter.Event = app.readString(qs, "event", "")
+	input.DeliveryFilter.Status = app.readString(qs, "status", "")
+	if input.DeliveryFilter.Status != "" {
+		v.Check(
+			internal.PermittedValue(
+				input.DeliveryFilter.Status,
+				models.DeliveryPending,
+				models.DeliveryDelivered,
+				models.DeliveryFailed,
+				models.DeliveryDropped,
+			),
+			"status",
+			"invalid delivery status",
+		)
+	}
+
+	input.CommonFilters.Page = app.readInt(qs, "page", 1, v)
+	input.CommonFilters.PageSize = app.readInt(qs, "page_size", 10, v)
+	input.CommonFilters.Sort = app.readString(qs, "sort", "-id")
+	var safeSortList []string
+	safeSortList = append(safeSortList, models.DeliveryColumns...)
+	safeSortList = append(safeSortList, internal.PrefixString(models.DeliveryColumns, "-")...)
+	input.CommonFilters.SortSafeList = safeSortList
+
+	if models.ValidateCommonFilters(v, input.CommonFilters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	deliveries, err := app.Models.Deliveries.GetAll(r.Context(), input.DeliveryFilter, input.CommonFilters)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidOrderBy):
+			app.badRequestResponse(w, r, err)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"delivery_list": deliveries}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,238 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrEngineNotRunning is returned when a Command is sent
+// to an Engine which is not running
+var ErrEngineNotRunning = errors.New("engine: not running")
+
+// ControlOp is a runtime control operation of a running Engine
+type ControlOp int
+
+const (
+	OpPause          ControlOp = iota // crawlers stop taking URLs from queue
+	OpResume                          // paused crawlers resume
+	OpSetDelay                        // change RequestDelay of crawlers
+	OpAddCrawlers                     // start N more crawlers
+	OpRemoveCrawlers                  // stop N crawlers after their in-flight URL
+	OpDrain                           // stop all crawlers after their in-flight URL and end the run
+)
+
+var controlOpNames = []string{"pause", "resume", "set-delay", "add-crawlers", "remove-crawlers", "drain"}
+
+func (op ControlOp) String() string {
+	if int(op) < len(controlOpNames) && op >= 0 {
+		return controlOpNames[op]
+	}
+	return fmt.Sprintf("ControlOp(%d)", op)
+}
+
+// Command is sent to a running Engine through its control channel
+type Command struct {
+	Op    ControlOp
+	Delay time.Duration // new RequestDelay for OpSetDelay
+	N     int           // number of crawlers for OpAddCrawlers and OpRemoveCrawlers
+	reply chan error
+}
+
+// crawlControl holds the runtime settings shared by crawlers.
+// It is changed only by the Engine while handling a Command.
+type crawlControl struct {
+	requestDelay atomic.Int64 // time.Duration
+	draining     atomic.Bool
+
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{} // closed on resume; guarded by mu
+}
+
+// newCrawlControl returns pointer to new crawlControl with requestDelay
+func newCrawlControl(requestDelay time.Duration) *crawlControl {
+	cc := &crawlControl{}
+	cc.requestDelay.Store(int64(requestDelay))
+	return cc
+}
+
+// delay returns the current request delay
+func (cc *crawlControl) delay() time.Duration {
+	return time.Duration(cc.requestDelay.Load())
+}
+
+func (cc *crawlControl) pause() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if !cc.paused {
+		cc.paused = true
+		cc.resumed = make(chan struct{})
+	}
+}
+
+func (cc *crawlControl) resume() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.paused {
+		cc.paused = false
+		close(cc.resumed)
+	}
+}
+
+func (cc *crawlControl) isPaused() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.paused
+}
+
+// waitWhilePaused blocks while crawlers are paused or until ctx is done
+func (cc *crawlControl) waitWhilePaused(ctx context.Context) error {
+	cc.mu.Lock()
+	paused, resumed := cc.paused, cc.resumed
+	cc.mu.Unlock()
+
+	if !paused {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Control sends cmd to the running engine and waits for it to be applied.
+// Returns ErrEngineNotRunning when Run is not in progress.
+func (e *Engine) Control(cmd Command) error {
+	e.mu.Lock()
+	done := e.done
+	e.mu.Unlock()
+
+	if done == nil {
+		return ErrEngineNotRunning
+	}
+
+	cmd.reply = make(chan error, 1)
+	select {
+	case e.control <- cmd:
+		return <-cmd.reply
+	case <-done:
+		return ErrEngineNotRunning
+	}
+}
+
+// Pause stops crawlers from taking new URLs from the queue.
+// URLs being processed are completed.
+func (e *Engine) Pause() error {
+	return e.Control(Command{Op: OpPause})
+}
+
+// Resume resumes paused crawlers
+func (e *Engine) Resume() error {
+	return e.Control(Command{Op: OpResume})
+}
+
+// SetRequestDelay changes the delay between subsequent requests of crawlers
+func (e *Engine) SetRequestDelay(d time.Duration) error {
+	return e.Control(Command{Op: OpSetDelay, Delay: d})
+}
+
+// AddCrawlers starts n more crawlers
+func (e *Engine) AddCrawlers(n int) error {
+	return e.Control(Command{Op: OpAddCrawlers, N: n})
+}
+
+// RemoveCrawlers stops n crawlers once their in-flight URL is processed.
+// At least one crawler is kept; use Drain to stop all crawlers.
+func (e *Engine) RemoveCrawlers(n int) error {
+	return e.Control(Command{Op: OpRemoveCrawlers, N: n})
+}
+
+// Drain stops all crawlers once their in-flight URLs are processed.
+// Run returns with Summary.Drained set and the remaining
+// URLs are left in the queue.
+func (e *Engine) Drain() error {
+	return e.Control(Command{Op: OpDrain})
+}
+
+// handleCommand applies cmd; called only by Run
+func (e *Engine) handleCommand(cmd Command) error {
+	ctrl := e.cfg.control
+
+	switch cmd.Op {
+	case OpPause:
+		ctrl.pause()
+	case OpResume:
+		ctrl.resume()
+	case OpSetDelay:
+		if cmd.Delay < 0 {
+			return fmt.Errorf("engine: invalid request delay %s", cmd.Delay)
+		}
+		ctrl.requestDelay.Store(int64(cmd.Delay))
+	case OpAddCrawlers:
+		if cmd.N < 1 {
+			return fmt.Errorf("engine: invalid number of crawlers to add: %d", cmd.N)
+		}
+		if ctrl.draining.Load() {
+			return errors.New("engine: cannot add crawlers while draining")
+		}
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		// all crawlers may have exited before cmd was received
+		if e.running == 0 {
+			return ErrEngineNotRunning
+		}
+		for range cmd.N {
+			name := fmt.Sprintf("%s#%03d", e.namePrefix, len(e.crawlers)+1)
+			crawler := newCrawler(name, e.cfg)
+			crawler.managed = true
+			e.crawlers = append(e.crawlers, crawler)
+			e.startCrawler(crawler)
+		}
+	case OpRemoveCrawlers:
+		if cmd.N < 1 {
+			return fmt.Errorf("engine: invalid number of crawlers to remove: %d", cmd.N)
+		}
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		active := e.activeCrawlers()
+		if cmd.N >= len(active) {
+			return fmt.Errorf(
+				"engine: cannot remove %d of %d crawlers; use drain to stop all crawlers",
+				cmd.N,
+				len(active),
+			)
+		}
+		// stop the most recently added crawlers first
+		for _, crawler := range active[len(active)-cmd.N:] {
+			crawler.requestStop()
+		}
+	case OpDrain:
+		ctrl.draining.Store(true)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		for _, crawler := range e.activeCrawlers() {
+			crawler.requestStop()
+		}
+	default:
+		return fmt.Errorf("engine: unknown control op %s", cmd.Op)
+	}
+	return nil
+}
+
+// activeCrawlers returns the crawlers which are not asked to stop.
+// e.mu must be held.
+func (e *Engine) activeCrawlers() []*Crawler {
+	var active []*Crawler
+	for _, crawler := range e.crawlers {
+		if !crawler.stopRequested() && crawler.Status().State != CrawlerStopped {
+			active = append(active, crawler)
+		}
+	}
+	return active
+}
@@ -0,0 +1,114 @@
+package webcrawler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// cookieJar is a [http.CookieJar] keeping the cookies it receives
+// so that they can be saved to a file and loaded in the next run
+type cookieJar struct {
+	http.CookieJar
+	path string // file cookies are saved to; not saved when empty
+
+	mu      sync.Mutex
+	cookies map[string]*savedCookie // keyed by <url>|<domain>|<path>|<name>
+}
+
+// savedCookie is a cookie with the URL it was received from
+type savedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// newCookieJar returns pointer to a new cookieJar with
+// the unexpired cookies of the file at path, if it exists
+func newCookieJar(path string) (*cookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	cj := &cookieJar{
+		CookieJar: jar,
+		path:      path,
+		cookies:   map[string]*savedCookie{},
+	}
+	if path == "" {
+		return cj, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cj, nil
+		}
+		return nil, err
+	}
+	var saved []*savedCookie
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return nil, err
+	}
+	for _, sc := range saved {
+		u, err := url.Parse(sc.URL)
+		if err != nil || sc.Cookie == nil {
+			continue
+		}
+		cj.SetCookies(u, []*http.Cookie{sc.Cookie})
+	}
+	return cj, nil
+}
+
+// SetCookies implements [http.CookieJar]
+func (cj *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	cj.CookieJar.SetCookies(u, cookies)
+
+	origin := (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+	now := time.Now()
+
+	cj.mu.Lock()
+	defer cj.mu.Unlock()
+	for _, c := range cookies {
+		key := origin + "|" + c.Domain + "|" + c.Path + "|" + c.Name
+		// Max-Age is relative to now; keep the time it expires at
+		saved := *c
+		if saved.MaxAge > 0 {
+			saved.Expires = now.Add(time.Duration(saved.MaxAge) * time.Second)
+			saved.MaxAge = 0
+		}
+		if saved.MaxAge < 0 || (!saved.Expires.IsZero() && saved.Expires.Before(now)) {
+			delete(cj.cookies, key)
+			continue
+		}
+		cj.cookies[key] = &savedCookie{URL: origin, Cookie: &saved}
+	}
+}
+
+// save writes the unexpired cookies of cj to its file, if any
+func (cj *cookieJar) save() error {
+	if cj.path == "" {
+		return nil
+	}
+
+	now := time.Now()
+	cj.mu.Lock()
+	saved := make([]*savedCookie, 0, len(cj.cookies))
+	for _, sc := range cj.cookies {
+		if sc.Cookie.Expires.IsZero() || sc.Cookie.Expires.After(now) {
+			saved = append(saved, sc)
+		}
+	}
+	cj.mu.Unlock()
+
+	b, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	// cookies are credentials
+	return os.WriteFile(cj.path, b, 0600)
+}
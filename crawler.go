@@ -1,49 +1,54 @@
 package webcrawler
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/jimsmart/grobotstxt"
 
 	"github.com/0x00f00bar/webcrawlerGo/internal"
 	"github.com/0x00f00bar/webcrawlerGo/models"
 	"github.com/0x00f00bar/webcrawlerGo/queue"
 )
 
-var (
-	defaultSleepDuration = 500 * time.Microsecond
-	invalidHrefPrefixs   = []string{"file:", "mailto:", "tel:", "javascript:", "#", "data:"}
-)
+var invalidHrefPrefixs = []string{"file:", "mailto:", "tel:", "javascript:", "#", "data:"}
 
 // Crawler crawls the URL fetched from Queue and saves
 // the contents to Models.
 //
-// Crawler will quit after IdleTimeout when queue is empty
+// Crawler will quit when Queue is drained i.e. the queue is empty
+// and no crawler is processing an item which may add new items.
 type Crawler struct {
 	Name string // Name of crawler for easy identification
 	*CrawlerConfig
+	status   crawlerStatus // current state of crawler (internal)
+	managed  bool          // crawler is run by an Engine (internal)
+	stop     chan struct{} // closed to stop crawler after in-flight URL (internal)
+	stopOnce sync.Once
 }
 
 // PrettyLogger interface is used to write scrolling logs to terminal
 type PrettyLogger interface {
-	// Log will send message to PrettyLogger instance
-	// to be written on terminal
-	Log(string)
+	// Log will send the structured log record to PrettyLogger
+	// instance to be rendered on terminal
+	Log(slog.Record)
 
 	// Quit should initiate call to quit PrettyLogger when
-	// the last crawler have exited
+	// the last crawler have exited. Called once by Engine;
+	// called by every crawler when run without an Engine.
 	Quit()
 }
 
@@ -56,19 +61,40 @@ type InvalidURLCache struct {
 type CrawlerConfig struct {
 	Queue            *queue.UniqueQueue // global queue
 	Models           *models.Models     // models to use
-	BaseURL          *url.URL           // base URL to crawl
+	BaseURL          *url.URL           // base URL to crawl; set to the first seed of Scope when nil
+	Scope            *Scope             // URLs to crawl; defaults to the host of BaseURL
 	UserAgent        string             // user-agent to use while crawling
 	MarkedURLs       []string           // marked URL to save to model
 	IgnorePatterns   []string           // URL pattern to ignore
-	RequestDelay     time.Duration      // delay between subsequent requests
-	IdleTimeout      time.Duration      // timeout after which crawler quits when queue is empty
-	Logger           *log.Logger        // will log to [os.Stdout] when nil and when no PrettyLogger; ONLY log to file if also using PrettyLogger
+	RequestDelay     time.Duration      // delay between subsequent requests; change with Engine.SetRequestDelay once running
+	HostRate         float64            // max requests per second to a host, shared by crawlers; robots.txt Crawl-delay is kept when longer
+	HostConns        int                // max concurrent requests to a host; no limit when 0
+	AdaptiveDelay    bool               // slow down requests to a host on rising latency, HTTP 429 and 5xx; speed back up when healthy
+	IdleTimeout      time.Duration      // Deprecated: crawlers quit when the queue is drained
+	Logger           *slog.Logger       // will log to [os.Stdout] when nil and when no PrettyLogger; ONLY log to file in LogDir if also using PrettyLogger
+	LogDir           string             // directory of log file created when Logger is nil; defaults to current directory
 	RetryTimes       int                // no. of times to retry failed request
+	RetryBackoff     time.Duration      // delay before a failed URL is pushed back to queue
 	FailedRequests   map[string]int     // map to store failed requests stats
+	ErrorPolicy      ErrorPolicy        // what to do on StorageError; aborts the crawl by default
+	Directives       DirectivePolicy    // what to do with noindex/nofollow of pages and links; only records them by default
+	Errors           chan<- error       // optional channel to report crawl errors; sends never block
 	KnownInvalidURLs *InvalidURLCache   // known map of invalid URLs
-	Ctx              context.Context    // context to quit on SIGINT/SIGTERM
-	robotsTxt        *string            // robots.txt as string (internal)
-	PrettyLogger     PrettyLogger       // optional logger to write to screen
+	RunID            uint               // crawl run to tag saved pages and fetched URLs with; 0 when not recorded
+	Events           *EventBus          // optional bus to publish crawl events to
+	Robots           RobotsPolicy       // robots.txt policy shared by crawlers; in-memory RobotsCache when nil
+	Auth             *Auth              // optional credentials and session of requests; started by Engine.Run
+	Transport        *TransportConfig   // proxies, TLS and timeouts of the clients of Engine and of the default Robots
+	Fetcher          Fetcher            // fetches requests of crawlers and of the default Robots; HTTPFetcher of the client of Crawl when nil
+	Variants         []*Variant         // request profiles saved pages are also fetched and saved with
+	Extractors       *LinkExtractors    // link extractors by content type of pages; the html extractor when nil
+	hosts            *hostLimiter       // politeness limits of crawled hosts (internal)
+	PrettyLogger     PrettyLogger       // optional logger to write to screen; nil when headless
+	stats            *crawlStats        // stats shared by crawlers (internal)
+	control          *crawlControl      // runtime settings changed by Engine (internal)
+	sessionRetries   map[string]int     // requeues of URLs after login (internal)
+	robotsRetries    map[string]int     // requeues of URLs while robots.txt is unreachable (internal)
+	failedMu         sync.Mutex         // guards FailedRequests, sessionRetries and robotsRetries (internal)
 }
 
 // NewCrawler return pointer to a new Crawler
@@ -78,7 +104,7 @@ func NewCrawler(name string, cfg *CrawlerConfig) (*Crawler, error) {
 		return nil, err
 	}
 
-	return &Crawler{name, cfg}, nil
+	return newCrawler(name, cfg), nil
 }
 
 // NNewCrawlers returns N new Crawlers configured with cfg.
@@ -97,12 +123,47 @@ func NNewCrawlers(n int, namePrefix string, cfg *CrawlerConfig) ([]*Crawler, err
 
 	for i := range n {
 		name := fmt.Sprintf("%s#%03d", namePrefix, i+1)
-		crawlers = append(crawlers, &Crawler{name, cfg})
+		crawlers = append(crawlers, newCrawler(name, cfg))
 	}
 
 	return crawlers, nil
 }
 
+// newCrawler returns pointer to a new idle Crawler
+func newCrawler(name string, cfg *CrawlerConfig) *Crawler {
+	c := &Crawler{Name: name, CrawlerConfig: cfg, stop: make(chan struct{})}
+	c.status.status.Name = name
+	c.setState(CrawlerIdle, "")
+	return c
+}
+
+// Status returns the current state of c
+func (c *Crawler) Status() CrawlerStatus {
+	return c.status.get()
+}
+
+// requestStop asks c to stop after processing its in-flight URL
+func (c *Crawler) requestStop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+// stopRequested tells if c was asked to stop
+func (c *Crawler) stopRequested() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// setState sets the current state of c and the URL being processed
+func (c *Crawler) setState(state CrawlerState, url string) {
+	c.status.set(state, url)
+}
+
 // validateConfig verifies crawler config
 // if Logger is nil, creates new os.Stdout default logger
 func validateConfig(cfg *CrawlerConfig) error {
@@ -114,41 +175,94 @@ func validateConfig(cfg *CrawlerConfig) error {
 		return errors.New("crawler: models cannot be nil")
 	}
 
-	if !internal.IsValidScheme(cfg.BaseURL.Scheme) {
-		return fmt.Errorf(
-			"crawler: invalid scheme '%s'. Supported schemes: HTTP, HTTPS",
-			cfg.BaseURL.Scheme,
-		)
+	if cfg.Scope == nil {
+		if cfg.BaseURL == nil {
+			return errors.New("crawler: Base URL or Scope must be set")
+		}
+		cfg.Scope = &Scope{Seeds: []*url.URL{cfg.BaseURL}}
 	}
-
-	if !internal.IsAbsoluteURL(cfg.BaseURL.String()) {
-		return errors.New("crawler: Base URL should be absolute")
+	if err := cfg.Scope.validate(); err != nil {
+		return fmt.Errorf("crawler: %w", err)
+	}
+	if cfg.BaseURL == nil {
+		cfg.BaseURL = cfg.Scope.Seeds[0]
 	}
 
 	if cfg.Logger == nil {
 		if cfg.PrettyLogger == nil {
-			cfg.Logger = log.New(os.Stdout, "crawler", log.LstdFlags|log.Lshortfile)
+			cfg.Logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
 		} else {
-			logFileName := fmt.Sprintf(
-				"./webcrawlerGo-%s.log",
-				time.Now().Format("02-01-2006-15-04-05"),
+			logDir := cfg.LogDir
+			if logDir == "" {
+				logDir = "."
+			}
+			if err := os.MkdirAll(logDir, 0744); err != nil {
+				return fmt.Errorf("crawler: could not create log directory: %v", err)
+			}
+			logFileName := filepath.Join(
+				logDir,
+				fmt.Sprintf("webcrawlerGo-%s.log", time.Now().Format("02-01-2006-15-04-05")),
 			)
 			f, err := os.OpenFile(logFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 			if err != nil {
-				panic(err)
+				return fmt.Errorf("crawler: could not open log file: %v", err)
 			}
-			cfg.Logger = log.New(f, "", log.LstdFlags|log.Lshortfile)
+			cfg.Logger = slog.New(slog.NewTextHandler(f, nil))
 		}
+	}
 
+	if cfg.Transport != nil {
+		if err := cfg.Transport.Validate(); err != nil {
+			return fmt.Errorf("crawler: %w", err)
+		}
 	}
 
-	// get robots.txt file
-	if cfg.robotsTxt == nil {
-		robotTxt, err := getRobotsTxt(cfg.BaseURL, cfg.UserAgent)
-		if err != nil {
-			return err
+	// robots.txt of a host is fetched when its first URL is checked
+	if cfg.Robots == nil {
+		robots := NewRobotsCache(nil, DefaultRobotsTTL)
+		robots.Fetcher = cfg.Fetcher
+		if cfg.Transport != nil {
+			client, err := cfg.Transport.NewClient()
+			if err != nil {
+				return fmt.Errorf("crawler: %w", err)
+			}
+			robots.Client = client
+		}
+		cfg.Robots = robots
+	}
+
+	if cfg.Auth != nil {
+		if err := cfg.Auth.Validate(); err != nil {
+			return fmt.Errorf("crawler: auth: %w", err)
 		}
-		cfg.robotsTxt = robotTxt
+		cfg.Auth.scopeTo(cfg.Scope.Seeds)
+	}
+
+	if err := ValidateVariants(cfg.Variants); err != nil {
+		return fmt.Errorf("crawler: %w", err)
+	}
+
+	if cfg.Extractors == nil {
+		cfg.Extractors, _ = DefaultLinkExtractors()
+	}
+
+	if cfg.HostRate < 0 || cfg.HostConns < 0 {
+		return errors.New("crawler: HostRate and HostConns cannot be negative")
+	}
+	if cfg.hosts == nil {
+		cfg.hosts = newHostLimiter(cfg.HostRate, cfg.HostConns, cfg.AdaptiveDelay, cfg.Robots, cfg.UserAgent)
+	}
+
+	if cfg.sessionRetries == nil {
+		cfg.sessionRetries = map[string]int{}
+	}
+	if cfg.robotsRetries == nil {
+		cfg.robotsRetries = map[string]int{}
+	}
+
+	// init retry stats map when retries are enabled
+	if cfg.RetryTimes > 0 && cfg.FailedRequests == nil {
+		cfg.FailedRequests = map[string]int{}
 	}
 
 	// make a map of known invalid paths for efficient filtering
@@ -156,296 +270,848 @@ func validateConfig(cfg *CrawlerConfig) error {
 		cfg.KnownInvalidURLs = &InvalidURLCache{}
 	}
 
+	if cfg.stats == nil {
+		cfg.stats = newCrawlStats()
+	}
+
+	if cfg.control == nil {
+		cfg.control = newCrawlControl(cfg.RequestDelay)
+	}
+
 	return nil
 }
 
-// Crawl to begin crawling
-func (c *Crawler) Crawl(client *http.Client) {
-	startTime := time.Now()
+// Crawl to begin crawling.
+//
+// Crawl returns nil when the queue is drained i.e. the queue is empty
+// and no other crawler is processing an item, or when the crawler is
+// stopped by its Engine. Returns ctx.Err() when ctx is done and an error
+// wrapping ErrCrawlAborted when a StorageError is encountered with ErrorPolicyAbort.
+// ctx is passed on to every HTTP request and model call. Requests are sent
+// with client unless Fetcher is set.
+func (c *Crawler) Crawl(ctx context.Context, client *http.Client) error {
+	// crawlers run by Engine are quit by the Engine
+	if c.PrettyLogger != nil && !c.managed {
+		defer c.PrettyLogger.Quit()
+	}
+	defer c.setState(CrawlerStopped, "")
+
+	// stop waiting for a URL when the crawler is stopped;
+	// in-flight URL is processed with ctx
+	waitCtx, cancelWait := context.WithCancel(ctx)
+	defer cancelWait()
+	go func() {
+		select {
+		case <-c.stop:
+			cancelWait()
+		case <-waitCtx.Done():
+		}
+	}()
+
+	fetcher := c.fetcher(client)
 
 	for {
-		select {
-		case <-c.Ctx.Done():
-			msg := fmt.Sprintf("%s: Termination signal received. Shutting down", c.Name)
-			c.Log(msg)
-			return
+		// queue may still hand out URLs after ctx is done
+		if err := ctx.Err(); err != nil {
+			c.Log(slog.LevelInfo, "termination signal received, shutting down")
+			return err
+		}
+
+		if c.stopRequested() {
+			c.Log(slog.LevelInfo, "crawler stopped")
+			return nil
+		}
+
+		// wait while paused by Engine
+		if c.control.isPaused() {
+			c.setState(CrawlerPaused, "")
+		}
+		if err := c.control.waitWhilePaused(waitCtx); err != nil && ctx.Err() == nil {
+			continue
+		}
+
+		// get item from queue; blocks while queue is empty and
+		// other crawlers are processing items
+		c.setState(CrawlerIdle, "")
+		urlpath, err := c.Queue.Dequeue(waitCtx)
+		if err != nil {
+			switch {
+			case c.stopRequested() && ctx.Err() == nil:
+				c.Log(slog.LevelInfo, "crawler stopped")
+				return nil
+			case errors.Is(err, queue.ErrQueueDrained):
+				c.Log(slog.LevelInfo, "queue is empty, quitting")
+				return nil
+			case errors.Is(context.Cause(ctx), ErrCrawlAborted):
+				c.Log(slog.LevelWarn, "crawl aborted, shutting down")
+			default:
+				c.Log(slog.LevelInfo, "termination signal received, shutting down")
+			}
+			return err
+		}
+		queueDepth.Set(float64(c.Queue.Size()))
+
+		err = c.crawlURL(ctx, urlpath, fetcher)
+		if err != nil {
+			err = c.handleError(ctx, urlpath, err)
+		}
+
+		// mark item as processed after all the embedded URLs are pushed to queue
+		c.Queue.Done()
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handleError logs and reports err as per the error policy.
+// Returns non-nil error when the crawl should be aborted.
+func (c *Crawler) handleError(ctx context.Context, urlpath string, err error) error {
+	// errors caused by shutting down are not reported
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	// URLs of hosts whose robots.txt is unreachable are requeued without
+	// holding the crawler; only those left unchecked are reported
+	if errors.Is(err, ErrRobotsUnreachable) && c.requeueUnreachable(urlpath) {
+		c.Log(slog.LevelInfo, "robots.txt unreachable, url requeued", "url", urlpath)
+		return nil
+	}
+
+	c.reportError(err)
+
+	var fetchErr *FetchError
+	var storageErr *StorageError
+
+	switch {
+	case errors.As(err, &fetchErr):
+		// only failed requests are retried
+		switch {
+		case errors.Is(err, ErrSessionExpired):
+			// logged in again; requeued regardless of RetryTimes
+			if c.requeueAfterLogin(urlpath) {
+				c.Log(slog.LevelInfo, "logged in again, url requeued", "url", urlpath)
+			} else {
+				c.Log(slog.LevelWarn, "session expired after login, skipping url", "url", urlpath)
+			}
+		case errors.Is(err, ErrRobotsUnreachable):
+			// the URL is left unchecked to be crawled by a later run
+			c.Log(slog.LevelWarn, "robots.txt unreachable, url deferred", "url", urlpath)
+		case fetchErr.StatusCode == 0:
+			c.Log(slog.LevelWarn, "GET request failed", "url", urlpath, "err", fetchErr.Err)
+			c.retry(ctx, urlpath)
 		default:
-			// get item from queue
-			urlpath, err := c.Queue.Remove()
-
-			// if queue is empty wait for defaultSleepDuration; retry upto idle timeout before quitting
-			if errors.Is(err, queue.ErrEmptyQueue) {
-				if time.Since(startTime) > c.IdleTimeout {
-					msg := fmt.Sprintf("%s: Queue is empty, quitting.", c.Name)
-					c.Log(msg)
-					c.PrettyLogger.Quit()
-					return
-				}
-				time.Sleep(defaultSleepDuration)
-				continue
+			args := []any{"url", urlpath, "status", fetchErr.StatusCode}
+			if fetchErr.Err != nil {
+				args = append(args, "err", fetchErr.Err)
 			}
+			c.Log(slog.LevelWarn, "invalid response", args...)
+		}
 
-			resp, err := c.getURL(urlpath, client)
-			if err != nil {
-				msg := fmt.Sprintf(
-					"%s: Error in GET request: %v for url: '%s'",
-					c.Name,
-					err,
-					urlpath,
-				)
-				c.Log(msg)
-				// check that FailedRequests is not nil (when map is not initialised i.e. RetryTimes==0)
-				if c.FailedRequests != nil && c.FailedRequests[urlpath] < c.RetryTimes {
-					// and add the url back to queue
-					c.Queue.InsertForce(urlpath)
-					c.FailedRequests[urlpath] += 1
-				}
-				continue
+	case errors.As(err, &storageErr):
+		switch c.ErrorPolicy {
+		case ErrorPolicySkip:
+			c.Log(slog.LevelError, "storage error, skipping url", "url", urlpath, "err", err)
+		case ErrorPolicyRetry:
+			c.Log(slog.LevelError, "storage error", "url", urlpath, "err", err)
+			if !c.retry(ctx, urlpath) {
+				c.Log(slog.LevelError, "retries exhausted", "url", urlpath)
 			}
+		default:
+			c.Log(slog.LevelError, "storage error, aborting crawl", "url", urlpath, "err", err)
+			return fmt.Errorf("%w: %w", ErrCrawlAborted, err)
+		}
 
-			// if response not 200 OK
-			if resp.StatusCode != http.StatusOK {
-				msg := fmt.Sprintf("%s: Invalid HTTP status code received %d for url: '%s'",
-					c.Name,
-					resp.StatusCode,
-					urlpath,
-				)
-				c.Log(msg)
-
-				// mark URL as dead if HTTP 404 encountered
-				// crawler will never crawl a URL again which is marked as dead
-				// but will know that it have seen the URL before through the queue
-				if resp.StatusCode == http.StatusNotFound {
-					uModel, err := c.Models.URLs.GetByURL(urlpath)
-					if err != nil {
-						c.Log(fmt.Sprintf("%s: Error: could not get URL '%s' from model: %v", c.Name, urlpath, err))
-						runtime.Goexit()
-					}
-					uModel.IsAlive = false
-					uModel.LastChecked = time.Now()
-					err = c.Models.URLs.Update(uModel)
-					if err != nil {
-						c.Log(fmt.Sprintf("%s: FATAL. could not update URL '%s' model: %v", c.Name, uModel.URL, err))
-						runtime.Goexit()
-					}
-				}
+	default:
+		c.Log(slog.LevelDebug, "skipped url", "url", urlpath, "err", err)
+	}
 
-				continue
-			}
+	return nil
+}
+
+// reportError counts err in crawl stats and sends it
+// to c.Errors without blocking. PolicySkipError is only
+// counted as a skipped URL; it is not a crawl error.
+func (c *Crawler) reportError(err error) {
+	var skipErr *PolicySkipError
+	if errors.As(err, &skipErr) {
+		c.stats.skippedURLs.Add(1)
+		return
+	}
+
+	c.stats.addError(err)
+	c.publish(Event{Type: EventError, URL: errorURL(err), Error: err.Error()})
+
+	if c.Errors != nil {
+		select {
+		case c.Errors <- err:
+		default:
+		}
+	}
+}
+
+// requeueAfterLogin pushes urlpath, whose session expired, back to queue
+// unless it expired maxSessionRetries times already, as the page may look
+// expired to a fresh session too. Returns false when it was not pushed back.
+func (c *Crawler) requeueAfterLogin(urlpath string) bool {
+	c.failedMu.Lock()
+	if c.sessionRetries[urlpath] >= maxSessionRetries {
+		c.failedMu.Unlock()
+		return false
+	}
+	c.sessionRetries[urlpath]++
+	c.failedMu.Unlock()
+
+	c.Queue.InsertForce(urlpath)
+	retriesTotal.Inc()
+	return true
+}
+
+// requeueUnreachable pushes urlpath, whose host has unreachable robots.txt,
+// back to queue once robots.txt is fetched again, unless it was requeued
+// RetryTimes times already. Unlike retry it does not wait for the backoff,
+// so that the URLs of the host do not hold crawlers. Returns false when
+// it was not pushed back.
+func (c *Crawler) requeueUnreachable(urlpath string) bool {
+	c.failedMu.Lock()
+	if c.robotsRetries[urlpath] >= c.RetryTimes {
+		c.failedMu.Unlock()
+		return false
+	}
+	c.robotsRetries[urlpath]++
+	c.failedMu.Unlock()
+
+	c.Queue.InsertAfter(urlpath, max(c.RetryBackoff, robotsRetryInterval))
+	retriesTotal.Inc()
+	return true
+}
+
+// retry pushes urlpath back to queue after RetryBackoff when it has
+// failed less than RetryTimes. Returns false when retries are exhausted.
+func (c *Crawler) retry(ctx context.Context, urlpath string) bool {
+	c.failedMu.Lock()
+	// check that FailedRequests is not nil (when map is not initialised i.e. RetryTimes==0)
+	if c.FailedRequests == nil || c.FailedRequests[urlpath] >= c.RetryTimes {
+		c.failedMu.Unlock()
+		return false
+	}
+	c.FailedRequests[urlpath] += 1
+	c.failedMu.Unlock()
+
+	// wait while the URL is still in flight so that
+	// the queue is not drained before it is pushed back
+	c.setState(CrawlerWaiting, urlpath)
+	sleep(ctx, c.RetryBackoff)
+	c.Queue.InsertForce(urlpath)
+	retriesTotal.Inc()
+	return true
+}
+
+// crawlURL fetches urlpath, pushes the embedded URLs to queue
+// and saves the content of urlpath when marked or monitored
+func (c *Crawler) crawlURL(ctx context.Context, urlpath string, fetcher Fetcher) error {
+	c.stats.urlsCrawled.Add(1)
 
-			doc, err := goquery.NewDocumentFromReader(resp.Body)
+	parsedURL, err := url.Parse(urlpath)
+	if err != nil {
+		return &FetchError{URL: urlpath, Err: err}
+	}
+
+	// seeds and URLs loaded from DB are checked against robots.txt here;
+	// only disallowed URLs are skipped, others are retried or left unchecked
+	if err := c.Robots.Allowed(ctx, parsedURL, c.UserAgent); err != nil {
+		if errors.Is(err, ErrRobotsDisallowed) {
+			return &PolicySkipError{URL: urlpath, Reason: err.Error()}
+		}
+		return &FetchError{URL: urlpath, Err: err}
+	}
+
+	// wait for the politeness limits of the host shared by crawlers
+	c.setState(CrawlerWaiting, urlpath)
+	hostDone, err := c.hosts.wait(ctx, parsedURL)
+	if err != nil {
+		return &FetchError{URL: urlpath, Err: err}
+	}
+
+	c.setState(CrawlerFetching, urlpath)
+	fetchStart := time.Now()
+	requestsInFlight.Inc()
+	resp, err := c.getURL(ctx, urlpath, fetcher, nil)
+	requestsInFlight.Dec()
+	hostDone(resp, time.Since(fetchStart))
+	if err != nil {
+		return &FetchError{URL: urlpath, Err: err}
+	}
+	// close response body
+	defer resp.Body.Close()
+
+	if err := c.checkSession(ctx, fetcher, urlpath, resp, "", fetchStart); err != nil {
+		return err
+	}
+
+	fetchDurationSeconds.Observe(time.Since(fetchStart).Seconds())
+	pagesFetchedTotal.Inc()
+	responsesTotal.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	c.stats.addStatusCode(resp.StatusCode)
+	c.setState(CrawlerProcessing, urlpath)
+	c.publish(Event{
+		Type:       EventFetchCompleted,
+		URL:        urlpath,
+		StatusCode: resp.StatusCode,
+		Duration:   time.Since(fetchStart),
+	})
+
+	c.Log(
+		slog.LevelDebug,
+		"fetched url",
+		"url", urlpath,
+		"status", resp.StatusCode,
+		"duration", time.Since(fetchStart),
+	)
+
+	// if response not 200 OK
+	if resp.StatusCode != http.StatusOK {
+		// mark URL as dead if HTTP 404 encountered
+		// crawler will never crawl a URL again which is marked as dead
+		// but will know that it have seen the URL before through the queue
+		if resp.StatusCode == http.StatusNotFound {
+			uModel, err := c.Models.URLs.GetByURL(ctx, urlpath)
 			if err != nil {
-				msg := fmt.Sprintf("%s: Could not read response body: %v", c.Name, err)
-				c.Log(msg)
-				continue
+				return &StorageError{URL: urlpath, Op: "get url", Err: err}
 			}
-
-			// if status OK fetch all hrefs embedded in the page
-			hrefs, err := c.fetchEmbeddedURLs(doc)
+			// dead monitored URLs are re-checked; report only URLs going dead
+			wasAlive := uModel.IsAlive
+			uModel.IsAlive = false
+			uModel.LastChecked = time.Now()
+			c.tagRun(uModel)
+			err = c.Models.URLs.Update(ctx, uModel)
 			if err != nil {
-				msg := fmt.Sprintf("%s: Failed to fetch embedded URLs for URL '%s' : %v",
-					c.Name,
-					urlpath,
-					err,
-				)
-				c.Log(msg)
-				continue
+				return &StorageError{URL: urlpath, Op: "update url", Err: err}
+			}
+			if wasAlive {
+				c.stats.deadURLs.Add(1)
+				c.publish(Event{
+					Type:       EventURLDead,
+					URL:        urlpath,
+					StatusCode: resp.StatusCode,
+					Monitored:  uModel.IsMonitored,
+				})
 			}
+		}
+
+		return &FetchError{URL: urlpath, StatusCode: resp.StatusCode}
+	}
+
+	// the body is kept for the link extractors of non-HTML pages
+	body, err := io.ReadAll(&countingReader{r: resp.Body, counter: fetchedBytesTotal})
+	if err != nil {
+		return &FetchError{
+			URL:        urlpath,
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("could not read response body: %v", err),
+		}
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return &FetchError{
+			URL:        urlpath,
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("could not parse response body: %v", err),
+		}
+	}
+
+	// content of the login page served to an expired session
+	if c.Auth != nil && c.Auth.Login != nil && c.Auth.Login.expiry != nil {
+		content, _ := doc.Html()
+		if err := c.checkSession(ctx, fetcher, urlpath, resp, content, fetchStart); err != nil {
+			return err
+		}
+	}
+
+	// robots directives of the page are recorded as the skip reason of its URL
+	directives := parseDirectives(resp.Header, doc, c.UserAgent)
+	skipReason := directives.reason()
+	if skipReason != "" {
+		c.Log(slog.LevelInfo, "page has robots directives", "url", urlpath, "directives", skipReason)
+	}
+
+	// if status OK fetch all links embedded in the page unless nofollow;
+	// relative links are resolved against the URL of the response
+	var links []Link
+	if !directives.nofollow || !c.Directives.skipFollow() {
+		links = c.fetchEmbeddedURLs(&Page{
+			URL:    resp.Request.URL,
+			Base:   resp.Request.URL,
+			Header: resp.Header,
+			Body:   body,
+			Doc:    doc,
+		})
+	}
 
-			// go through fetched urls, if url not in queue(map) save to db and queue
-			for _, href := range hrefs {
-				if c.isValidURL(href) {
-					if ok := c.Queue.Insert(href); ok {
-						msg := fmt.Sprintf("%s: Added url '%s' to queue", c.Name, href)
-						c.Log(msg)
-						// temp time var as time.Time value cannot be set to nil
-						// and we don't want to set URL.LastSaved and URL.LastChecked right now
-						var t time.Time
-						u := models.NewURL(href, t, t, c.isMarkedURL(href))
-						err = c.Models.URLs.Insert(u)
-						if err != nil {
-							msg = fmt.Sprintf("%s: FATAL : Failed to insert url '%s' to model: %v",
-								c.Name,
-								href,
-								err,
-							)
-							c.Log(msg)
-							runtime.Goexit()
-						}
-						// if url is marked set value to true to fetch its content
-						if u.IsMonitored {
-							c.Queue.SetMapValue(href, true)
-						}
-					}
-				} else {
-					msg := fmt.Sprintf("%s: Invalid url: %s", c.Name, href)
-					c.Log(msg)
-					c.KnownInvalidURLs.cache.Store(href, true)
-				}
+	// go through fetched urls, if url not in queue(map) save to db and queue
+	for _, link := range links {
+		href := link.URL
+		if err := c.validateURL(ctx, href); err != nil {
+			c.Log(slog.LevelDebug, "invalid url", "url", href, "err", err)
+			c.KnownInvalidURLs.cache.Store(href, true)
+			c.reportError(err)
+			continue
+		}
+
+		// other pages may link to href without nofollow, so it is neither
+		// queued nor known invalid; only its skip reason is recorded
+		if link.Nofollow && c.Directives.skipFollow() {
+			if err := c.recordNofollowLink(ctx, link, urlpath); err != nil {
+				return err
 			}
+			continue
+		}
 
-			// map value of current URL
-			saveURLContent, err := c.Queue.GetMapValue(urlpath)
-			if errors.Is(err, queue.ErrItemNotFound) {
-				msg := fmt.Sprintf(
-					"%s: FATAL : URL not found in queue map '%s'. Quitting.",
-					c.Name,
-					urlpath,
-				)
-				c.Log(msg)
-				runtime.Goexit()
+		// the URL is queued once stored, as crawlers look it up when fetched
+		if ok := c.Queue.Reserve(href); ok {
+			// temp time var as time.Time value cannot be set to nil
+			// and we don't want to set URL.LastSaved and URL.LastChecked right now
+			var t time.Time
+			u := models.NewURL(href, t, t, c.isMarkedURL(href))
+			u.LinkSource = link.Source
+			if link.Nofollow {
+				u.SkipReason = nofollowLinkReason
+			}
+			if err := c.insertURL(ctx, u); err != nil {
+				return err
+			}
+			c.stats.urlsDiscovered.Add(1)
+			c.publish(Event{Type: EventURLDiscovered, URL: href})
+			// if url is marked set value to true to fetch its content
+			if u.IsMonitored {
+				c.Queue.SetMapValue(href, true)
 			}
+			c.Queue.Push(href)
+			c.Log(slog.LevelInfo, "added url to queue", "url", href, "source", link.Source)
+		}
+	}
 
-			// if current url is to be monitored OR marked, save content to DB and update url
-			if c.isMarkedURL(urlpath) || saveURLContent {
-				err = c.savePageContent(urlpath, doc)
-				if err != nil {
-					msg := fmt.Sprintf("%s: FATAL. %s", c.Name, err)
-					c.Log(msg)
-					runtime.Goexit()
-				}
-				msg := fmt.Sprintf("%s: Saved content of url '%s'", c.Name, urlpath)
-				c.Log(msg)
-
-				// set key value to false as url is now processed
-				c.Queue.SetMapValue(urlpath, false)
-			} else {
-				// else update LastChecked field
-				err = c.updateURLLastCheckedDate(urlpath, time.Now())
-				if err != nil {
-					msg := fmt.Sprintf("%s: FATAL. %s", c.Name, err)
-					c.Log(msg)
-					runtime.Goexit()
-				}
+	// map value of current URL
+	saveURLContent, err := c.Queue.GetMapValue(urlpath)
+	if errors.Is(err, queue.ErrItemNotFound) {
+		return &StorageError{URL: urlpath, Op: "find url in queue map", Err: err}
+	}
+
+	// if current url is to be monitored OR marked, save content to DB and update url
+	saveContent := c.isMarkedURL(urlpath) || saveURLContent
+	if saveContent && directives.noindex && c.Directives.skipSave() {
+		// noindex page is not saved; only LastChecked is updated
+		c.Queue.SetMapValue(urlpath, false)
+		err = c.updateURLLastCheckedDate(ctx, urlpath, time.Now(), skipReason)
+		if err != nil {
+			return err
+		}
+		c.Log(slog.LevelInfo, "skipped saving noindex url", "url", urlpath)
+		c.reportError(&PolicySkipError{URL: urlpath, Reason: skipReason})
+	} else if saveContent {
+		c.setState(CrawlerSaving, urlpath)
+		page, err := c.savePageContent(ctx, urlpath, "", doc, skipReason)
+		if err != nil {
+			return err
+		}
+		c.Log(slog.LevelInfo, "saved content of url", "url", urlpath)
+		c.stats.pagesSaved.Add(1)
+		pagesSavedTotal.Inc()
+		c.publish(Event{Type: EventPageSaved, URL: urlpath, PageID: page.ID})
+
+		// set key value to false as url is now processed
+		c.Queue.SetMapValue(urlpath, false)
+
+		if err := c.saveVariants(ctx, urlpath, fetcher, skipReason); err != nil {
+			return err
+		}
+	} else {
+		// else update LastChecked field
+		err = c.updateURLLastCheckedDate(ctx, urlpath, time.Now(), skipReason)
+		if err != nil {
+			return err
+		}
+	}
+
+	// take rest for RequestDelay
+	c.setState(CrawlerWaiting, urlpath)
+	sleep(ctx, c.control.delay())
+	return nil
+}
+
+// saveVariants fetches urlpath with every Variant matching it and saves
+// the pages of the variants. Variants which could not be fetched or had
+// no content are reported and skipped; returns StorageError when a page
+// could not be saved.
+func (c *Crawler) saveVariants(
+	ctx context.Context,
+	urlpath string,
+	fetcher Fetcher,
+	skipReason string,
+) error {
+	for _, v := range c.Variants {
+		if !v.matches(urlpath) {
+			continue
+		}
+
+		doc, err := c.fetchVariant(ctx, urlpath, fetcher, v)
+		if errors.Is(err, ErrSessionExpired) {
+			// logged in again; fetched once more with the new session
+			doc, err = c.fetchVariant(ctx, urlpath, fetcher, v)
+		}
+		var page *models.Page
+		if err == nil {
+			c.setState(CrawlerSaving, urlpath)
+			page, err = c.savePageContent(ctx, urlpath, v.Name, doc, skipReason)
+		}
+		if err != nil {
+			var storageErr *StorageError
+			if errors.As(err, &storageErr) || ctx.Err() != nil {
+				return err
 			}
+			c.Log(slog.LevelWarn, "skipped variant", "url", urlpath, "variant", v.Name, "err", err)
+			c.reportError(err)
+			continue
+		}
 
-			// close response body
-			resp.Body.Close()
+		c.Log(slog.LevelInfo, "saved content of url variant", "url", urlpath, "variant", v.Name)
+		c.stats.pagesSaved.Add(1)
+		pagesSavedTotal.Inc()
+		c.publish(Event{Type: EventPageSaved, URL: urlpath, PageID: page.ID, Variant: v.Name})
+	}
+	return nil
+}
 
-			// take rest for RequestDelay
-			time.Sleep(c.RequestDelay)
+// fetchVariant fetches urlpath with the headers and cookies of v
+// within the politeness limits of its host
+func (c *Crawler) fetchVariant(
+	ctx context.Context,
+	urlpath string,
+	fetcher Fetcher,
+	v *Variant,
+) (*goquery.Document, error) {
+	parsedURL, err := url.Parse(urlpath)
+	if err != nil {
+		return nil, &FetchError{URL: urlpath, Err: err}
+	}
+
+	c.setState(CrawlerWaiting, urlpath)
+	hostDone, err := c.hosts.wait(ctx, parsedURL)
+	if err != nil {
+		return nil, &FetchError{URL: urlpath, Err: err}
+	}
+
+	c.setState(CrawlerFetching, urlpath)
+	fetchStart := time.Now()
+	requestsInFlight.Inc()
+	resp, err := c.getURL(ctx, urlpath, fetcher, v)
+	requestsInFlight.Dec()
+	hostDone(resp, time.Since(fetchStart))
+	if err != nil {
+		return nil, &FetchError{URL: urlpath, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if err := c.checkSession(ctx, fetcher, urlpath, resp, "", fetchStart); err != nil {
+		return nil, err
+	}
+
+	fetchDurationSeconds.Observe(time.Since(fetchStart).Seconds())
+	pagesFetchedTotal.Inc()
+	responsesTotal.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	c.stats.addStatusCode(resp.StatusCode)
+	c.publish(Event{
+		Type:       EventFetchCompleted,
+		URL:        urlpath,
+		StatusCode: resp.StatusCode,
+		Duration:   time.Since(fetchStart),
+		Variant:    v.Name,
+	})
+
+	c.Log(
+		slog.LevelDebug,
+		"fetched url variant",
+		"url", urlpath,
+		"variant", v.Name,
+		"status", resp.StatusCode,
+		"duration", time.Since(fetchStart),
+	)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &FetchError{URL: urlpath, StatusCode: resp.StatusCode}
+	}
 
-			// reset startTime
-			startTime = time.Now()
+	doc, err := goquery.NewDocumentFromReader(&countingReader{r: resp.Body, counter: fetchedBytesTotal})
+	if err != nil {
+		return nil, &FetchError{
+			URL:        urlpath,
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("could not read response body: %v", err),
 		}
 	}
+
+	// content of the login page served to an expired session
+	if c.Auth != nil && c.Auth.Login != nil && c.Auth.Login.expiry != nil {
+		content, _ := doc.Html()
+		if err := c.checkSession(ctx, fetcher, urlpath, resp, content, fetchStart); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
 }
 
-// savePageContent saves URL response body to models
-func (c *Crawler) savePageContent(urlpath string, doc *goquery.Document) error {
+// savePageContent saves URL response body, fetched with variant, to models
+// and returns the saved page. Publishes EventPageChanged when the content
+// differs from the previous page of the variant.
+func (c *Crawler) savePageContent(
+	ctx context.Context,
+	urlpath string,
+	variant string,
+	doc *goquery.Document,
+	skipReason string,
+) (*models.Page, error) {
 	// GetByURL should not fail because whenever a new URL is encountered
 	// it is saved to queue AND db
-	uModel, err := c.Models.URLs.GetByURL(urlpath)
+	uModel, err := c.Models.URLs.GetByURL(ctx, urlpath)
 	if err != nil {
-		return fmt.Errorf("could not get URL from model: %v", err)
+		return nil, &StorageError{URL: urlpath, Op: "get url", Err: err}
 	}
 	contentStr, err := doc.Html()
 	if err != nil {
-		return fmt.Errorf("could not read page content: %v", err)
+		return nil, &FetchError{
+			URL:        urlpath,
+			StatusCode: http.StatusOK,
+			Err:        fmt.Errorf("could not read page content: %v", err),
+		}
 	}
 	if len(contentStr) < 100 {
-		return fmt.Errorf("empty/no content. url: '%s'; len: %d", urlpath, len(contentStr))
+		return nil, &PolicySkipError{
+			URL:    urlpath,
+			Reason: fmt.Sprintf("empty/no content; len: %d", len(contentStr)),
+		}
+	}
+	prevPage, err := c.Models.Pages.GetLatestByURL(ctx, uModel.ID, variant)
+	if err != nil && !errors.Is(err, models.ErrRecordNotFound) {
+		return nil, &StorageError{URL: urlpath, Op: "get latest page", Err: err}
 	}
 	newPage := models.NewPage(uModel.ID, contentStr)
-	if err = c.Models.Pages.Insert(newPage); err != nil {
-		return fmt.Errorf("could not insert page into model: %v", err)
+	newPage.RunID = c.RunID
+	newPage.Variant = variant
+	if err = c.Models.Pages.Insert(ctx, newPage); err != nil {
+		return nil, &StorageError{URL: urlpath, Op: "insert page", Err: err}
 	}
 	uModel.LastChecked = time.Now()
 	uModel.LastSaved = time.Now()
-	if err = c.Models.URLs.Update(uModel); err != nil {
-		return fmt.Errorf("could not update URL model: %v", err)
+	uModel.SkipReason = skipReason
+	c.tagRun(uModel)
+	revived := markAlive(uModel)
+	if err = c.Models.URLs.Update(ctx, uModel); err != nil {
+		return nil, &StorageError{URL: urlpath, Op: "update url", Err: err}
 	}
-	return nil
+	if revived {
+		c.publishAlive(uModel)
+	}
+	if prevPage != nil && prevPage.Content != contentStr {
+		c.Log(slog.LevelInfo, "content of url changed", "url", urlpath, "variant", variant)
+		c.publish(Event{
+			Type:           EventPageChanged,
+			URL:            urlpath,
+			Monitored:      uModel.IsMonitored,
+			PageID:         newPage.ID,
+			PreviousPageID: prevPage.ID,
+			Variant:        variant,
+		})
+	}
+	return newPage, nil
 }
 
-// updateURLLastCheckedDate updates the LastChecked field of URL
-func (c *Crawler) updateURLLastCheckedDate(urlpath string, datetime time.Time) error {
+// updateURLLastCheckedDate updates the LastChecked and SkipReason fields of URL
+func (c *Crawler) updateURLLastCheckedDate(
+	ctx context.Context,
+	urlpath string,
+	datetime time.Time,
+	skipReason string,
+) error {
 	// GetByURL should not fail because whenever a new URL is encountered
 	// it is saved to queue AND db
-	uModel, err := c.Models.URLs.GetByURL(urlpath)
+	uModel, err := c.Models.URLs.GetByURL(ctx, urlpath)
 	if err != nil {
-		return fmt.Errorf("could not get URL '%s' from model: %v", urlpath, err)
+		return &StorageError{URL: urlpath, Op: "get url", Err: err}
 	}
 	uModel.LastChecked = datetime
-	if err = c.Models.URLs.Update(uModel); err != nil {
-		return fmt.Errorf("could not update URL model: %v", err)
+	uModel.SkipReason = skipReason
+	c.tagRun(uModel)
+	revived := markAlive(uModel)
+	if err = c.Models.URLs.Update(ctx, uModel); err != nil {
+		return &StorageError{URL: urlpath, Op: "update url", Err: err}
+	}
+	if revived {
+		c.publishAlive(uModel)
 	}
 	return nil
 }
 
-// fetchEmbeddedURLs will fetch all values in href attribute of <a> tag from doc
-func (c *Crawler) fetchEmbeddedURLs(doc *goquery.Document) ([]string, error) {
-	hrefs := []string{}
+// insertURL saves discovered URL u to model. A URL stored as a skipped
+// nofollow link is updated with the skip reason of u instead.
+func (c *Crawler) insertURL(ctx context.Context, u *models.URL) error {
+	err := c.Models.URLs.Insert(ctx, u)
+	if err == nil {
+		return nil
+	}
+	stored, getErr := c.Models.URLs.GetByURL(ctx, u.URL)
+	if getErr != nil {
+		return &StorageError{URL: u.URL, Op: "insert url", Err: err}
+	}
+	stored.SkipReason = u.SkipReason
+	stored.IsMonitored = stored.IsMonitored || u.IsMonitored
+	if err := c.Models.URLs.Update(ctx, stored); err != nil {
+		return &StorageError{URL: u.URL, Op: "update url", Err: err}
+	}
+	*u = *stored
+	return nil
+}
 
-	doc.Find("a").Each(func(i int, s *goquery.Selection) {
-		if href, found := s.Attr("href"); found {
-			href = strings.TrimSuffix(strings.TrimSpace(href), "/")
-			if href == "" {
-				return
-			}
-			// if href is not absolute add BaseURL to href
-			if !internal.IsAbsoluteURL(href) && !internal.BeginsWith(href, invalidHrefPrefixs) {
-				if !strings.HasPrefix(href, "/") {
-					href = "/" + href
-				}
-				href = c.BaseURL.String() + href
+// recordNofollowLink saves the URL of the rel="nofollow" link of the page
+// urlpath, which is not followed, with its skip reason. URLs already known
+// to the queue were followed through other links and are left as is.
+func (c *Crawler) recordNofollowLink(ctx context.Context, link Link, urlpath string) error {
+	if _, err := c.Queue.GetMapValue(link.URL); err == nil {
+		return nil
+	}
+	c.Log(slog.LevelDebug, "skipped nofollow link", "url", link.URL, "page", urlpath)
+	c.reportError(&PolicySkipError{URL: link.URL, Reason: nofollowLinkReason})
+
+	_, err := c.Models.URLs.GetByURL(ctx, link.URL)
+	switch {
+	case err == nil:
+		return nil
+	case !errors.Is(err, models.ErrRecordNotFound):
+		return &StorageError{URL: link.URL, Op: "get url", Err: err}
+	}
+
+	var t time.Time
+	u := models.NewURL(link.URL, t, t, false)
+	u.LinkSource = link.Source
+	u.SkipReason = nofollowLinkReason
+	if err := c.Models.URLs.Insert(ctx, u); err != nil {
+		// recorded by another crawler meanwhile
+		if _, getErr := c.Models.URLs.GetByURL(ctx, link.URL); getErr == nil {
+			return nil
+		}
+		return &StorageError{URL: link.URL, Op: "insert url", Err: err}
+	}
+	return nil
+}
+
+// markAlive sets u alive and tells if u was marked as dead
+func markAlive(u *models.URL) bool {
+	if u.IsAlive {
+		return false
+	}
+	u.IsAlive = true
+	return true
+}
+
+// publishAlive logs and publishes that u is back online
+func (c *Crawler) publishAlive(u *models.URL) {
+	c.Log(slog.LevelInfo, "dead url is back alive", "url", u.URL)
+	c.publish(Event{Type: EventURLAlive, URL: u.URL, StatusCode: http.StatusOK, Monitored: u.IsMonitored})
+}
+
+// tagRun sets the last run of u to RunID when the run is recorded
+func (c *Crawler) tagRun(u *models.URL) {
+	if c.RunID != 0 {
+		u.LastRunID = c.RunID
+	}
+}
+
+// fetchEmbeddedURLs returns the links of page found by the Extractors of its
+// content type. Relative links are resolved against the URL of page, or the
+// <base> href of HTML pages when present. URLs in scope are rewritten to the
+// scheme of the scope. rel="nofollow" links are reported and skipped as per
+// Directives.
+func (c *Crawler) fetchEmbeddedURLs(page *Page) []Link {
+	if base, found := page.Doc.Find("base[href]").First().Attr("href"); found {
+		if baseURL, err := page.URL.Parse(strings.TrimSpace(base)); err == nil {
+			page.Base = baseURL
+		}
+	}
+
+	contentType := page.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(page.Body)
+	}
+
+	links := []Link{}
+	for _, extractor := range c.Extractors.For(contentType) {
+		found, err := extractor.Extract(page)
+		if err != nil {
+			c.Log(slog.LevelDebug, "could not extract links", "url", page.URL, "extractor", extractor.Name(), "err", err)
+		}
+		for _, link := range found {
+			if parsed, err := url.Parse(link.URL); err == nil && parsed.IsAbs() {
+				link.URL = c.Scope.Canonical(parsed).String()
 			}
+			link.URL = strings.TrimSuffix(link.URL, "/")
 
 			// if href is known to be invalid, ignore
-			if _, knownInvalid := c.KnownInvalidURLs.cache.Load(href); !knownInvalid {
-				hrefs = append(hrefs, href)
+			if _, knownInvalid := c.KnownInvalidURLs.cache.Load(link.URL); knownInvalid {
+				continue
 			}
+			links = append(links, link)
 		}
-	})
-	return hrefs, nil
+	}
+	return links
 }
 
 /*
-	validateURL checks if the URL is valid
+	validateURL checks if the URL is valid.
+	Returns *PolicySkipError with the reason when invalid.
 
 Rules:
-  - Have base URL if absolute URL
+  - Is in crawl scope if absolute URL
   - Is not empty
   - Scheme is either HTTP/HTTPS
   - Not in ignore paths list
   - Not Disallowed by robots.txt
 */
-func (c *Crawler) isValidURL(href string) bool {
+func (c *Crawler) validateURL(ctx context.Context, href string) error {
 	// URL is not empty
 	if href == "" {
-		return false
+		return &PolicySkipError{URL: href, Reason: "empty url"}
 	}
 
 	parsedURL, err := url.Parse(href)
 	if err != nil {
-		return false
+		return &PolicySkipError{URL: href, Reason: "could not parse url"}
 	}
 
-	// check if URL is absolute and have same hostname as crawler BaseURL
+	// check if URL is absolute and on a host in crawl scope
 	if parsedURL.Scheme != "" && parsedURL.Host != "" {
-		if parsedURL.Hostname() != c.BaseURL.Hostname() {
-			return false
+		if !c.Scope.Contains(parsedURL) {
+			return &PolicySkipError{URL: href, Reason: "outside crawl scope"}
 		}
 	}
 
 	// valid scheme: HTTP/HTTPS
 	if !internal.IsValidScheme(parsedURL.Scheme) {
-		return false
+		return &PolicySkipError{URL: href, Reason: "invalid scheme"}
 	}
 
 	// check if path in ignore paths list
 	if internal.ContainsAny(parsedURL.Path, c.IgnorePatterns) {
-		return false
+		return &PolicySkipError{URL: href, Reason: "matches ignore pattern"}
 	}
 
-	// check if path is allowed by robots.txt
-	if !grobotstxt.AgentAllowed(*c.robotsTxt, c.UserAgent, href) {
-		msg := fmt.Sprintf("%s: Not allowed by robots.txt: %s", c.Name, href)
-		c.Log(msg)
-		return false
+	// check if path is allowed by robots.txt of its host; URLs of hosts with
+	// unreachable robots.txt are queued and checked again before crawling
+	if err := c.Robots.Allowed(ctx, parsedURL, c.UserAgent); errors.Is(err, ErrRobotsDisallowed) {
+		return &PolicySkipError{URL: href, Reason: err.Error()}
 	}
 
-	return true
+	return nil
 }
 
 // isMarkedURL checks whether the href should be processed
@@ -453,62 +1119,101 @@ func (c *Crawler) isMarkedURL(href string) bool {
 	return internal.ContainsAny(href, c.MarkedURLs)
 }
 
-// getURL fetchs the URL with c.UserAgent
-func (c *Crawler) getURL(url string, client *http.Client) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// getURL fetchs the URL with fetcher, c.UserAgent and
+// the headers and cookies of variant, when not nil
+func (c *Crawler) getURL(
+	ctx context.Context,
+	url string,
+	fetcher Fetcher,
+	variant *Variant,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("User-Agent", c.UserAgent)
+	if c.Auth != nil {
+		c.Auth.authorize(req)
+	}
+	if variant != nil {
+		variant.apply(req)
+	}
 
-	return client.Do(req)
+	return fetcher.Fetch(req)
 }
 
-// Log writes the msg to [Crawler.Logger] and [Crawler.PrettyLogger] when present
-func (c *Crawler) Log(msg string) {
-	c.Logger.Println(msg)
-	if c.PrettyLogger != nil {
-		c.PrettyLogger.Log(msg)
+// fetcher returns Fetcher, or the HTTPFetcher of client when nil
+func (c *Crawler) fetcher(client *http.Client) Fetcher {
+	if c.Fetcher != nil {
+		return c.Fetcher
 	}
+	return &HTTPFetcher{Client: client}
 }
 
-// getRobotsTxt will return the <baseURL>/robots.txt file if found as string
-func getRobotsTxt(baseUrl *url.URL, userAgent string) (*string, error) {
-
-	urlString := fmt.Sprintf("%s://%s/robots.txt", baseUrl.Scheme, baseUrl.Host)
-	req, err := http.NewRequest(http.MethodGet, urlString, nil)
-	if err != nil {
-		return nil, err
+// checkSession logs in again when resp of urlpath requested at since,
+// with its content when already read, shows that the session of the form
+// login expired. Returns FetchError wrapping ErrSessionExpired, so that the
+// URL is requeued, or the login error.
+func (c *Crawler) checkSession(
+	ctx context.Context,
+	fetcher Fetcher,
+	urlpath string,
+	resp *http.Response,
+	content string,
+	since time.Time,
+) error {
+	if c.Auth == nil || !c.Auth.expired(resp, content) {
+		return nil
 	}
-
-	req.Header.Set("User-Agent", userAgent)
-	httpClient := &http.Client{
-		Timeout: 5 * time.Second,
+	c.Log(slog.LevelWarn, "session expired, logging in again", "url", urlpath)
+	if err := c.Auth.relogin(ctx, fetcher, c.UserAgent, since); err != nil {
+		return &FetchError{URL: urlpath, Err: err}
 	}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("could not get robots.txt, error: %v", err)
+	return &FetchError{URL: urlpath, Err: ErrSessionExpired}
+}
+
+// Log writes a record with level, msg and args as attributes to [Crawler.Logger]
+// and [Crawler.PrettyLogger] when present. Every record has the "crawler" attribute
+// set to crawler name. Records below the level of [Crawler.Logger] are dropped.
+func (c *Crawler) Log(level slog.Level, msg string, args ...any) {
+	ctx := context.Background()
+	if !c.Logger.Enabled(ctx, level) {
+		return
 	}
 
-	// following google's policy, failure when HTTP Status 429 or >= 500
-	// but following 10 redirections
-	// see: https://developers.google.com/search/docs/crawling-indexing/robots/robots_txt#http-status-codes
-	if resp.StatusCode == http.StatusTooManyRequests ||
-		resp.StatusCode >= http.StatusInternalServerError {
-		return nil, fmt.Errorf(
-			"could not get robots.txt, received HTTP status %d: %s",
-			resp.StatusCode,
-			http.StatusText(resp.StatusCode),
-		)
+	// skip [runtime.Callers] and this function
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add("crawler", c.Name)
+	r.Add(args...)
+
+	_ = c.Logger.Handler().Handle(ctx, r)
+	if c.PrettyLogger != nil {
+		c.PrettyLogger.Log(r)
 	}
+}
 
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error while reading robots.txt: %v", err)
+// publish sends e to [Crawler.Events] when present. Time, crawler name
+// and run id of e are set by publish.
+func (c *Crawler) publish(e Event) {
+	if c.Events == nil {
+		return
 	}
-	defer resp.Body.Close()
-	robotsTxt := string(respBytes)
+	e.Time = time.Now()
+	e.Crawler = c.Name
+	e.RunID = c.RunID
+	c.Events.Publish(e)
+}
 
-	return &robotsTxt, nil
+// sleep pauses the current goroutine for duration d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
 }
@@ -0,0 +1,137 @@
+package webcrawler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// nofollowLinkReason is the skip reason of URLs of rel="nofollow" links
+const nofollowLinkReason = "rel=nofollow link"
+
+// DirectivePolicy decides what a crawler does with the robots directives
+// of a page: noindex and nofollow of <meta name="robots"> and X-Robots-Tag,
+// and rel="nofollow" of links.
+//
+// The directives a page is skipped for, or would be skipped for with
+// DirectivePolicyRecord, are recorded as the skip reason of its URL.
+type DirectivePolicy int
+
+const (
+	DirectivePolicyRecord     DirectivePolicy = iota // follow and save as usual; only record the skip reason
+	DirectivePolicyObey                              // skip following nofollow pages and links and saving noindex pages
+	DirectivePolicySkipFollow                        // skip following nofollow pages and links; noindex pages are saved
+	DirectivePolicySkipSave                          // skip saving noindex pages; all links are followed
+)
+
+var directivePolicyNames = []string{"record", "obey", "skip-follow", "skip-save"}
+
+func (p DirectivePolicy) String() string {
+	if int(p) < len(directivePolicyNames) && p >= 0 {
+		return directivePolicyNames[p]
+	}
+	return fmt.Sprintf("DirectivePolicy(%d)", p)
+}
+
+// ParseDirectivePolicy returns the DirectivePolicy named s
+// i.e. one of "record", "obey", "skip-follow" or "skip-save"
+func ParseDirectivePolicy(s string) (DirectivePolicy, error) {
+	for i, name := range directivePolicyNames {
+		if strings.EqualFold(s, name) {
+			return DirectivePolicy(i), nil
+		}
+	}
+	return DirectivePolicyRecord, fmt.Errorf(
+		"crawler: invalid directive policy '%s'. Supported: %s",
+		s,
+		strings.Join(directivePolicyNames, ", "),
+	)
+}
+
+// skipFollow tells if links of nofollow pages and rel="nofollow" links are not followed
+func (p DirectivePolicy) skipFollow() bool {
+	return p == DirectivePolicyObey || p == DirectivePolicySkipFollow
+}
+
+// skipSave tells if noindex pages are not saved
+func (p DirectivePolicy) skipSave() bool {
+	return p == DirectivePolicyObey || p == DirectivePolicySkipSave
+}
+
+// pageDirectives holds the robots directives of a page
+type pageDirectives struct {
+	noindex  bool
+	nofollow bool
+	sources  []string // directives by source e.g. "meta robots: noindex"
+}
+
+// reason returns the directives of pd by source; empty when pd has none
+func (pd *pageDirectives) reason() string {
+	return strings.Join(pd.sources, "; ")
+}
+
+// add applies the comma separated directives of source to pd
+func (pd *pageDirectives) add(source, directives string) {
+	var found []string
+	for _, d := range strings.Split(directives, ",") {
+		switch d = strings.ToLower(strings.TrimSpace(d)); d {
+		case "noindex":
+			pd.noindex = true
+		case "nofollow":
+			pd.nofollow = true
+		case "none":
+			pd.noindex, pd.nofollow = true, true
+		default:
+			continue
+		}
+		found = append(found, d)
+	}
+	if len(found) > 0 {
+		pd.sources = append(pd.sources, source+": "+strings.Join(found, ", "))
+	}
+}
+
+// parseDirectives returns the robots directives of a page for userAgent from
+// the X-Robots-Tag headers of its response and its <meta> robots tags. Tags
+// and headers of other user agents are ignored.
+func parseDirectives(header http.Header, doc *goquery.Document, userAgent string) *pageDirectives {
+	pd := &pageDirectives{}
+	agent := strings.ToLower(productToken(userAgent))
+
+	for _, value := range header.Values("X-Robots-Tag") {
+		// values may be prefixed by the user agent they apply to e.g.
+		// 'googlebot: noindex'; 'unavailable_after: <date>' is not an agent
+		if name, directives, found := strings.Cut(value, ":"); found {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if strings.ContainsAny(name, ", ") || name == "unavailable_after" {
+				pd.add("X-Robots-Tag", value)
+				continue
+			}
+			if name != agent {
+				continue
+			}
+			value = directives
+		}
+		pd.add("X-Robots-Tag", value)
+	}
+
+	doc.Find("meta[name][content]").Each(func(i int, s *goquery.Selection) {
+		name := strings.ToLower(strings.TrimSpace(s.AttrOr("name", "")))
+		if name == "robots" || (agent != "" && name == agent) {
+			pd.add("meta "+name, s.AttrOr("content", ""))
+		}
+	})
+	return pd
+}
+
+// isNofollowLink tells if the rel attribute of link s has nofollow
+func isNofollowLink(s *goquery.Selection) bool {
+	for _, rel := range strings.Fields(s.AttrOr("rel", "")) {
+		if strings.EqualFold(rel, "nofollow") {
+			return true
+		}
+	}
+	return false
+}
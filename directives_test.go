@@ -0,0 +1,143 @@
+package webcrawler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestDirectives(t *testing.T) {
+	t.Run("ParseDirectivePolicy", func(t *testing.T) {
+		tests := []struct {
+			input   string
+			want    DirectivePolicy
+			wantErr bool
+		}{
+			{input: "obey", want: DirectivePolicyObey},
+			{input: "Skip-Follow", want: DirectivePolicySkipFollow},
+			{input: "skip-save", want: DirectivePolicySkipSave},
+			{input: "record", want: DirectivePolicyRecord},
+			{input: "ignore", want: DirectivePolicyRecord, wantErr: true},
+		}
+
+		for _, test := range tests {
+			got, err := ParseDirectivePolicy(test.input)
+			if got != test.want || (err != nil) != test.wantErr {
+				t.Errorf(
+					"input: %s, got %s, %v, want %s, error %t",
+					test.input, got, err, test.want, test.wantErr,
+				)
+			}
+		}
+	})
+
+	t.Run("Policies", func(t *testing.T) {
+		tests := []struct {
+			policy     DirectivePolicy
+			skipFollow bool
+			skipSave   bool
+		}{
+			{policy: DirectivePolicyObey, skipFollow: true, skipSave: true},
+			{policy: DirectivePolicySkipFollow, skipFollow: true, skipSave: false},
+			{policy: DirectivePolicySkipSave, skipFollow: false, skipSave: true},
+			{policy: DirectivePolicyRecord, skipFollow: false, skipSave: false},
+		}
+
+		for _, test := range tests {
+			if got := test.policy.skipFollow(); got != test.skipFollow {
+				t.Errorf("policy: %s, skipFollow got %t, want %t", test.policy, got, test.skipFollow)
+			}
+			if got := test.policy.skipSave(); got != test.skipSave {
+				t.Errorf("policy: %s, skipSave got %t, want %t", test.policy, got, test.skipSave)
+			}
+		}
+	})
+
+	t.Run("parseDirectives", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			headers  []string
+			html     string
+			noindex  bool
+			nofollow bool
+			reason   string
+		}{
+			{
+				name: "none",
+				html: `<meta name="description" content="noindex">`,
+			},
+			{
+				name:    "meta robots",
+				html:    `<meta name="robots" content="NoIndex, follow">`,
+				noindex: true,
+				reason:  "meta robots: noindex",
+			},
+			{
+				name:     "meta of user agent",
+				html:     `<meta name="webcrawlerGo" content="nofollow"><meta name="googlebot" content="noindex">`,
+				nofollow: true,
+				reason:   "meta webcrawlergo: nofollow",
+			},
+			{
+				name:     "header none",
+				headers:  []string{"none"},
+				noindex:  true,
+				nofollow: true,
+				reason:   "X-Robots-Tag: none",
+			},
+			{
+				name:    "header of user agents",
+				headers: []string{"googlebot: nofollow", "webcrawlerGo: noindex"},
+				noindex: true,
+				reason:  "X-Robots-Tag: noindex",
+			},
+			{
+				name:     "header and meta",
+				headers:  []string{"noindex, unavailable_after: 25 Jun 2010 15:00:00 PST"},
+				html:     `<meta name="robots" content="nofollow">`,
+				noindex:  true,
+				nofollow: true,
+				reason:   "X-Robots-Tag: noindex; meta robots: nofollow",
+			},
+		}
+
+		for _, test := range tests {
+			header := http.Header{}
+			for _, value := range test.headers {
+				header.Add("X-Robots-Tag", value)
+			}
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(test.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pd := parseDirectives(header, doc, "webcrawlerGo/v1.0 - Web crawler")
+			if pd.noindex != test.noindex || pd.nofollow != test.nofollow {
+				t.Errorf(
+					"%s: got noindex %t, nofollow %t, want %t, %t",
+					test.name, pd.noindex, pd.nofollow, test.noindex, test.nofollow,
+				)
+			}
+			if got := pd.reason(); got != test.reason {
+				t.Errorf("%s: got reason %q, want %q", test.name, got, test.reason)
+			}
+		}
+	})
+
+	t.Run("isNofollowLink", func(t *testing.T) {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(
+			`<a id="a" rel="noopener NoFollow">a</a><a id="b" rel="nofollower">b</a><a id="c">c</a>`,
+		))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for id, want := range map[string]bool{"a": true, "b": false, "c": false} {
+			if got := isNofollowLink(doc.Find("#" + id)); got != want {
+				t.Errorf("link %s: got %t, want %t", id, got, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,313 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxSummaryErrors is the maximum number of errors kept in Summary.Errors
+const maxSummaryErrors = 100
+
+// DefaultRequestTimeout is the timeout of the http.Client
+// created by NewEngine and TransportConfig without Timeout
+var DefaultRequestTimeout = 5 * time.Second
+
+// Engine runs a pool of crawlers sharing the same CrawlerConfig
+// and aggregates their stats.
+//
+// A running Engine can be controlled with Command sent through
+// Control or its helpers e.g. Pause, Resume and Drain.
+type Engine struct {
+	Client     *http.Client // client used by all crawlers; replace before calling Run to customise
+	cfg        *CrawlerConfig
+	namePrefix string
+	control    chan Command
+
+	mu          sync.Mutex
+	crawlers    []*Crawler
+	startedAt   time.Time
+	done        chan struct{} // closed when all crawlers of a run have exited; nil before Run
+	running     int           // crawlers yet to exit
+	runCtx      context.Context
+	cancel      context.CancelCauseFunc
+	interrupted atomic.Bool // set when a crawler stopped before the queue drained
+}
+
+// Summary holds the aggregated stats of a crawl
+type Summary struct {
+	StartedAt      time.Time // time when Run was called
+	FinishedAt     time.Time // time when last crawler exited
+	Crawlers       int       // number of crawlers used
+	URLsCrawled    int       // URLs fetched from the queue
+	URLsDiscovered int       // new URLs pushed to the queue and model
+	PagesSaved     int       // pages saved to model
+	FailedRequests int       // GET requests which failed
+	DeadURLs       int       // URLs marked as dead
+	FetchErrors    int       // count of FetchError
+	StorageErrors  int       // count of StorageError
+	SkippedURLs    int       // count of PolicySkipError
+	Errors         []error   // first maxSummaryErrors fetch and storage errors
+	Interrupted    bool      // true when ctx was done before the queue drained
+	Drained        bool      // true when the run was ended with Drain; queue may have URLs left
+}
+
+// Duration returns the time taken by the crawl
+func (s Summary) Duration() time.Duration {
+	return s.FinishedAt.Sub(s.StartedAt)
+}
+
+// crawlStats is shared by crawlers to count crawl events
+type crawlStats struct {
+	urlsCrawled    atomic.Int64
+	urlsDiscovered atomic.Int64
+	pagesSaved     atomic.Int64
+	failedRequests atomic.Int64
+	deadURLs       atomic.Int64
+	fetchErrors    atomic.Int64
+	storageErrors  atomic.Int64
+	skippedURLs    atomic.Int64
+
+	mu          sync.Mutex
+	errors      []error
+	statusCodes map[int]int
+}
+
+// newCrawlStats returns pointer to new crawlStats
+func newCrawlStats() *crawlStats {
+	return &crawlStats{
+		statusCodes: map[int]int{},
+	}
+}
+
+// addStatusCode counts a response with HTTP status code
+func (s *crawlStats) addStatusCode(code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCodes[code]++
+}
+
+// addError counts err as per its type and keeps
+// fetch and storage errors for the summary
+func (s *crawlStats) addError(err error) {
+	var fetchErr *FetchError
+
+	switch {
+	case errors.As(err, &fetchErr):
+		s.fetchErrors.Add(1)
+		// URLs left unchecked as robots.txt is unreachable were not requested
+		if fetchErr.StatusCode == 0 && !errors.Is(err, ErrRobotsUnreachable) {
+			s.failedRequests.Add(1)
+		}
+	default:
+		s.storageErrors.Add(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.errors) < maxSummaryErrors {
+		s.errors = append(s.errors, err)
+	}
+}
+
+// NewEngine returns pointer to a new Engine running n crawlers
+// configured with cfg. Crawlers will be named with namePrefix.
+//
+// Engine uses a new [http.Client] configured with cfg.Transport, unless
+// cfg.Fetcher is set. Fetchers sending live requests should use Client,
+// which has the cookie jar of cfg.Auth once Run is called.
+func NewEngine(n int, namePrefix string, cfg *CrawlerConfig) (*Engine, error) {
+	crawlers, err := NNewCrawlers(n, namePrefix, cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := cfg.Transport.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("crawler: %w", err)
+	}
+	// stats are counted per engine
+	cfg.stats = newCrawlStats()
+	for _, crawler := range crawlers {
+		crawler.managed = true
+	}
+
+	return &Engine{
+		Client:     client,
+		crawlers:   crawlers,
+		cfg:        cfg,
+		namePrefix: namePrefix,
+		control:    make(chan Command),
+	}, nil
+}
+
+// Run starts all the crawlers and waits for them to exit.
+//
+// Crawlers exit when the queue is drained or when ctx is done.
+// ctx is passed on to every HTTP request and model call.
+// When a crawler aborts the crawl as per ErrorPolicyAbort, all crawlers
+// are stopped and the error wrapping ErrCrawlAborted is returned.
+// Returns ctx.Err() along with the summary when ctx was done
+// before the queue drained.
+//
+// Commands sent with Control are applied while Run is in progress.
+// PrettyLogger.Quit is called once all the crawlers have exited.
+func (e *Engine) Run(ctx context.Context) (Summary, error) {
+	summary := Summary{
+		StartedAt: time.Now(),
+	}
+
+	e.mu.Lock()
+	if e.done != nil {
+		e.mu.Unlock()
+		return summary, errors.New("engine: Run called more than once")
+	}
+	done := make(chan struct{})
+	e.done = done
+	e.mu.Unlock()
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// open the session of crawlers; cookies are saved once they exit
+	if e.cfg.Auth != nil {
+		fetcher := e.cfg.Fetcher
+		if fetcher == nil {
+			fetcher = &HTTPFetcher{Client: e.Client}
+		}
+		if err := e.cfg.Auth.start(runCtx, e.Client, fetcher, e.cfg.UserAgent); err != nil {
+			if e.cfg.PrettyLogger != nil {
+				e.cfg.PrettyLogger.Quit()
+			}
+			summary.FinishedAt = time.Now()
+			return summary, err
+		}
+		defer func() {
+			if err := e.cfg.Auth.SaveCookies(); err != nil {
+				e.cfg.Logger.Error("could not save cookies", "err", err)
+			}
+		}()
+	}
+
+	e.mu.Lock()
+	e.startedAt = summary.StartedAt
+	e.runCtx = runCtx
+	e.cancel = cancel
+	for _, crawler := range e.crawlers {
+		e.startCrawler(crawler)
+	}
+	e.mu.Unlock()
+
+	// apply commands until all crawlers have exited
+	for running := true; running; {
+		select {
+		case cmd := <-e.control:
+			cmd.reply <- e.handleCommand(cmd)
+		case <-done:
+			running = false
+		}
+	}
+
+	if e.cfg.PrettyLogger != nil {
+		e.cfg.PrettyLogger.Quit()
+	}
+
+	e.mu.Lock()
+	summary.Crawlers = len(e.crawlers)
+	e.mu.Unlock()
+
+	summary.FinishedAt = time.Now()
+	summary.URLsCrawled = int(e.cfg.stats.urlsCrawled.Load())
+	summary.URLsDiscovered = int(e.cfg.stats.urlsDiscovered.Load())
+	summary.PagesSaved = int(e.cfg.stats.pagesSaved.Load())
+	summary.FailedRequests = int(e.cfg.stats.failedRequests.Load())
+	summary.DeadURLs = int(e.cfg.stats.deadURLs.Load())
+	summary.FetchErrors = int(e.cfg.stats.fetchErrors.Load())
+	summary.StorageErrors = int(e.cfg.stats.storageErrors.Load())
+	summary.SkippedURLs = int(e.cfg.stats.skippedURLs.Load())
+	e.cfg.stats.mu.Lock()
+	summary.Errors = append(summary.Errors, e.cfg.stats.errors...)
+	e.cfg.stats.mu.Unlock()
+
+	if err := context.Cause(runCtx); errors.Is(err, ErrCrawlAborted) {
+		return summary, err
+	}
+
+	if e.interrupted.Load() {
+		summary.Interrupted = true
+		return summary, ctx.Err()
+	}
+
+	summary.Drained = e.cfg.control.draining.Load()
+	return summary, nil
+}
+
+// startCrawler runs crawler in a new goroutine. e.mu must be held.
+func (e *Engine) startCrawler(crawler *Crawler) {
+	e.running++
+
+	go func() {
+		err := crawler.Crawl(e.runCtx, e.Client)
+		switch {
+		case errors.Is(err, ErrCrawlAborted):
+			// stop other crawlers; first cause is kept
+			e.cancel(err)
+		case err != nil:
+			e.interrupted.Store(true)
+		}
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.running--
+		if e.running == 0 {
+			close(e.done)
+		}
+	}()
+}
+
+// Snapshot returns the live stats of the engine. It is safe to
+// call from other goroutines while Run is in progress.
+func (e *Engine) Snapshot() Snapshot {
+	e.mu.Lock()
+	startedAt := e.startedAt
+	crawlers := make([]*Crawler, len(e.crawlers))
+	copy(crawlers, e.crawlers)
+	e.mu.Unlock()
+
+	snap := Snapshot{
+		StartedAt:      startedAt,
+		QueueSize:      e.cfg.Queue.Size(),
+		InFlight:       e.cfg.Queue.InFlight(),
+		URLsCrawled:    int(e.cfg.stats.urlsCrawled.Load()),
+		URLsDiscovered: int(e.cfg.stats.urlsDiscovered.Load()),
+		PagesSaved:     int(e.cfg.stats.pagesSaved.Load()),
+		FailedRequests: int(e.cfg.stats.failedRequests.Load()),
+		DeadURLs:       int(e.cfg.stats.deadURLs.Load()),
+		FetchErrors:    int(e.cfg.stats.fetchErrors.Load()),
+		StorageErrors:  int(e.cfg.stats.storageErrors.Load()),
+		SkippedURLs:    int(e.cfg.stats.skippedURLs.Load()),
+		StatusCodes:    map[int]int{},
+		Crawlers:       make([]CrawlerStatus, len(crawlers)),
+		Paused:         e.cfg.control.isPaused(),
+		Draining:       e.cfg.control.draining.Load(),
+		RequestDelay:   e.cfg.control.delay(),
+	}
+
+	e.cfg.stats.mu.Lock()
+	for code, count := range e.cfg.stats.statusCodes {
+		snap.StatusCodes[code] = count
+	}
+	e.cfg.stats.mu.Unlock()
+
+	for i, crawler := range crawlers {
+		snap.Crawlers[i] = crawler.Status()
+		if snap.Crawlers[i].State != CrawlerStopped && !crawler.stopRequested() {
+			snap.ActiveCrawlers++
+		}
+	}
+
+	return snap
+}
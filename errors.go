@@ -0,0 +1,116 @@
+package webcrawler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrCrawlAborted is returned by [Crawler.Crawl] and [Engine.Run]
+// when a crawler aborts the crawl as per ErrorPolicyAbort
+var ErrCrawlAborted = errors.New("crawler: crawl aborted")
+
+// FetchError is reported when a URL could not be fetched
+// or the response was not usable
+type FetchError struct {
+	URL        string
+	StatusCode int   // HTTP status code; 0 when the request failed
+	Err        error // underlying error; nil when only the status code is invalid
+}
+
+func (e *FetchError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf(
+			"fetch '%s': invalid HTTP status %d: %s",
+			e.URL,
+			e.StatusCode,
+			http.StatusText(e.StatusCode),
+		)
+	}
+	return fmt.Sprintf("fetch '%s': %v", e.URL, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// StorageError is reported when a model or queue operation fails
+type StorageError struct {
+	URL string
+	Op  string // operation which failed e.g. "insert page"
+	Err error
+}
+
+func (e *StorageError) Error() string {
+	return fmt.Sprintf("storage: could not %s for url '%s': %v", e.Op, e.URL, e.Err)
+}
+
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
+// PolicySkipError is reported when a URL is skipped because of
+// crawl policy e.g. robots.txt, ignore patterns or empty content
+type PolicySkipError struct {
+	URL    string
+	Reason string
+}
+
+func (e *PolicySkipError) Error() string {
+	return fmt.Sprintf("skipped '%s': %s", e.URL, e.Reason)
+}
+
+// errorURL returns the URL of a FetchError, StorageError
+// or PolicySkipError; empty for other errors
+func errorURL(err error) string {
+	var fetchErr *FetchError
+	var storageErr *StorageError
+	var skipErr *PolicySkipError
+
+	switch {
+	case errors.As(err, &fetchErr):
+		return fetchErr.URL
+	case errors.As(err, &storageErr):
+		return storageErr.URL
+	case errors.As(err, &skipErr):
+		return skipErr.URL
+	}
+	return ""
+}
+
+// ErrorPolicy decides what a crawler does on a StorageError.
+//
+// Failed GET requests are always retried upto RetryTimes
+// and invalid HTTP status codes are never retried.
+type ErrorPolicy int
+
+const (
+	ErrorPolicyAbort ErrorPolicy = iota // abort the whole crawl
+	ErrorPolicySkip                     // report the error and skip the URL
+	ErrorPolicyRetry                    // push the URL back to queue after RetryBackoff, upto RetryTimes
+)
+
+var errorPolicyNames = []string{"abort", "skip", "retry"}
+
+func (p ErrorPolicy) String() string {
+	if int(p) < len(errorPolicyNames) && p >= 0 {
+		return errorPolicyNames[p]
+	}
+	return fmt.Sprintf("ErrorPolicy(%d)", p)
+}
+
+// ParseErrorPolicy returns the ErrorPolicy named s
+// i.e. one of "abort", "skip" or "retry"
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	for i, name := range errorPolicyNames {
+		if strings.EqualFold(s, name) {
+			return ErrorPolicy(i), nil
+		}
+	}
+	return ErrorPolicyAbort, fmt.Errorf(
+		"crawler: invalid error policy '%s'. Supported: %s",
+		s,
+		strings.Join(errorPolicyNames, ", "),
+	)
+}
@@ -0,0 +1,180 @@
+package webcrawler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the type of a crawl Event
+type EventType int
+
+const (
+	EventURLDiscovered  EventType = iota // new URL pushed to queue and model
+	EventFetchCompleted                  // response received for a URL
+	EventPageSaved                       // page content saved to model
+	EventURLDead                         // URL marked as dead
+	EventError                           // fetch or storage error reported
+	EventPageChanged                     // saved page content differs from the previous page of the URL
+	EventURLAlive                        // URL marked as dead is back online
+	EventRunFailed                       // crawl run failed or was aborted; published by the run owner
+)
+
+var eventTypeNames = []string{
+	"url_discovered", "fetch_completed", "page_saved", "url_dead", "error",
+	"page_changed", "url_alive", "run_failed",
+}
+
+func (t EventType) String() string {
+	if int(t) < len(eventTypeNames) && t >= 0 {
+		return eventTypeNames[t]
+	}
+	return fmt.Sprintf("EventType(%d)", t)
+}
+
+// MarshalText implements [encoding.TextMarshaler]
+func (t EventType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// ParseEventType returns the EventType named s
+func ParseEventType(s string) (EventType, error) {
+	for i, name := range eventTypeNames {
+		if strings.EqualFold(s, name) {
+			return EventType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid event type %q, must be one of: %s", s, strings.Join(eventTypeNames, ", "))
+}
+
+// Event is published by crawlers to the EventBus
+type Event struct {
+	ID             uint64        `json:"id"` // sequence number assigned by the EventBus
+	Type           EventType     `json:"type"`
+	Time           time.Time     `json:"time"`
+	Crawler        string        `json:"crawler"`
+	URL            string        `json:"url"`
+	RunID          uint          `json:"run_id,omitempty"`
+	StatusCode     int           `json:"status_code,omitempty"`      // EventFetchCompleted, EventURLDead and EventURLAlive
+	Duration       time.Duration `json:"duration,omitempty"`         // time taken by the request; EventFetchCompleted
+	Error          string        `json:"error,omitempty"`            // EventError and EventRunFailed
+	Monitored      bool          `json:"monitored,omitempty"`        // URL is monitored; EventURLDead and EventURLAlive
+	PageID         uint          `json:"page_id,omitempty"`          // saved page; EventPageSaved and EventPageChanged
+	PreviousPageID uint          `json:"previous_page_id,omitempty"` // previous page of the URL; EventPageChanged
+	Variant        string        `json:"variant,omitempty"`          // fetch variant; EventFetchCompleted, EventPageSaved and EventPageChanged
+}
+
+// EventFilter selects the events sent to a Subscription
+type EventFilter struct {
+	Types      []EventType // event types to match; all types when empty
+	URLPattern string      // pattern the event URL should contain; all URLs when empty
+}
+
+// Match tells if e passes the filter
+func (f EventFilter) Match(e Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == e.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return strings.Contains(e.URL, f.URLPattern)
+}
+
+// ErrEventBusClosed is returned when subscribing to a closed EventBus
+var ErrEventBusClosed = errors.New("events: bus closed")
+
+// EventBus fans out crawl events to subscribers.
+//
+// Publish never blocks; events are dropped for subscribers
+// which are not keeping up.
+type EventBus struct {
+	seq    atomic.Uint64
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewEventBus returns pointer to a new EventBus
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events matching its filter on C
+type Subscription struct {
+	C       <-chan Event // closed on Close or when the bus is closed
+	c       chan Event
+	filter  EventFilter
+	bus     *EventBus
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Subscribe returns a new Subscription receiving events matching filter.
+// buffer is the number of events held for a slow subscriber.
+func (b *EventBus) Subscribe(filter EventFilter, buffer int) (*Subscription, error) {
+	c := make(chan Event, max(1, buffer))
+	sub := &Subscription{C: c, c: c, filter: filter, bus: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrEventBusClosed
+	}
+	b.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish sends e to the subscribers matching e.
+// e.ID is assigned by the bus.
+func (b *EventBus) Publish(e Event) {
+	e.ID = b.seq.Add(1)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.c <- e:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Close closes all subscriptions; later subscriptions fail
+// with ErrEventBusClosed and published events are discarded
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		sub.once.Do(func() { close(sub.c) })
+	}
+}
+
+// Close removes s from the bus and closes s.C
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	delete(s.bus.subs, s)
+	s.once.Do(func() { close(s.c) })
+}
+
+// Dropped returns the number of events dropped
+// because s was not keeping up
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
@@ -0,0 +1,401 @@
+package webcrawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// AnyContentType registers a LinkExtractor for pages of every content type
+const AnyContentType = "*/*"
+
+// followedLinkRels are the rel values of <link> tags and Link headers
+// whose URLs are followed; other relations e.g. stylesheet are not pages
+var followedLinkRels = []string{"next", "prev", "previous", "alternate", "canonical", "amphtml"}
+
+// htmlContentTypes are the media types of HTML pages
+var htmlContentTypes = []string{"text/html", "application/xhtml+xml"}
+
+// Page is a fetched page passed to link extractors
+type Page struct {
+	URL    *url.URL          // URL of the response, after redirects
+	Base   *url.URL          // URL relative links resolve against: <base href> of HTML pages, else URL
+	Header http.Header       // headers of the response
+	Body   []byte            // content of the response
+	Doc    *goquery.Document // Body parsed as HTML
+}
+
+// Resolve returns href resolved against the base of p without fragment.
+// Hrefs of other schemes e.g. mailto: are returned as is, to be rejected
+// by the crawler; empty when href is empty.
+func (p *Page) Resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || internal.BeginsWith(href, invalidHrefPrefixs) {
+		return href
+	}
+	resolved, err := p.Base.Parse(href)
+	if err != nil {
+		return href
+	}
+	resolved.Fragment = ""
+	resolved.RawFragment = ""
+	return resolved.String()
+}
+
+// Link is a link found in a page
+type Link struct {
+	URL      string // URL of the link, resolved with Page.Resolve
+	Source   string // tag and attribute, or header, the link was found in e.g. "a[href]", "link[rel=next]"
+	Nofollow bool   // link has rel="nofollow"
+}
+
+// LinkExtractor finds the links of pages of its content types.
+// Implementations must be safe for concurrent use.
+type LinkExtractor interface {
+	// Name identifies the extractor e.g. to enable it by name
+	Name() string
+	// ContentTypes returns the media types of the pages the extractor
+	// is registered for e.g. "text/html"; AnyContentType for all pages
+	ContentTypes() []string
+	// Extract returns the links of page
+	Extract(page *Page) ([]Link, error)
+}
+
+// LinkExtractors holds link extractors by the content types they are registered for
+type LinkExtractors struct {
+	names  []string
+	byType map[string][]LinkExtractor
+}
+
+// NewLinkExtractors returns pointer to new LinkExtractors with extractors registered
+func NewLinkExtractors(extractors ...LinkExtractor) *LinkExtractors {
+	le := &LinkExtractors{byType: map[string][]LinkExtractor{}}
+	for _, e := range extractors {
+		le.Register(e)
+	}
+	return le
+}
+
+// Register registers e for its content types. Must not be called once crawling.
+func (le *LinkExtractors) Register(e LinkExtractor) {
+	le.names = append(le.names, e.Name())
+	for _, t := range e.ContentTypes() {
+		t = strings.ToLower(t)
+		le.byType[t] = append(le.byType[t], e)
+	}
+}
+
+// Names returns the names of the registered extractors
+func (le *LinkExtractors) Names() []string {
+	return le.names
+}
+
+// For returns the extractors of pages of contentType, a Content-Type
+// header value, followed by the extractors of AnyContentType
+func (le *LinkExtractors) For(contentType string) []LinkExtractor {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	var extractors []LinkExtractor
+	extractors = append(extractors, le.byType[mediaType]...)
+	return append(extractors, le.byType[AnyContentType]...)
+}
+
+// builtinExtractors are the built-in link extractors by name
+var builtinExtractors = []LinkExtractor{
+	&HTMLExtractor{},
+	&SrcsetExtractor{},
+	&FormExtractor{},
+	&JSONLDExtractor{},
+	&LinkHeaderExtractor{},
+	&FeedExtractor{},
+	&SitemapExtractor{},
+}
+
+// BuiltinExtractorNames returns the names of the built-in link extractors
+func BuiltinExtractorNames() []string {
+	names := make([]string, len(builtinExtractors))
+	for i, e := range builtinExtractors {
+		names[i] = e.Name()
+	}
+	return names
+}
+
+// DefaultLinkExtractors returns LinkExtractors of the built-in extractors
+// named names, or of the html extractor only when names is empty; the
+// other extractors are opt-in. Returns error for unknown names.
+func DefaultLinkExtractors(names ...string) (*LinkExtractors, error) {
+	if len(names) == 0 {
+		return NewLinkExtractors(&HTMLExtractor{}), nil
+	}
+	le := NewLinkExtractors()
+	for _, name := range names {
+		found := false
+		for _, e := range builtinExtractors {
+			if strings.EqualFold(name, e.Name()) {
+				le.Register(e)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf(
+				"crawler: invalid link extractor '%s'. Supported: %s",
+				name,
+				strings.Join(BuiltinExtractorNames(), ", "),
+			)
+		}
+	}
+	return le, nil
+}
+
+// HTMLExtractor finds the links of HTML pages in <a> and <area> hrefs,
+// <link> hrefs of followed relations e.g. next and alternate, <iframe>
+// and <frame> srcs and <meta> refresh URLs
+type HTMLExtractor struct{}
+
+// Name implements LinkExtractor
+func (*HTMLExtractor) Name() string { return "html" }
+
+// ContentTypes implements LinkExtractor
+func (*HTMLExtractor) ContentTypes() []string { return htmlContentTypes }
+
+// Extract implements LinkExtractor
+func (*HTMLExtractor) Extract(page *Page) ([]Link, error) {
+	var links []Link
+	add := func(href, source string, nofollow bool) {
+		if href = page.Resolve(href); href != "" {
+			links = append(links, Link{URL: href, Source: source, Nofollow: nofollow})
+		}
+	}
+
+	page.Doc.Find("a[href], area[href]").Each(func(_ int, s *goquery.Selection) {
+		add(s.AttrOr("href", ""), goquery.NodeName(s)+"[href]", isNofollowLink(s))
+	})
+	page.Doc.Find("link[href][rel]").Each(func(_ int, s *goquery.Selection) {
+		if rel := followedRel(s.AttrOr("rel", "")); rel != "" {
+			add(s.AttrOr("href", ""), "link[rel="+rel+"]", isNofollowLink(s))
+		}
+	})
+	page.Doc.Find("iframe[src], frame[src]").Each(func(_ int, s *goquery.Selection) {
+		add(s.AttrOr("src", ""), goquery.NodeName(s)+"[src]", false)
+	})
+	page.Doc.Find("meta[http-equiv][content]").Each(func(_ int, s *goquery.Selection) {
+		if strings.EqualFold(s.AttrOr("http-equiv", ""), "refresh") {
+			add(refreshURL(s.AttrOr("content", "")), "meta[refresh]", false)
+		}
+	})
+	return links, nil
+}
+
+// followedRel returns the first of the space separated relations rel
+// which is followed; empty when none
+func followedRel(rel string) string {
+	for _, r := range strings.Fields(rel) {
+		r = strings.ToLower(r)
+		if internal.ValuePresent(r, followedLinkRels) {
+			return r
+		}
+	}
+	return ""
+}
+
+// refreshURL returns the URL of the content of a <meta> refresh
+// e.g. "5; url=/next"; empty when content has no URL
+func refreshURL(content string) string {
+	_, after, found := strings.Cut(content, ";")
+	if !found {
+		return ""
+	}
+	after = strings.TrimSpace(after)
+	if len(after) < 4 || !strings.EqualFold(after[:3], "url") {
+		return ""
+	}
+	after = strings.TrimSpace(after[3:])
+	after, found = strings.CutPrefix(after, "=")
+	if !found {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(after), `'"`)
+}
+
+// FormExtractor finds the action URLs of the GET forms of HTML pages,
+// requested without the form fields
+type FormExtractor struct{}
+
+// Name implements LinkExtractor
+func (*FormExtractor) Name() string { return "form" }
+
+// ContentTypes implements LinkExtractor
+func (*FormExtractor) ContentTypes() []string { return htmlContentTypes }
+
+// Extract implements LinkExtractor
+func (*FormExtractor) Extract(page *Page) ([]Link, error) {
+	var links []Link
+	page.Doc.Find("form[action]").Each(func(_ int, s *goquery.Selection) {
+		method := strings.TrimSpace(s.AttrOr("method", ""))
+		if method != "" && !strings.EqualFold(method, http.MethodGet) {
+			return
+		}
+		if href := page.Resolve(s.AttrOr("action", "")); href != "" {
+			links = append(links, Link{URL: href, Source: "form[action]"})
+		}
+	})
+	return links, nil
+}
+
+// SrcsetExtractor finds the URLs of the image candidates in srcset
+// attributes of <img> and <source> tags of HTML pages
+type SrcsetExtractor struct{}
+
+// Name implements LinkExtractor
+func (*SrcsetExtractor) Name() string { return "srcset" }
+
+// ContentTypes implements LinkExtractor
+func (*SrcsetExtractor) ContentTypes() []string { return htmlContentTypes }
+
+// Extract implements LinkExtractor
+func (*SrcsetExtractor) Extract(page *Page) ([]Link, error) {
+	var links []Link
+	page.Doc.Find("img[srcset], source[srcset]").Each(func(_ int, s *goquery.Selection) {
+		source := goquery.NodeName(s) + "[srcset]"
+		// candidates are comma separated URLs with an optional descriptor
+		for _, candidate := range strings.Split(s.AttrOr("srcset", ""), ",") {
+			fields := strings.Fields(candidate)
+			if len(fields) == 0 {
+				continue
+			}
+			if href := page.Resolve(fields[0]); href != "" {
+				links = append(links, Link{URL: href, Source: source})
+			}
+		}
+	})
+	return links, nil
+}
+
+// JSONLDExtractor finds the URLs of "url" fields of the JSON-LD
+// <script type="application/ld+json"> blocks of HTML pages
+type JSONLDExtractor struct{}
+
+// Name implements LinkExtractor
+func (*JSONLDExtractor) Name() string { return "jsonld" }
+
+// ContentTypes implements LinkExtractor
+func (*JSONLDExtractor) ContentTypes() []string { return htmlContentTypes }
+
+// Extract implements LinkExtractor. Returns the links of the valid
+// blocks along with error of the invalid ones.
+func (*JSONLDExtractor) Extract(page *Page) ([]Link, error) {
+	var links []Link
+	var err error
+	page.Doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var data any
+		if jsonErr := json.Unmarshal([]byte(s.Text()), &data); jsonErr != nil {
+			err = fmt.Errorf("invalid JSON-LD: %w", jsonErr)
+			return
+		}
+		for _, href := range jsonLDURLs(data) {
+			if href = page.Resolve(href); href != "" {
+				links = append(links, Link{URL: href, Source: "json-ld[url]"})
+			}
+		}
+	})
+	return links, err
+}
+
+// jsonLDURLs returns the string values of the "url" fields of data and of its nested objects
+func jsonLDURLs(data any) []string {
+	var urls []string
+	switch v := data.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if key == "url" {
+				switch u := value.(type) {
+				case string:
+					urls = append(urls, u)
+					continue
+				case []any:
+					for _, item := range u {
+						if s, ok := item.(string); ok {
+							urls = append(urls, s)
+						}
+					}
+				}
+			}
+			urls = append(urls, jsonLDURLs(value)...)
+		}
+	case []any:
+		for _, item := range v {
+			urls = append(urls, jsonLDURLs(item)...)
+		}
+	}
+	return urls
+}
+
+// LinkHeaderExtractor finds the URLs of followed relations e.g. next
+// and alternate in the Link headers of pages of every content type
+type LinkHeaderExtractor struct{}
+
+// Name implements LinkExtractor
+func (*LinkHeaderExtractor) Name() string { return "link-header" }
+
+// ContentTypes implements LinkExtractor
+func (*LinkHeaderExtractor) ContentTypes() []string { return []string{AnyContentType} }
+
+// Extract implements LinkExtractor
+func (*LinkHeaderExtractor) Extract(page *Page) ([]Link, error) {
+	// Link header URLs resolve against the URL of the response
+	headerPage := &Page{Base: page.URL}
+	var links []Link
+	for _, value := range page.Header.Values("Link") {
+		for _, link := range parseLinkHeader(value) {
+			if rel := followedRel(link.rel); rel != "" {
+				if href := headerPage.Resolve(link.href); href != "" {
+					links = append(links, Link{URL: href, Source: "Link[rel=" + rel + "]"})
+				}
+			}
+		}
+	}
+	return links, nil
+}
+
+// headerLink is a link of a Link header
+type headerLink struct {
+	href string
+	rel  string
+}
+
+// parseLinkHeader returns the links of the Link header value
+// e.g. `<https://example.com/?page=2>; rel="next", </a>; rel=alternate`
+func parseLinkHeader(value string) []headerLink {
+	var links []headerLink
+	for {
+		start := strings.IndexByte(value, '<')
+		end := strings.IndexByte(value, '>')
+		if start < 0 || end < start {
+			return links
+		}
+		link := headerLink{href: value[start+1 : end]}
+		value = value[end+1:]
+
+		// parameters up to the next link
+		params := value
+		if next := strings.IndexByte(value, '<'); next >= 0 {
+			params = value[:next]
+		}
+		for _, param := range strings.Split(params, ";") {
+			name, val, found := strings.Cut(param, "=")
+			if found && strings.EqualFold(strings.TrimSpace(name), "rel") {
+				link.rel = strings.Trim(val, `", `)
+			}
+		}
+		links = append(links, link)
+	}
+}
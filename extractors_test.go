@@ -0,0 +1,382 @@
+package webcrawler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// newTestPage returns the page of rawURL with header and body,
+// parsed as HTML
+func newTestPage(t *testing.T, rawURL string, header http.Header, body string) *Page {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := mustParseURL(t, rawURL)
+	if header == nil {
+		header = http.Header{}
+	}
+	return &Page{URL: u, Base: u, Header: header, Body: []byte(body), Doc: doc}
+}
+
+// linkStrings returns links as "<source> <url>", with " nofollow" when set
+func linkStrings(links []Link) []string {
+	s := []string{}
+	for _, link := range links {
+		value := link.Source + " " + link.URL
+		if link.Nofollow {
+			value += " nofollow"
+		}
+		s = append(s, value)
+	}
+	return s
+}
+
+func TestLinkExtractors(t *testing.T) {
+	t.Run("DefaultLinkExtractors", func(t *testing.T) {
+		tests := []struct {
+			input   []string
+			want    []string
+			wantErr bool
+		}{
+			{input: nil, want: []string{"html"}},
+			{input: []string{"HTML", "srcset", "form"}, want: []string{"html", "srcset", "form"}},
+			{input: []string{"sitemap"}, want: []string{"sitemap"}},
+			{input: []string{"html", "images"}, wantErr: true},
+		}
+
+		for _, test := range tests {
+			le, err := DefaultLinkExtractors(test.input...)
+			if (err != nil) != test.wantErr {
+				t.Errorf("input: %v, got error %v, want error %t", test.input, err, test.wantErr)
+				continue
+			}
+			if err == nil && !slices.Equal(le.Names(), test.want) {
+				t.Errorf("input: %v, got %v, want %v", test.input, le.Names(), test.want)
+			}
+		}
+
+		le, err := DefaultLinkExtractors("html", "link-header", "feed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for input, want := range map[string]int{
+			"text/html; charset=utf-8": 2,
+			"application/rss+xml":      2,
+			"image/png":                1,
+		} {
+			if got := len(le.For(input)); got != want {
+				t.Errorf("content type: %s, got %d extractors, want %d", input, got, want)
+			}
+		}
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		page := newTestPage(t, "https://example.com/docs/", nil, `<html><head>
+<link rel="Next" href="?page=2"><link rel="stylesheet" href="/style.css">
+<meta http-equiv="refresh" content="5; url='/moved'">
+</head><body>
+<a href="guide#install">guide</a><a href="mailto:hi@example.com">mail</a><a href="">empty</a>
+<map><area href="/area" rel="nofollow"></map>
+<iframe src="/embed"></iframe>
+<img src="/a.png" srcset="/a-1x.png 1x, /a-2x.png 2x"><picture><source srcset="/b.webp"></picture>
+<form action="/search"></form><form method="GET" action="/filter"></form><form method="post" action="/login"></form>
+</body></html>`)
+
+		tests := []struct {
+			extractor LinkExtractor
+			want      []string
+		}{
+			{
+				extractor: &HTMLExtractor{},
+				want: []string{
+					"a[href] https://example.com/docs/guide",
+					"a[href] mailto:hi@example.com",
+					"area[href] https://example.com/area nofollow",
+					"link[rel=next] https://example.com/docs/?page=2",
+					"iframe[src] https://example.com/embed",
+					"meta[refresh] https://example.com/moved",
+				},
+			},
+			{
+				extractor: &SrcsetExtractor{},
+				want: []string{
+					"img[srcset] https://example.com/a-1x.png",
+					"img[srcset] https://example.com/a-2x.png",
+					"source[srcset] https://example.com/b.webp",
+				},
+			},
+			{
+				extractor: &FormExtractor{},
+				want: []string{
+					"form[action] https://example.com/search",
+					"form[action] https://example.com/filter",
+				},
+			},
+		}
+
+		for _, test := range tests {
+			links, err := test.extractor.Extract(page)
+			if err != nil {
+				t.Errorf("%s: got error %v", test.extractor.Name(), err)
+			}
+			if got := linkStrings(links); !slices.Equal(got, test.want) {
+				t.Errorf("%s: got %v, want %v", test.extractor.Name(), got, test.want)
+			}
+		}
+	})
+
+	t.Run("JSONLD", func(t *testing.T) {
+		page := newTestPage(t, "https://example.com/", nil, `
+<script type="application/ld+json">{"@type": "Article", "url": "/article"}</script>
+<script type="application/ld+json">{invalid</script>`)
+
+		links, err := (&JSONLDExtractor{}).Extract(page)
+		if err == nil {
+			t.Error("expected error for an invalid block")
+		}
+		want := []string{"json-ld[url] https://example.com/article"}
+		if got := linkStrings(links); !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("LinkHeader", func(t *testing.T) {
+		header := http.Header{}
+		header.Add("Link", `</feed>; rel="alternate"; type="application/rss+xml", </style.css>; rel=stylesheet`)
+		header.Add("Link", `<https://cdn.example.com/page/2>; rel="prefetch next"`)
+		// Link header URLs resolve against the response URL, not <base href>
+		page := newTestPage(t, "https://example.com/blog/", header, `<base href="https://other.example.com/">`)
+		page.Base = mustParseURL(t, "https://other.example.com/")
+
+		links, err := (&LinkHeaderExtractor{}).Extract(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"Link[rel=alternate] https://example.com/feed",
+			"Link[rel=next] https://cdn.example.com/page/2",
+		}
+		if got := linkStrings(links); !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Feed", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input string
+			want  []string
+		}{
+			{
+				name: "rss",
+				input: `<?xml version="1.0" encoding="ISO-8859-1"?><rss version="2.0"><channel>
+<link>https://example.com/</link><item><title>a</title><link> /posts/a </link></item>
+</channel></rss>`,
+				want: []string{"rss[link] https://example.com/", "rss[link] https://example.com/posts/a"},
+			},
+			{
+				name: "rdf",
+				input: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+<item><link>https://example.com/posts/b</link></item></rdf:RDF>`,
+				want: []string{"rss[link] https://example.com/posts/b"},
+			},
+			{
+				name: "atom",
+				input: `<feed xmlns="http://www.w3.org/2005/Atom"><link rel="self" href="/feed.atom"/>
+<entry><link href="/posts/c"/><link rel="enclosure" href="/c.mp3"/></entry></feed>`,
+				want: []string{"atom[link] https://example.com/posts/c"},
+			},
+			{
+				name:  "other XML",
+				input: `<urlset><url><loc>https://example.com/a</loc></url></urlset>`,
+				want:  []string{},
+			},
+		}
+
+		for _, test := range tests {
+			page := newTestPage(t, "https://example.com/feed", nil, "")
+			page.Body = []byte(test.input)
+			links, err := (&FeedExtractor{}).Extract(page)
+			if err != nil {
+				t.Errorf("%s: got error %v", test.name, err)
+			}
+			if got := linkStrings(links); !slices.Equal(got, test.want) {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			}
+		}
+	})
+
+	t.Run("Sitemap", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input string
+			want  []string
+		}{
+			{
+				name: "urlset",
+				input: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>https://example.com/a</loc><lastmod>2024-01-01</lastmod></url>
+<url><loc>
+  https://example.com/b?x=1&amp;y=2
+</loc></url></urlset>`,
+				want: []string{"urlset[loc] https://example.com/a", "urlset[loc] https://example.com/b?x=1&y=2"},
+			},
+			{
+				name:  "sitemapindex",
+				input: `<sitemapindex><sitemap><loc>/sitemap-posts.xml</loc></sitemap></sitemapindex>`,
+				want:  []string{"sitemapindex[loc] https://example.com/sitemap-posts.xml"},
+			},
+			{
+				name:  "feed",
+				input: `<rss><channel><link>https://example.com/</link></channel></rss>`,
+				want:  []string{},
+			},
+		}
+
+		for _, test := range tests {
+			page := newTestPage(t, "https://example.com/sitemap.xml", nil, "")
+			page.Body = []byte(test.input)
+			links, err := (&SitemapExtractor{}).Extract(page)
+			if err != nil {
+				t.Errorf("%s: got error %v", test.name, err)
+			}
+			if got := linkStrings(links); !slices.Equal(got, test.want) {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			}
+		}
+	})
+
+	t.Run("parseLinkHeader", func(t *testing.T) {
+		tests := []struct {
+			input string
+			want  []headerLink
+		}{
+			{input: "", want: nil},
+			{input: "no links", want: nil},
+			{input: `<https://example.com/?page=2>; rel="next"`, want: []headerLink{{href: "https://example.com/?page=2", rel: "next"}}},
+			{
+				input: `</a>; rel=alternate; type="text/html", </b>;title="b" ; REL="prev next"`,
+				want:  []headerLink{{href: "/a", rel: "alternate"}, {href: "/b", rel: "prev next"}},
+			},
+			{input: `</a>; title="a"`, want: []headerLink{{href: "/a"}}},
+			{input: `/a>; rel=next`, want: nil},
+		}
+
+		for _, test := range tests {
+			if got := parseLinkHeader(test.input); !slices.Equal(got, test.want) {
+				t.Errorf("input: %q, got %v, want %v", test.input, got, test.want)
+			}
+		}
+	})
+
+	t.Run("refreshURL", func(t *testing.T) {
+		tests := []struct {
+			input string
+			want  string
+		}{
+			{input: "5", want: ""},
+			{input: "0; url=/next", want: "/next"},
+			{input: "0;URL = 'https://example.com/a b'", want: "https://example.com/a b"},
+			{input: `3; url="/quoted"`, want: "/quoted"},
+			{input: "0; /next", want: ""},
+			{input: "0; urn=/next", want: ""},
+			{input: "0; url", want: ""},
+		}
+
+		for _, test := range tests {
+			if got := refreshURL(test.input); got != test.want {
+				t.Errorf("input: %q, got %q, want %q", test.input, got, test.want)
+			}
+		}
+	})
+
+	t.Run("jsonLDURLs", func(t *testing.T) {
+		tests := []struct {
+			input string
+			want  []string
+		}{
+			{input: `"https://example.com/"`, want: nil},
+			{input: `{"url": "/a", "name": "a"}`, want: []string{"/a"}},
+			{input: `{"url": ["/a", 1, "/b"]}`, want: []string{"/a", "/b"}},
+			{input: `{"url": {"url": "/nested"}}`, want: []string{"/nested"}},
+			{
+				input: `[{"@graph": [{"url": "/c"}, {"author": {"url": "/d"}}]}, {"image": "/e.png"}]`,
+				want:  []string{"/c", "/d"},
+			},
+		}
+
+		for _, test := range tests {
+			var data any
+			if err := json.Unmarshal([]byte(test.input), &data); err != nil {
+				t.Fatal(err)
+			}
+			got := jsonLDURLs(data)
+			slices.Sort(got)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("input: %s, got %v, want %v", test.input, got, test.want)
+			}
+		}
+	})
+
+	t.Run("walkXML", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			input   string
+			stop    string // local name of the element visit returns false for
+			want    []string
+			wantErr bool
+		}{
+			{
+				name:  "elements",
+				input: `<?xml version="1.0"?><a:root xmlns:a="urn:a"><b>text</b><c/></a:root>`,
+				want:  []string{"root root", "root b=text", "root c"},
+			},
+			{
+				name:  "stop",
+				input: `<root><b/><c/><d/></root>`,
+				stop:  "c",
+				want:  []string{"root root", "root b", "root c"},
+			},
+			{
+				name:  "empty",
+				input: "",
+				want:  []string{},
+			},
+			{
+				name:    "invalid",
+				input:   `<root><b>`,
+				want:    []string{"root root", "root b"},
+				wantErr: true,
+			},
+		}
+
+		for _, test := range tests {
+			got := []string{}
+			err := walkXML([]byte(test.input), func(root string, el xml.StartElement, text func() string) bool {
+				value := root + " " + el.Name.Local
+				if el.Name.Local == "b" {
+					if s := text(); s != "" {
+						value += "=" + s
+					}
+				}
+				got = append(got, value)
+				return el.Name.Local != test.stop
+			})
+			if (err != nil) != test.wantErr {
+				t.Errorf("%s: got error %v, want error %t", test.name, err, test.wantErr)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			}
+		}
+	})
+}
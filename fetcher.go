@@ -0,0 +1,30 @@
+package webcrawler
+
+import (
+	"net/http"
+)
+
+// Fetcher sends the requests of crawlers, of the form login and of
+// robots.txt and returns their responses. The caller closes the body
+// of the response. Implementations must be safe for concurrent use.
+//
+// HTTPFetcher sends live requests; Recorder saves the responses of
+// another Fetcher to a cassette which Replayer serves back without
+// network, so that a crawl can be reproduced offline.
+type Fetcher interface {
+	Fetch(req *http.Request) (*http.Response, error)
+}
+
+// HTTPFetcher is the Fetcher sending requests with Client
+type HTTPFetcher struct {
+	Client *http.Client // [http.DefaultClient] when nil
+}
+
+// Fetch implements Fetcher
+func (f *HTTPFetcher) Fetch(req *http.Request) (*http.Response, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
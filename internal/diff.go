@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells limits the size of the table used to find the longest
+// common subsequence of the changed lines. Larger changes are reported
+// as the removal of all old lines and the addition of all new lines.
+const maxDiffCells = 16 << 20
+
+// diffLine is a line of a diff; op is ' ' for an unchanged line,
+// '-' for a removed line and '+' for an added line
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// UnifiedDiff returns the line diff of a and b in unified format with
+// context unchanged lines around the changes, labelled with aName and
+// bName. Returns an empty string when a and b are equal.
+func UnifiedDiff(aName, bName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	// aLine and bLine are the line numbers of lines[i] in a and b
+	aLine, bLine := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	aLine[0], bLine[0] = 1, 1
+	for i, l := range lines {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if l.op != '+' {
+			aLine[i+1]++
+		}
+		if l.op != '-' {
+			bLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// hunk from the context before the change until the context after
+		// the last change not separated by more than 2*context lines
+		start := max(i-context, 0)
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		end = min(end+context, len(lines))
+
+		fmt.Fprintf(
+			&sb,
+			"@@ -%s +%s @@\n",
+			hunkRange(aLine[start], aLine[end]-aLine[start]),
+			hunkRange(bLine[start], bLine[end]-bLine[start]),
+		)
+		for _, l := range lines[start:end] {
+			sb.WriteByte(l.op)
+			sb.WriteString(l.text)
+			sb.WriteByte('\n')
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// hunkRange formats the range of lines of a hunk
+func hunkRange(line, count int) string {
+	if count == 0 {
+		// empty ranges refer to the line before
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits s into lines without the line endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the lines of a and b as unchanged, removed
+// and added lines, keeping the longest common subsequence unchanged
+func diffLines(a, b []string) []diffLine {
+	// unchanged lines at the start and end are not part of the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b)-prefix-suffix)
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(am), len(bm)
+	if n*m > maxDiffCells {
+		for _, text := range am {
+			lines = append(lines, diffLine{'-', text})
+		}
+		for _, text := range bm {
+			lines = append(lines, diffLine{'+', text})
+		}
+	} else {
+		// lcs[i*(m+1)+j] is the length of the longest common
+		// subsequence of am[i:] and bm[j:]
+		lcs := make([]int32, (n+1)*(m+1))
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+				} else {
+					lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && am[i] == bm[j]:
+				lines = append(lines, diffLine{' ', am[i]})
+				i++
+				j++
+			case j == m || (i < n && lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]):
+				lines = append(lines, diffLine{'-', am[i]})
+				i++
+			default:
+				lines = append(lines, diffLine{'+', bm[j]})
+				j++
+			}
+		}
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
@@ -0,0 +1,135 @@
+package webcrawler
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrNotLocal is returned by DirFetcher for URLs of other hosts
+var ErrNotLocal = errors.New("local: URL not on the virtual host")
+
+// DirFetcher is a Fetcher serving the files of a directory, e.g. the build
+// output of a static site, as the pages of a virtual host so that they are
+// crawled without a web server.
+//
+// The path of a URL maps to the file at the same path under the directory.
+// Paths of directories map to their index.html, and paths without an
+// extension to the .html file of the path when no such file exists, as
+// served by most static hosts. Missing files are served HTTP 404 and
+// requests for URLs of other hosts return error wrapping ErrNotLocal.
+type DirFetcher struct {
+	host *url.URL // scheme and host the files are served under
+	fsys fs.FS    // files of the directory
+}
+
+// NewDirFetcher returns pointer to a new DirFetcher of the files
+// of directory root served under the scheme and host of vhost
+func NewDirFetcher(root string, vhost *url.URL) (*DirFetcher, error) {
+	if vhost == nil || vhost.Scheme == "" || vhost.Host == "" {
+		return nil, errors.New("local: virtual host must be an absolute URL")
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("local: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("local: %s is not a directory", root)
+	}
+	return &DirFetcher{
+		host: &url.URL{Scheme: vhost.Scheme, Host: vhost.Host},
+		fsys: os.DirFS(root),
+	}, nil
+}
+
+// Fetch implements Fetcher
+func (f *DirFetcher) Fetch(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	if req.URL.Scheme != f.host.Scheme || req.URL.Host != f.host.Host {
+		return nil, fmt.Errorf("%w: %s", ErrNotLocal, req.URL)
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return newReplayResponse(req, http.StatusMethodNotAllowed, http.Header{}, nil), nil
+	}
+
+	name, finalPath := f.resolve(req.URL.Path)
+	if name == "" {
+		return newReplayResponse(req, http.StatusNotFound, http.Header{}, nil), nil
+	}
+	body, err := fs.ReadFile(f.fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("local: %w", err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", contentType(name, body))
+	if info, err := fs.Stat(f.fsys, name); err == nil {
+		header.Set("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+	}
+
+	// relative URLs of a directory index resolve against the path of the directory
+	if finalPath != req.URL.Path {
+		finalURL := *req.URL
+		finalURL.Path = finalPath
+		finalURL.RawPath = ""
+		req = req.Clone(req.Context())
+		req.URL = &finalURL
+	}
+	if req.Method == http.MethodHead {
+		body = nil
+	}
+	return newReplayResponse(req, http.StatusOK, header, body), nil
+}
+
+// resolve returns the name of the file served for the URL path urlPath and
+// the path of its URL, which ends with '/' for directories. name is empty
+// when there is no such file.
+func (f *DirFetcher) resolve(urlPath string) (name, finalPath string) {
+	name = strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", ""
+	}
+
+	info, err := fs.Stat(f.fsys, name)
+	switch {
+	case err == nil && info.IsDir():
+		index := path.Join(name, "index.html")
+		if !f.isFile(index) {
+			return "", ""
+		}
+		if !strings.HasSuffix(urlPath, "/") {
+			urlPath += "/"
+		}
+		return index, urlPath
+	case err == nil:
+		return name, urlPath
+	case path.Ext(name) == "" && f.isFile(name+".html"):
+		return name + ".html", urlPath
+	}
+	return "", ""
+}
+
+// isFile tells if name is a regular file of f
+func (f *DirFetcher) isFile(name string) bool {
+	info, err := fs.Stat(f.fsys, name)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// contentType returns the media type of the file name with content body
+func contentType(name string, body []byte) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(body)
+}
@@ -0,0 +1,185 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestSite writes files, keyed by their slash separated path, under
+// a new directory site in a temporary directory and returns site
+func newTestSite(t *testing.T, files map[string]string) string {
+	t.Helper()
+	site := filepath.Join(t.TempDir(), "site")
+	for name, content := range files {
+		path := filepath.Join(site, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return site
+}
+
+func TestDirFetcher(t *testing.T) {
+	site := newTestSite(t, map[string]string{
+		"index.html":        "<h1>home</h1>",
+		"about.html":        "<h1>about</h1>",
+		"docs/index.html":   "<h1>docs</h1>",
+		"docs/guide":        "plain guide",
+		"docs/guide.html":   "<h1>guide</h1>",
+		"assets/style.css":  "body {}",
+		"empty/.keep":       "",
+		"release-1.0.html":  "<h1>release</h1>",
+		"nested/a/b/c.html": "<h1>c</h1>",
+	})
+	// files next to the site must not be served
+	if err := os.WriteFile(filepath.Join(filepath.Dir(site), "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher, err := NewDirFetcher(site, mustParseURL(t, "http://localhost"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("NewDirFetcher", func(t *testing.T) {
+		if _, err := NewDirFetcher(site, mustParseURL(t, "/relative")); err == nil {
+			t.Error("expected error for a relative virtual host")
+		}
+		if _, err := NewDirFetcher(filepath.Join(site, "index.html"), mustParseURL(t, "http://localhost")); err == nil {
+			t.Error("expected error for a file root")
+		}
+		if _, err := NewDirFetcher(filepath.Join(site, "missing"), mustParseURL(t, "http://localhost")); err == nil {
+			t.Error("expected error for a missing root")
+		}
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		tests := []struct {
+			input     string
+			name      string
+			finalPath string
+		}{
+			{input: "", name: "index.html", finalPath: "/"},
+			{input: "/", name: "index.html", finalPath: "/"},
+			{input: "/about", name: "about.html", finalPath: "/about"},
+			{input: "/about.html", name: "about.html", finalPath: "/about.html"},
+			{input: "/docs", name: "docs/index.html", finalPath: "/docs/"},
+			{input: "/docs/", name: "docs/index.html", finalPath: "/docs/"},
+			{input: "/docs/guide", name: "docs/guide", finalPath: "/docs/guide"},
+			{input: "/assets/style.css", name: "assets/style.css", finalPath: "/assets/style.css"},
+			{input: "/nested/a/../a/./b/c", name: "nested/a/b/c.html", finalPath: "/nested/a/../a/./b/c"},
+			{input: "/../about", name: "about.html", finalPath: "/../about"},
+			{input: "/../secret.txt", name: "", finalPath: ""},
+			{input: "/../../site/index.html", name: "", finalPath: ""},
+			{input: "/release-1.0", name: "", finalPath: ""},
+			{input: "/empty", name: "", finalPath: ""},
+			{input: "/assets/style", name: "", finalPath: ""},
+			{input: "/missing", name: "", finalPath: ""},
+		}
+
+		for _, test := range tests {
+			name, finalPath := fetcher.resolve(test.input)
+			if name != test.name || finalPath != test.finalPath {
+				t.Errorf(
+					"input: %q, got %q, %q, want %q, %q",
+					test.input, name, finalPath, test.name, test.finalPath,
+				)
+			}
+		}
+	})
+
+	t.Run("Fetch", func(t *testing.T) {
+		tests := []struct {
+			method      string
+			input       string
+			status      int
+			finalURL    string
+			contentType string
+			body        string
+		}{
+			{
+				method: http.MethodGet, input: "http://localhost/docs", status: http.StatusOK,
+				finalURL: "http://localhost/docs/", contentType: "text/html; charset=utf-8", body: "<h1>docs</h1>",
+			},
+			{
+				method: http.MethodGet, input: "http://localhost/about?lang=en", status: http.StatusOK,
+				finalURL: "http://localhost/about?lang=en", contentType: "text/html; charset=utf-8", body: "<h1>about</h1>",
+			},
+			{
+				method: http.MethodGet, input: "http://localhost/docs/guide", status: http.StatusOK,
+				finalURL: "http://localhost/docs/guide", contentType: "text/plain; charset=utf-8", body: "plain guide",
+			},
+			{
+				method: http.MethodHead, input: "http://localhost/assets/style.css", status: http.StatusOK,
+				finalURL: "http://localhost/assets/style.css", contentType: "text/css; charset=utf-8",
+			},
+			{
+				method: http.MethodGet, input: "http://localhost/missing", status: http.StatusNotFound,
+				finalURL: "http://localhost/missing",
+			},
+			{
+				method: http.MethodPost, input: "http://localhost/", status: http.StatusMethodNotAllowed,
+				finalURL: "http://localhost/",
+			},
+		}
+
+		for _, test := range tests {
+			req, err := http.NewRequest(test.method, test.input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := fetcher.Fetch(req)
+			if err != nil {
+				t.Errorf("%s %s: got error %v", test.method, test.input, err)
+				continue
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != test.status {
+				t.Errorf("%s %s: got status %d, want %d", test.method, test.input, resp.StatusCode, test.status)
+			}
+			if got := resp.Request.URL.String(); got != test.finalURL {
+				t.Errorf("%s %s: got final URL %s, want %s", test.method, test.input, got, test.finalURL)
+			}
+			if got := resp.Header.Get("Content-Type"); got != test.contentType {
+				t.Errorf("%s %s: got Content-Type %q, want %q", test.method, test.input, got, test.contentType)
+			}
+			if string(body) != test.body {
+				t.Errorf("%s %s: got body %q, want %q", test.method, test.input, body, test.body)
+			}
+		}
+	})
+
+	t.Run("OtherHost", func(t *testing.T) {
+		for _, input := range []string{"https://localhost/", "http://example.com/"} {
+			req, err := http.NewRequest(http.MethodGet, input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fetcher.Fetch(req); !errors.Is(err, ErrNotLocal) {
+				t.Errorf("input: %s, got error %v, want %v", input, err, ErrNotLocal)
+			}
+		}
+	})
+
+	t.Run("ContextDone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fetcher.Fetch(req); !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package webcrawler
+
+import (
+	"io"
+
+	"github.com/0x00f00bar/webcrawlerGo/metrics"
+)
+
+// crawler metrics exported on metrics.DefaultRegistry
+var (
+	pagesFetchedTotal = metrics.NewCounter(
+		"webcrawlergo_pages_fetched_total",
+		"Number of URLs fetched by crawlers.",
+	)
+	fetchedBytesTotal = metrics.NewCounter(
+		"webcrawlergo_fetched_bytes_total",
+		"Number of response body bytes read by crawlers.",
+	)
+	responsesTotal = metrics.NewCounterVec(
+		"webcrawlergo_http_responses_total",
+		"Number of HTTP responses received by crawlers by status code.",
+		"code",
+	)
+	fetchDurationSeconds = metrics.NewHistogram(
+		"webcrawlergo_fetch_duration_seconds",
+		"Time taken to receive the response headers of a URL.",
+		nil,
+	)
+	queueDepth = metrics.NewGauge(
+		"webcrawlergo_queue_depth",
+		"Number of URLs waiting in the queue.",
+	)
+	requestsInFlight = metrics.NewGauge(
+		"webcrawlergo_requests_in_flight",
+		"Number of HTTP requests currently being made by crawlers.",
+	)
+	retriesTotal = metrics.NewCounter(
+		"webcrawlergo_retries_total",
+		"Number of URLs pushed back to the queue for retry.",
+	)
+	pagesSavedTotal = metrics.NewCounter(
+		"webcrawlergo_pages_saved_total",
+		"Number of pages saved to model.",
+	)
+)
+
+func init() {
+	metrics.MustRegister(
+		pagesFetchedTotal,
+		fetchedBytesTotal,
+		responsesTotal,
+		fetchDurationSeconds,
+		queueDepth,
+		requestsInFlight,
+		retriesTotal,
+		pagesSavedTotal,
+	)
+}
+
+// countingReader adds the number of bytes read from r to counter
+type countingReader struct {
+	r       io.Reader
+	counter *metrics.Counter
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.counter.Add(float64(n))
+	return n, err
+}
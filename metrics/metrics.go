@@ -0,0 +1,370 @@
+// Package metrics implements counters, gauges and histograms
+// exported in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets are the default histogram buckets (in seconds)
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector writes its samples in Prometheus text format
+type Collector interface {
+	// Name returns the metric name
+	Name() string
+	// WritePrometheus writes HELP, TYPE and sample lines to w
+	WritePrometheus(w io.Writer)
+}
+
+// desc holds the metadata common to all metrics
+type desc struct {
+	name string
+	help string
+	typ  string
+}
+
+func (d *desc) Name() string {
+	return d.name
+}
+
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.typ)
+}
+
+// atomicFloat is a float64 which can be updated concurrently
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) Store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) Add(v float64) {
+	for {
+		old := f.bits.Load()
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if f.bits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// Counter is a monotonically increasing value
+type Counter struct {
+	desc
+	value atomicFloat
+}
+
+// NewCounter returns pointer to new Counter
+func NewCounter(name, help string) *Counter {
+	return &Counter{desc: desc{name, help, "counter"}}
+}
+
+// Inc increments c by 1
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add adds v to c. Negative values are ignored.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.value.Add(v)
+}
+
+// Value returns the current value of c
+func (c *Counter) Value() float64 {
+	return c.value.Load()
+}
+
+func (c *Counter) WritePrometheus(w io.Writer) {
+	c.writeHeader(w)
+	writeSample(w, c.name, "", c.Value())
+}
+
+// Gauge is a value which can go up and down
+type Gauge struct {
+	desc
+	value atomicFloat
+}
+
+// NewGauge returns pointer to new Gauge
+func NewGauge(name, help string) *Gauge {
+	return &Gauge{desc: desc{name, help, "gauge"}}
+}
+
+// Set sets g to v
+func (g *Gauge) Set(v float64) {
+	g.value.Store(v)
+}
+
+// Add adds v to g
+func (g *Gauge) Add(v float64) {
+	g.value.Add(v)
+}
+
+// Inc increments g by 1
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+// Dec decrements g by 1
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+// Value returns the current value of g
+func (g *Gauge) Value() float64 {
+	return g.value.Load()
+}
+
+func (g *Gauge) WritePrometheus(w io.Writer) {
+	g.writeHeader(w)
+	writeSample(w, g.name, "", g.Value())
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	desc
+	buckets []float64
+	counts  []atomic.Uint64 // count per bucket, non cumulative; last is +Inf
+	sum     atomicFloat
+	count   atomic.Uint64
+}
+
+// NewHistogram returns pointer to new Histogram with buckets
+// as upper bounds. DefBuckets are used when buckets is nil.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	sorted := sortedBuckets(buckets)
+	return &Histogram{
+		desc:    desc{name, help, "histogram"},
+		buckets: sorted,
+		counts:  make([]atomic.Uint64, len(sorted)+1),
+	}
+}
+
+// Observe adds v to h
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.counts[i].Add(1)
+	h.sum.Add(v)
+	h.count.Add(1)
+}
+
+// Count returns the number of observations in h
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+func (h *Histogram) WritePrometheus(w io.Writer) {
+	h.writeHeader(w)
+	h.writeSamples(w, "")
+}
+
+func (h *Histogram) writeSamples(w io.Writer, labels string) {
+	var cumulative uint64
+	for i, le := range h.buckets {
+		cumulative += h.counts[i].Load()
+		writeSample(
+			w,
+			h.name+"_bucket",
+			joinLabels(labels, `le="`+formatFloat(le)+`"`),
+			float64(cumulative),
+		)
+	}
+	cumulative += h.counts[len(h.buckets)].Load()
+	writeSample(w, h.name+"_bucket", joinLabels(labels, `le="+Inf"`), float64(cumulative))
+	writeSample(w, h.name+"_sum", labels, h.sum.Load())
+	writeSample(w, h.name+"_count", labels, float64(h.count.Load()))
+}
+
+// CounterVec is a set of counters partitioned by label values
+type CounterVec struct {
+	desc
+	vec *vec[*Counter]
+}
+
+// NewCounterVec returns pointer to new CounterVec with labelNames
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{
+		desc: desc{name, help, "counter"},
+		vec: newVec(labelNames, func() *Counter {
+			return NewCounter(name, help)
+		}),
+	}
+}
+
+// WithLabelValues returns the counter for labelValues, creating it
+// when not present. Panics when the number of values does not match
+// the number of label names.
+func (cv *CounterVec) WithLabelValues(labelValues ...string) *Counter {
+	return cv.vec.get(labelValues)
+}
+
+func (cv *CounterVec) WritePrometheus(w io.Writer) {
+	cv.writeHeader(w)
+	cv.vec.each(func(labels string, c *Counter) {
+		writeSample(w, cv.name, labels, c.Value())
+	})
+}
+
+// HistogramVec is a set of histograms partitioned by label values
+type HistogramVec struct {
+	desc
+	vec *vec[*Histogram]
+}
+
+// NewHistogramVec returns pointer to new HistogramVec with labelNames.
+// DefBuckets are used when buckets is nil.
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return &HistogramVec{
+		desc: desc{name, help, "histogram"},
+		vec: newVec(labelNames, func() *Histogram {
+			return NewHistogram(name, help, buckets)
+		}),
+	}
+}
+
+// WithLabelValues returns the histogram for labelValues, creating it
+// when not present. Panics when the number of values does not match
+// the number of label names.
+func (hv *HistogramVec) WithLabelValues(labelValues ...string) *Histogram {
+	return hv.vec.get(labelValues)
+}
+
+func (hv *HistogramVec) WritePrometheus(w io.Writer) {
+	hv.writeHeader(w)
+	hv.vec.each(func(labels string, h *Histogram) {
+		h.writeSamples(w, labels)
+	})
+}
+
+// vec holds metrics of type T keyed by their formatted labels
+type vec[T any] struct {
+	labelNames []string
+	newMetric  func() T
+	mu         sync.RWMutex
+	metrics    map[string]T
+}
+
+func newVec[T any](labelNames []string, newMetric func() T) *vec[T] {
+	return &vec[T]{
+		labelNames: labelNames,
+		newMetric:  newMetric,
+		metrics:    map[string]T{},
+	}
+}
+
+func (v *vec[T]) get(labelValues []string) T {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf(
+			"metrics: expected %d label values, got %d",
+			len(v.labelNames),
+			len(labelValues),
+		))
+	}
+
+	pairs := make([]string, len(labelValues))
+	for i, value := range labelValues {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, v.labelNames[i], escapeLabelValue(value))
+	}
+	key := strings.Join(pairs, ",")
+
+	v.mu.RLock()
+	m, ok := v.metrics[key]
+	v.mu.RUnlock()
+	if ok {
+		return m
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if m, ok = v.metrics[key]; !ok {
+		m = v.newMetric()
+		v.metrics[key] = m
+	}
+	return m
+}
+
+// each calls fn for every metric in v sorted by labels
+func (v *vec[T]) each(fn func(labels string, m T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.metrics))
+	for key := range v.metrics {
+		keys = append(keys, key)
+	}
+	v.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		v.mu.RLock()
+		m := v.metrics[key]
+		v.mu.RUnlock()
+		fn(key, m)
+	}
+}
+
+func writeSample(w io.Writer, name, labels string, value float64) {
+	if labels != "" {
+		fmt.Fprintf(w, "%s{%s} %s\n", name, labels, formatFloat(value))
+		return
+	}
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(value))
+}
+
+func joinLabels(labels, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedBuckets(buckets []float64) []float64 {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelReplacer.Replace(s)
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("WritePrometheus", func(t *testing.T) {
+		r := NewRegistry()
+
+		c := NewCounter("test_total", "Test counter.")
+		g := NewGauge("test_gauge", "Test gauge.")
+		cv := NewCounterVec("test_codes_total", "Test counter vec.", "code")
+		h := NewHistogram("test_seconds", "Test histogram.", []float64{1, 0.5})
+		r.MustRegister(c, g, cv, h)
+
+		c.Add(2)
+		c.Add(-1) // ignored
+		g.Set(5)
+		g.Dec()
+		cv.WithLabelValues("200").Inc()
+		cv.WithLabelValues(`a"b`).Inc()
+		h.Observe(0.2)
+		h.Observe(0.7)
+		h.Observe(3)
+
+		var buf bytes.Buffer
+		r.WritePrometheus(&buf)
+
+		want := `# HELP test_codes_total Test counter vec.
+# TYPE test_codes_total counter
+test_codes_total{code="200"} 1
+test_codes_total{code="a\"b"} 1
+# HELP test_gauge Test gauge.
+# TYPE test_gauge gauge
+test_gauge 4
+# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.5"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 3.9
+test_seconds_count 3
+# HELP test_total Test counter.
+# TYPE test_total counter
+test_total 2
+`
+		if got := buf.String(); got != want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		r := NewRegistry()
+
+		if err := r.Register(NewCounter("dup_total", "")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err := r.Register(NewGauge("dup_total", ""))
+		if err == nil || !strings.Contains(err.Error(), "duplicate") {
+			t.Errorf("expected duplicate error, got %v", err)
+		}
+	})
+}
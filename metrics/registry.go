@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// DefaultRegistry is the registry used by the package level
+// MustRegister and Handler
+var DefaultRegistry = NewRegistry()
+
+// Registry holds collectors by their metric name
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+// NewRegistry returns pointer to new Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: map[string]Collector{},
+	}
+}
+
+// Register adds c to r. Returns error if a collector
+// with the same name is already registered.
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.collectors[c.Name()]; ok {
+		return fmt.Errorf("metrics: duplicate metric name '%s'", c.Name())
+	}
+	r.collectors[c.Name()] = c
+	return nil
+}
+
+// MustRegister registers collectors to r and panics on error
+func (r *Registry) MustRegister(collectors ...Collector) {
+	for _, c := range collectors {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// WritePrometheus writes all the collectors sorted
+// by name to w in Prometheus text format
+func (r *Registry) WritePrometheus(w io.Writer) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		r.mu.RLock()
+		c := r.collectors[name]
+		r.mu.RUnlock()
+		c.WritePrometheus(w)
+	}
+}
+
+// Handler returns [http.Handler] which serves the collectors of r
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		r.WritePrometheus(&buf)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
+
+// MustRegister registers collectors to DefaultRegistry and panics on error
+func MustRegister(collectors ...Collector) {
+	DefaultRegistry.MustRegister(collectors...)
+}
+
+// Handler returns [http.Handler] which serves DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
@@ -0,0 +1,225 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var DeliveryColumns = []string{
+	"id", "webhook", "event", "url", "run_id", "status",
+	"attempts", "response_status", "created_at", "finished_at",
+}
+
+// Status of a webhook delivery
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+	DeliveryDropped   = "dropped" // not sent as the queue of the webhook was full
+)
+
+type DeliveryFilter struct {
+	Webhook string `json:"webhook"`
+	Event   string `json:"event"`
+	Status  string `json:"status"`
+}
+
+// Queries related to webhook_deliveries table
+const (
+	QuerySelectDelivery = `SELECT id, webhook, event, url, COALESCE(run_id, 0), payload, status,
+	attempts, response_status, error, created_at, finished_at FROM webhook_deliveries `
+	QueryGetDeliveryById = QuerySelectDelivery + "WHERE id = __ARG__"
+	QueryGetAllDelivery  = QuerySelectDelivery + "WHERE webhook LIKE __ARG__ "
+	QueryInsertDelivery  = `
+	INSERT INTO webhook_deliveries (webhook, event, url, run_id, payload, status, created_at)
+	VALUES (__ARG__, __ARG__, __ARG__, __ARG__, __ARG__, __ARG__, __ARG__)
+	RETURNING id`
+	QueryUpdateDelivery = `
+	UPDATE webhook_deliveries
+	SET status = __ARG__, attempts = __ARG__, response_status = __ARG__, error = __ARG__, finished_at = __ARG__
+	WHERE id = __ARG__`
+)
+
+// Delivery type holds a webhook notification and
+// the result of sending it
+type Delivery struct {
+	ID             uint       `json:"id"`
+	Webhook        string     `json:"webhook"` // name of the webhook
+	Event          string     `json:"event"`
+	URL            string     `json:"url"`
+	RunID          uint       `json:"run_id,omitempty"`
+	Payload        string     `json:"payload,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status"` // HTTP status of the last attempt; 0 when no response
+	Error          string     `json:"error"`           // error of the last attempt
+	CreatedAt      time.Time  `json:"created_at"`
+	FinishedAt     *time.Time `json:"finished_at"` // nil while pending
+}
+
+// NewDelivery returns new pending Delivery with CreatedAt set to time.Now
+func NewDelivery(webhook, event, url string, runID uint, payload string) *Delivery {
+	return &Delivery{
+		Webhook:   webhook,
+		Event:     event,
+		URL:       url,
+		RunID:     runID,
+		Payload:   payload,
+		Status:    DeliveryPending,
+		CreatedAt: time.Now(),
+	}
+}
+
+// scanDelivery scans a row selected with QuerySelectDelivery
+func scanDelivery(scan func(dest ...any) error) (*Delivery, error) {
+	var d Delivery
+	var finishedAt sql.NullTime
+
+	err := scan(
+		&d.ID,
+		&d.Webhook,
+		&d.Event,
+		&d.URL,
+		&d.RunID,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseStatus,
+		&d.Error,
+		&d.CreatedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		d.FinishedAt = &finishedAt.Time
+	}
+	return &d, nil
+}
+
+// DeliveryGetById fetches a row from webhook_deliveries table by id
+func DeliveryGetById(ctx context.Context, id int, query string, db *sql.DB) (*Delivery, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	d, err := scanDelivery(db.QueryRowContext(ctx, query, id).Scan)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// DeliveryGetAll fetches all rows from webhook_deliveries table as per filters
+func DeliveryGetAll(
+	ctx context.Context,
+	df DeliveryFilter,
+	cf CommonFilters,
+	query string,
+	db *sql.DB,
+	queryTransformFn func(string) string,
+) ([]*Delivery, error) {
+	args := []any{"%" + df.Webhook + "%"}
+
+	if df.Event != "" {
+		query += " AND event = __ARG__"
+		args = append(args, df.Event)
+	}
+	if df.Status != "" {
+		query += " AND status = __ARG__"
+		args = append(args, df.Status)
+	}
+
+	orderBy, err := GetOrderByQuery(&cf)
+	if err != nil {
+		return nil, err
+	}
+	query += orderBy
+
+	query += " LIMIT __ARG__ OFFSET __ARG__"
+	args = append(args, cf.Limit(), cf.Offset())
+
+	query = queryTransformFn(query)
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*Delivery{}
+
+	for rows.Next() {
+		d, err := scanDelivery(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// DeliveryInsert writes a delivery to webhook_deliveries table
+func DeliveryInsert(ctx context.Context, m *Delivery, query string, db *sql.DB) error {
+	defer observeDBWrite("insert_delivery", time.Now())
+
+	args := []any{m.Webhook, m.Event, m.URL, nullID(m.RunID), m.Payload, m.Status, m.CreatedAt}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	return db.QueryRowContext(ctx, query, args...).Scan(&m.ID)
+}
+
+// DeliveryUpdate writes the status and the result
+// of the last attempt of a delivery
+func DeliveryUpdate(ctx context.Context, m *Delivery, query string, db *sql.DB) error {
+	defer observeDBWrite("update_delivery", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	args := []any{
+		m.Status,
+		m.Attempts,
+		m.ResponseStatus,
+		m.Error,
+		m.FinishedAt,
+		m.ID,
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
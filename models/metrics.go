@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/0x00f00bar/webcrawlerGo/metrics"
+)
+
+var dbWriteDurationSeconds = metrics.NewHistogramVec(
+	"webcrawlergo_db_write_duration_seconds",
+	"Time taken by model write queries by operation.",
+	nil,
+	"op",
+)
+
+func init() {
+	metrics.MustRegister(dbWriteDurationSeconds)
+}
+
+// observeDBWrite records the time since start for write operation op
+func observeDBWrite(op string, start time.Time) {
+	dbWriteDurationSeconds.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
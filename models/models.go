@@ -21,41 +21,76 @@ var DefaultDBTimeout = 5 * time.Second
 // for query arguments
 const QueryArgStr = "__ARG__"
 
-// Models embeds URLModel and PageModel interface
+// Models embeds URLModel, PageModel, RunModel, DeliveryModel, StatsModel and RobotsModel interface
 type Models struct {
-	URLs  URLModel
-	Pages PageModel
+	URLs       URLModel
+	Pages      PageModel
+	Runs       RunModel
+	Deliveries DeliveryModel
+	Stats      StatsModel
+	Robots     RobotsModel
 }
 
 type URLModel interface {
-	GetAll(URLFilter, CommonFilters) ([]*URL, error)
-	GetAllMonitored(CommonFilters) ([]*URL, error)
-	GetById(id int) (*URL, error)
-	GetByURL(url string) (*URL, error)
-	Insert(*URL) error
-	Update(*URL) error
-	Delete(id int) error
+	GetAll(context.Context, URLFilter, CommonFilters) ([]*URL, error)
+	GetAllMonitored(context.Context, CommonFilters) ([]*URL, error)
+	GetById(ctx context.Context, id int) (*URL, error)
+	GetByURL(ctx context.Context, url string) (*URL, error)
+	Insert(context.Context, *URL) error
+	Update(context.Context, *URL) error
+	Delete(ctx context.Context, id int) error
 }
 
 type PageModel interface {
-	GetById(id int) (*Page, error)
-	GetAllByURL(urlId uint, cf CommonFilters) ([]*Page, error)
+	GetById(ctx context.Context, id int) (*Page, error)
+	GetAllByURL(ctx context.Context, urlId uint, pf PageFilter, cf CommonFilters) ([]*Page, error)
+	GetLatestByURL(ctx context.Context, urlId uint, variant string) (*Page, error)
+	GetAllByRun(ctx context.Context, runId uint, pf PageFilter, cf CommonFilters) ([]*Page, error)
 	GetLatestPageCount(
 		ctx context.Context,
 		baseURL *url.URL,
 		markedURL string,
+		variant string,
 		cutoffDate time.Time,
 	) (int, error)
 	GetLatestPagesPaginated(
 		ctx context.Context,
 		baseURL *url.URL,
 		markedURL string,
+		variant string,
 		cutoffDate time.Time,
 		pageNum int,
 		pageSize int,
 	) ([]*PageContent, error)
-	Insert(*Page) error
+	Insert(context.Context, *Page) error
 	// Update method is not required, yet
 	// Update(*Page) error
-	Delete(id int) error
+	Delete(ctx context.Context, id int) error
+}
+
+type RunModel interface {
+	GetAll(context.Context, RunFilter, CommonFilters) ([]*CrawlRun, error)
+	GetById(ctx context.Context, id int) (*CrawlRun, error)
+	Insert(context.Context, *CrawlRun) error
+	Update(context.Context, *CrawlRun) error
+}
+
+type DeliveryModel interface {
+	GetAll(context.Context, DeliveryFilter, CommonFilters) ([]*Delivery, error)
+	GetById(ctx context.Context, id int) (*Delivery, error)
+	Insert(context.Context, *Delivery) error
+	Update(context.Context, *Delivery) error
+}
+
+type StatsModel interface {
+	Get(ctx context.Context, baseURL string) (*Stats, error)
+}
+
+type RobotsModel interface {
+	GetAll(context.Context, RobotsFilter, CommonFilters) ([]*RobotsTxt, error)
+	GetById(ctx context.Context, id int) (*RobotsTxt, error)
+	GetLatest(ctx context.Context, host string, reachable bool) (*RobotsTxt, error)
+	GetPrevious(context.Context, *RobotsTxt) (*RobotsTxt, error)
+	Insert(context.Context, *RobotsTxt) error
+	Update(context.Context, *RobotsTxt) error
 }
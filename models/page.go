@@ -8,35 +8,49 @@ import (
 	"time"
 )
 
-var PageColumns = []string{"id", "url_id", "added_at", "content"}
+var PageColumns = []string{"id", "url_id", "added_at", "content", "run_id", "variant"}
+
+// AllVariants selects the pages of all fetch variants
+// in GetLatestPageCount and GetLatestPagesPaginated
+const AllVariants = "*"
+
+type PageFilter struct {
+	Variant        string `json:"variant"` // name of the fetch variant; empty for the default fetch
+	VariantPresent bool   `json:"-"`
+}
 
 // Queries related to pages table
 const (
-	QuerySelectPage          = "SELECT id, url_id, added_at, content FROM pages"
+	QuerySelectPage          = "SELECT id, url_id, added_at, content, COALESCE(run_id, 0), variant FROM pages"
 	QueryGetPageById         = QuerySelectPage + " WHERE id = __ARG__"
-	QueryGetAllPageByURL     = "SELECT id, url_id, added_at FROM pages WHERE url_id = __ARG__"
-	QueryInsertPage          = `INSERT INTO pages (url_id, content) VALUES (__ARG__, __ARG__) RETURNING id, added_at`
+	QueryGetLatestPageByURL  = QuerySelectPage + " WHERE url_id = __ARG__ AND variant = __ARG__ ORDER BY added_at DESC, id DESC LIMIT 1"
+	QuerySelectPageInfo      = "SELECT id, url_id, added_at, COALESCE(run_id, 0), variant FROM pages"
+	QueryGetAllPageByURL     = QuerySelectPageInfo + " WHERE url_id = __ARG__"
+	QueryGetAllPageByRun     = QuerySelectPageInfo + " WHERE run_id = __ARG__"
+	QueryInsertPage          = `INSERT INTO pages (url_id, content, run_id, variant) VALUES (__ARG__, __ARG__, __ARG__, __ARG__) RETURNING id, added_at`
 	QueryDeletePage          = `DELETE from pages WHERE id = __ARG__`
 	QueryGetLatestPagesCount = `WITH LatestPages AS (
 		SELECT u.url, p.id, p.added_at,
-			ROW_NUMBER() OVER (PARTITION BY u.id ORDER BY p.added_at DESC) AS rn
+			ROW_NUMBER() OVER (PARTITION BY u.id, p.variant ORDER BY p.added_at DESC) AS rn
 		FROM pages p
 		JOIN urls u ON p.url_id = u.id
 		WHERE u.is_monitored=true AND u.url LIKE __ARG__ || '%'
 		AND u.url LIKE '%' || __ARG__ || '%'
 		AND p.added_at <= __ARG__
+		AND (__ARG__ = '*' OR p.variant = __ARG__)
 	)
 	SELECT COUNT(*)
 	FROM LatestPages
 	WHERE rn = 1`
 	QueryGetLatestPagesPaginated = `WITH LatestPages AS (
-		SELECT u.url, p.added_at, p.content,
-			ROW_NUMBER() OVER (PARTITION BY u.id ORDER BY p.added_at DESC) AS rn
+		SELECT u.url, p.variant, p.added_at, p.content,
+			ROW_NUMBER() OVER (PARTITION BY u.id, p.variant ORDER BY p.added_at DESC) AS rn
 		FROM pages p
 		JOIN urls u ON p.url_id = u.id
 		WHERE u.is_monitored=true AND u.url LIKE __ARG__ || '%'
 		AND u.url LIKE '%' || __ARG__ || '%'
 		AND p.added_at <= __ARG__
+		AND (__ARG__ = '*' OR p.variant = __ARG__)
 	)
 	SELECT *
 	FROM LatestPages
@@ -51,12 +65,15 @@ type Page struct {
 	URLID   uint      `json:"url_id"`
 	AddedAt time.Time `json:"added_at"`
 	Content string    `json:"content,omitempty"`
+	RunID   uint      `json:"run_id,omitempty"`  // crawl run which saved the page
+	Variant string    `json:"variant,omitempty"` // fetch variant of the page; empty for the default fetch
 }
 
 // PageContent type contains feilds required for
 // saving page contents to disk
 type PageContent struct {
 	URL     string
+	Variant string
 	AddedAt time.Time
 	Content string
 	rn      int // row number from query
@@ -72,14 +89,14 @@ func NewPage(urlId uint, content string) *Page {
 }
 
 // PageGetById fetches a row from pages table by id
-func PageGetById(id int, query string, db *sql.DB) (*Page, error) {
+func PageGetById(ctx context.Context, id int, query string, db *sql.DB) (*Page, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 
 	var page Page
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	err := db.QueryRowContext(ctx, query, id).Scan(
@@ -87,6 +104,40 @@ func PageGetById(id int, query string, db *sql.DB) (*Page, error) {
 		&page.URLID,
 		&page.AddedAt,
 		&page.Content,
+		&page.RunID,
+		&page.Variant,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &page, nil
+}
+
+// PageGetLatestByURL fetches the latest saved page of urlID
+// fetched with variant, with its content
+func PageGetLatestByURL(ctx context.Context, urlID uint, variant string, query string, db *sql.DB) (*Page, error) {
+	if urlID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	var page Page
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	err := db.QueryRowContext(ctx, query, urlID, variant).Scan(
+		&page.ID,
+		&page.URLID,
+		&page.AddedAt,
+		&page.Content,
+		&page.RunID,
+		&page.Variant,
 	)
 	if err != nil {
 		switch {
@@ -100,20 +151,55 @@ func PageGetById(id int, query string, db *sql.DB) (*Page, error) {
 	return &page, nil
 }
 
-// PageGetAllByURL fetches all rows from pages table by urlId
-// and order by orderBy; does not include page content
+// PageGetAllByURL fetches all rows from pages table by urlId, filtered
+// by pf and order by orderBy; does not include page content
 func PageGetAllByURL(
+	ctx context.Context,
 	urlID uint,
+	pf PageFilter,
 	cf CommonFilters,
 	query string,
 	db *sql.DB,
 	queryTransformFn func(string) string,
 ) ([]*Page, error) {
-	if urlID < 1 {
+	return pageGetAllBy(ctx, urlID, pf, cf, query, db, queryTransformFn)
+}
+
+// PageGetAllByRun fetches all rows from pages table saved by crawl run
+// runId, filtered by pf and order by orderBy; does not include page content
+func PageGetAllByRun(
+	ctx context.Context,
+	runID uint,
+	pf PageFilter,
+	cf CommonFilters,
+	query string,
+	db *sql.DB,
+	queryTransformFn func(string) string,
+) ([]*Page, error) {
+	return pageGetAllBy(ctx, runID, pf, cf, query, db, queryTransformFn)
+}
+
+// pageGetAllBy fetches all rows from pages table where the
+// column in query's WHERE clause equals id
+func pageGetAllBy(
+	ctx context.Context,
+	id uint,
+	pf PageFilter,
+	cf CommonFilters,
+	query string,
+	db *sql.DB,
+	queryTransformFn func(string) string,
+) ([]*Page, error) {
+	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 
-	args := []any{urlID}
+	args := []any{id}
+
+	if pf.VariantPresent {
+		query += " AND variant = __ARG__"
+		args = append(args, pf.Variant)
+	}
 
 	orderBy, err := GetOrderByQuery(&cf)
 	if err != nil {
@@ -126,7 +212,7 @@ func PageGetAllByURL(
 
 	query = queryTransformFn(query)
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	rows, err := db.QueryContext(ctx, query, args...)
@@ -144,6 +230,8 @@ func PageGetAllByURL(
 			&page.ID,
 			&page.URLID,
 			&page.AddedAt,
+			&page.RunID,
+			&page.Variant,
 		)
 		if err != nil {
 			return nil, err
@@ -160,11 +248,12 @@ func PageGetAllByURL(
 }
 
 // PageInsert writes a page to pages table
-func PageInsert(m *Page, query string, db *sql.DB) error {
+func PageInsert(ctx context.Context, m *Page, query string, db *sql.DB) error {
+	defer observeDBWrite("insert_page", time.Now())
 
-	args := []interface{}{m.URLID, m.Content}
+	args := []interface{}{m.URLID, m.Content, nullID(m.RunID), m.Variant}
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	return db.QueryRowContext(ctx, query, args...).Scan(&m.ID, &m.AddedAt)
@@ -175,12 +264,14 @@ func PageInsert(m *Page, query string, db *sql.DB) error {
 // }
 
 // PageDelete delete page row by id
-func PageDelete(id int, query string, db *sql.DB) error {
+func PageDelete(ctx context.Context, id int, query string, db *sql.DB) error {
+	defer observeDBWrite("delete_page", time.Now())
+
 	if id < 1 {
 		return ErrRecordNotFound
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	result, err := db.ExecContext(ctx, query, id)
@@ -200,12 +291,14 @@ func PageDelete(id int, query string, db *sql.DB) error {
 	return nil
 }
 
-// PageGetLatestPageCount returns count of the latest pages
-// filtered by baseurl, markedURL and by cutoff date
+// PageGetLatestPageCount returns count of the latest pages filtered
+// by baseurl, markedURL, variant and by cutoff date. Pages of every
+// variant are counted when variant is AllVariants.
 func PageGetLatestPageCount(
 	ctx context.Context,
 	baseurl *url.URL,
 	markedURL string,
+	variant string,
 	cutoffDate time.Time,
 	query string,
 	db *sql.DB,
@@ -215,7 +308,7 @@ func PageGetLatestPageCount(
 
 	var recordCount int
 
-	commonArgs := []interface{}{baseurl.String(), markedURL, cutoffDate}
+	commonArgs := []interface{}{baseurl.String(), markedURL, cutoffDate, variant, variant}
 	// get total records for the marked url
 	err := db.QueryRowContext(timeOutCtx, query, commonArgs...).
 		Scan(&recordCount)
@@ -226,11 +319,13 @@ func PageGetLatestPageCount(
 }
 
 // PageGetLatestPagesPaginated returns PageContent of the latest pages
-// filtered by baseurl, markedURL and by cutoff date
+// filtered by baseurl, markedURL, variant and by cutoff date. The latest
+// page of every variant is returned when variant is AllVariants.
 func PageGetLatestPagesPaginated(
 	ctx context.Context,
 	baseurl *url.URL,
 	markedURL string,
+	variant string,
 	cutoffDate time.Time,
 	pageNum int,
 	pageSize int,
@@ -246,6 +341,8 @@ func PageGetLatestPagesPaginated(
 		baseurl.String(),
 		markedURL,
 		cutoffDate,
+		variant,
+		variant,
 		pageSize,
 		pageNum,
 		pageSize,
@@ -262,6 +359,7 @@ func PageGetLatestPagesPaginated(
 
 		err = rows.Scan(
 			&pageContent.URL,
+			&pageContent.Variant,
 			&pageContent.AddedAt,
 			&pageContent.Content,
 			&pageContent.rn,
@@ -0,0 +1,50 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// deliveryDB is used to implement DeliveryModel interface
+type deliveryDB struct {
+	DB *sql.DB
+}
+
+// newDeliveryDB returns *deliveryDB which implements DeliveryModel interface
+func newDeliveryDB(db *sql.DB) *deliveryDB {
+	return &deliveryDB{
+		DB: db,
+	}
+}
+
+// GetById fetches a row from webhook_deliveries table by id
+func (r deliveryDB) GetById(ctx context.Context, id int) (*models.Delivery, error) {
+	query := makePgSQLQuery(models.QueryGetDeliveryById)
+
+	return models.DeliveryGetById(ctx, id, query, r.DB)
+}
+
+// GetAll fetches all rows from webhook_deliveries table in orderBy order
+func (r deliveryDB) GetAll(
+	ctx context.Context,
+	df models.DeliveryFilter,
+	cf models.CommonFilters,
+) ([]*models.Delivery, error) {
+	return models.DeliveryGetAll(ctx, df, cf, models.QueryGetAllDelivery, r.DB, makePgSQLQuery)
+}
+
+// Insert writes a delivery to webhook_deliveries table
+func (r deliveryDB) Insert(ctx context.Context, d *models.Delivery) error {
+	query := makePgSQLQuery(models.QueryInsertDelivery)
+
+	return models.DeliveryInsert(ctx, d, query, r.DB)
+}
+
+// Update writes the status and the result of the last attempt of a delivery
+func (r deliveryDB) Update(ctx context.Context, d *models.Delivery) error {
+	query := makePgSQLQuery(models.QueryUpdateDelivery)
+
+	return models.DeliveryUpdate(ctx, d, query, r.DB)
+}
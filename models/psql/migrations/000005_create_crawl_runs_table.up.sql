@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS crawl_runs (
+    id bigserial PRIMARY KEY,
+    started_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    finished_at timestamp(0) with time zone DEFAULT NULL,
+    base_url text NOT NULL,
+    options jsonb NOT NULL DEFAULT '{}',
+    exit_reason text NOT NULL DEFAULT 'running',
+    urls_crawled integer NOT NULL DEFAULT 0,
+    urls_discovered integer NOT NULL DEFAULT 0,
+    pages_saved integer NOT NULL DEFAULT 0,
+    errors integer NOT NULL DEFAULT 0,
+    dead_urls integer NOT NULL DEFAULT 0
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_page_run_id;
+
+ALTER TABLE urls
+DROP COLUMN IF EXISTS last_run_id;
+
+ALTER TABLE pages
+DROP COLUMN IF EXISTS run_id;
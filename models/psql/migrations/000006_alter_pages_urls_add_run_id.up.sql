@@ -0,0 +1,7 @@
+ALTER TABLE pages
+ADD COLUMN IF NOT EXISTS run_id bigint DEFAULT NULL REFERENCES crawl_runs ON DELETE SET NULL;
+
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS last_run_id bigint DEFAULT NULL REFERENCES crawl_runs ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_page_run_id ON pages(run_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    webhook text NOT NULL,
+    event text NOT NULL,
+    url text NOT NULL DEFAULT '',
+    run_id bigint DEFAULT NULL REFERENCES crawl_runs ON DELETE SET NULL,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    response_status integer NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    finished_at timestamp(0) with time zone DEFAULT NULL
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS robots_txt (
+    id bigserial PRIMARY KEY,
+    host text NOT NULL,
+    status_code integer NOT NULL DEFAULT 0,
+    content text NOT NULL DEFAULT '',
+    error text NOT NULL DEFAULT '',
+    fetched_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    checked_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_robots_txt_host ON robots_txt(host);
@@ -0,0 +1,2 @@
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS skip_reason text NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE pages
+DROP COLUMN IF EXISTS variant;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE pages
+ADD COLUMN IF NOT EXISTS variant text NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS link_source text NOT NULL DEFAULT '';
\ No newline at end of file
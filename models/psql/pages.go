@@ -22,23 +22,46 @@ func newPageDB(db *sql.DB) *pageDB {
 }
 
 // GetById fetches a row from pages table by id
-func (p pageDB) GetById(id int) (*models.Page, error) {
+func (p pageDB) GetById(ctx context.Context, id int) (*models.Page, error) {
 	query := makePgSQLQuery(models.QueryGetPageById)
 
-	return models.PageGetById(id, query, p.DB)
+	return models.PageGetById(ctx, id, query, p.DB)
 }
 
-// GetAllByURL fetches a row from pages table by urlId
-// and order by orderBy
-func (p pageDB) GetAllByURL(urlID uint, cf models.CommonFilters) ([]*models.Page, error) {
-	return models.PageGetAllByURL(urlID, cf, models.QueryGetAllPageByURL, p.DB, makePgSQLQuery)
+// GetLatestByURL fetches the latest row from pages table by urlId and variant
+func (p pageDB) GetLatestByURL(ctx context.Context, urlID uint, variant string) (*models.Page, error) {
+	query := makePgSQLQuery(models.QueryGetLatestPageByURL)
+
+	return models.PageGetLatestByURL(ctx, urlID, variant, query, p.DB)
+}
+
+// GetAllByURL fetches a row from pages table by urlId,
+// filtered by pf and order by orderBy
+func (p pageDB) GetAllByURL(
+	ctx context.Context,
+	urlID uint,
+	pf models.PageFilter,
+	cf models.CommonFilters,
+) ([]*models.Page, error) {
+	return models.PageGetAllByURL(ctx, urlID, pf, cf, models.QueryGetAllPageByURL, p.DB, makePgSQLQuery)
+}
+
+// GetAllByRun fetches rows from pages table saved by
+// crawl run runID, filtered by pf and order by orderBy
+func (p pageDB) GetAllByRun(
+	ctx context.Context,
+	runID uint,
+	pf models.PageFilter,
+	cf models.CommonFilters,
+) ([]*models.Page, error) {
+	return models.PageGetAllByRun(ctx, runID, pf, cf, models.QueryGetAllPageByRun, p.DB, makePgSQLQuery)
 }
 
 // Insert writes a page to pages table
-func (p pageDB) Insert(m *models.Page) error {
+func (p pageDB) Insert(ctx context.Context, m *models.Page) error {
 	query := makePgSQLQuery(models.QueryInsertPage)
 
-	return models.PageInsert(m, query, p.DB)
+	return models.PageInsert(ctx, m, query, p.DB)
 }
 
 // Update not required on pages table
@@ -46,31 +69,33 @@ func (p pageDB) Insert(m *models.Page) error {
 // }
 
 // Delete page row by id
-func (p pageDB) Delete(id int) error {
+func (p pageDB) Delete(ctx context.Context, id int) error {
 	query := makePgSQLQuery(models.QueryDeletePage)
 
-	return models.PageDelete(id, query, p.DB)
+	return models.PageDelete(ctx, id, query, p.DB)
 }
 
 // GetLatestPageCount returns the number of latest pages
-// filtered by baseurl, markedURL and by cutoff date
+// filtered by baseurl, markedURL, variant and by cutoff date
 func (p pageDB) GetLatestPageCount(
 	ctx context.Context,
 	baseURL *url.URL,
 	markedURL string,
+	variant string,
 	cutoffDate time.Time,
 ) (int, error) {
 	query := makePgSQLQuery(models.QueryGetLatestPagesCount)
 
-	return models.PageGetLatestPageCount(ctx, baseURL, markedURL, cutoffDate, query, p.DB)
+	return models.PageGetLatestPageCount(ctx, baseURL, markedURL, variant, cutoffDate, query, p.DB)
 }
 
 // GetLatestPagesPaginated returns PageContent of latest pages
-// filtered by baseurl, markedURL and by cutoff date
+// filtered by baseurl, markedURL, variant and by cutoff date
 func (p pageDB) GetLatestPagesPaginated(
 	ctx context.Context,
 	baseURL *url.URL,
 	markedURL string,
+	variant string,
 	cutoffDate time.Time,
 	pageNum int,
 	pageSize int,
@@ -81,6 +106,7 @@ func (p pageDB) GetLatestPagesPaginated(
 		ctx,
 		baseURL,
 		markedURL,
+		variant,
 		cutoffDate,
 		pageNum,
 		pageSize,
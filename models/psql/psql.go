@@ -13,15 +13,23 @@ import (
 const DriverNamePgSQL = "postgres"
 
 type PsqlDB struct {
-	URLModel  *urlDB
-	PageModel *pageDB
+	URLModel      *urlDB
+	PageModel     *pageDB
+	RunModel      *runDB
+	DeliveryModel *deliveryDB
+	StatsModel    *statsDB
+	RobotsModel   *robotsDB
 }
 
 // NewPsqlDB returns new instance of PostgreSQL with URL and Pages models
 func NewPsqlDB(db *sql.DB) *PsqlDB {
 	return &PsqlDB{
-		URLModel:  newUrlDB(db),
-		PageModel: newPageDB(db),
+		URLModel:      newUrlDB(db),
+		PageModel:     newPageDB(db),
+		RunModel:      newRunDB(db),
+		DeliveryModel: newDeliveryDB(db),
+		StatsModel:    newStatsDB(db),
+		RobotsModel:   newRobotsDB(db),
 	}
 }
 
@@ -58,12 +66,71 @@ func (pq PsqlDB) InitDatabase(ctx context.Context, db *sql.DB) error {
 	createPagesURLIDIndex := `CREATE INDEX IF NOT EXISTS idx_page_url_id ON pages(url_id);`
 	alterURLAddIsAlive := `ALTER TABLE urls
 ADD COLUMN IF NOT EXISTS is_alive BOOLEAN DEFAULT TRUE;`
+	createCrawlRunsTableQuery := `CREATE TABLE IF NOT EXISTS crawl_runs (
+    id bigserial PRIMARY KEY,
+    started_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    finished_at timestamp(0) with time zone DEFAULT NULL,
+    base_url text NOT NULL,
+    options jsonb NOT NULL DEFAULT '{}',
+    exit_reason text NOT NULL DEFAULT 'running',
+    urls_crawled integer NOT NULL DEFAULT 0,
+    urls_discovered integer NOT NULL DEFAULT 0,
+    pages_saved integer NOT NULL DEFAULT 0,
+    errors integer NOT NULL DEFAULT 0,
+    dead_urls integer NOT NULL DEFAULT 0
+	);`
+	alterAddRunID := `ALTER TABLE pages
+ADD COLUMN IF NOT EXISTS run_id bigint DEFAULT NULL REFERENCES crawl_runs ON DELETE SET NULL;
+
+ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS last_run_id bigint DEFAULT NULL REFERENCES crawl_runs ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_page_run_id ON pages(run_id);`
+	createDeliveriesTableQuery := `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    webhook text NOT NULL,
+    event text NOT NULL,
+    url text NOT NULL DEFAULT '',
+    run_id bigint DEFAULT NULL REFERENCES crawl_runs ON DELETE SET NULL,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    response_status integer NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    finished_at timestamp(0) with time zone DEFAULT NULL
+	);`
+
+	createRobotsTableQuery := `CREATE TABLE IF NOT EXISTS robots_txt (
+    id bigserial PRIMARY KEY,
+    host text NOT NULL,
+    status_code integer NOT NULL DEFAULT 0,
+    content text NOT NULL DEFAULT '',
+    error text NOT NULL DEFAULT '',
+    fetched_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    checked_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+	);
+
+CREATE INDEX IF NOT EXISTS idx_robots_txt_host ON robots_txt(host);`
+	alterURLAddSkipReason := `ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS skip_reason text NOT NULL DEFAULT '';`
+	alterPagesAddVariant := `ALTER TABLE pages
+ADD COLUMN IF NOT EXISTS variant text NOT NULL DEFAULT '';`
+	alterURLAddLinkSource := `ALTER TABLE urls
+ADD COLUMN IF NOT EXISTS link_source text NOT NULL DEFAULT '';`
 
 	queries := []string{
 		createURLTableQuery,
 		createPagesTableQuery,
 		createPagesURLIDIndex,
 		alterURLAddIsAlive,
+		createCrawlRunsTableQuery,
+		alterAddRunID,
+		createDeliveriesTableQuery,
+		createRobotsTableQuery,
+		alterURLAddSkipReason,
+		alterPagesAddVariant,
+		alterURLAddLinkSource,
 	}
 
 	for _, query := range queries {
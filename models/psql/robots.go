@@ -0,0 +1,68 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// robotsDB is used to implement RobotsModel interface
+type robotsDB struct {
+	DB *sql.DB
+}
+
+// newRobotsDB returns *robotsDB which implements RobotsModel interface
+func newRobotsDB(db *sql.DB) *robotsDB {
+	return &robotsDB{
+		DB: db,
+	}
+}
+
+// GetById fetches a row from robots_txt table by id
+func (r robotsDB) GetById(ctx context.Context, id int) (*models.RobotsTxt, error) {
+	query := makePgSQLQuery(models.QueryGetRobotsById)
+
+	return models.RobotsGetById(ctx, id, query, r.DB)
+}
+
+// GetLatest fetches the latest version of robots.txt of host,
+// or the latest version received when reachable is true
+func (r robotsDB) GetLatest(ctx context.Context, host string, reachable bool) (*models.RobotsTxt, error) {
+	query := makePgSQLQuery(models.QueryGetLatestRobots)
+	if reachable {
+		query = makePgSQLQuery(models.QueryGetReachableRobots)
+	}
+
+	return models.RobotsGet(ctx, query, r.DB, host)
+}
+
+// GetPrevious fetches the version of robots.txt of the host of rt before rt
+func (r robotsDB) GetPrevious(ctx context.Context, rt *models.RobotsTxt) (*models.RobotsTxt, error) {
+	query := makePgSQLQuery(models.QueryGetPreviousRobots)
+
+	return models.RobotsGet(ctx, query, r.DB, rt.Host, rt.ID)
+}
+
+// GetAll fetches all rows from robots_txt table in orderBy order
+func (r robotsDB) GetAll(
+	ctx context.Context,
+	rf models.RobotsFilter,
+	cf models.CommonFilters,
+) ([]*models.RobotsTxt, error) {
+	return models.RobotsGetAll(ctx, rf, cf, models.QueryGetAllRobots, r.DB, makePgSQLQuery)
+}
+
+// Insert writes a version of robots.txt to robots_txt table
+func (r robotsDB) Insert(ctx context.Context, rt *models.RobotsTxt) error {
+	query := makePgSQLQuery(models.QueryInsertRobots)
+
+	return models.RobotsInsert(ctx, rt, query, r.DB)
+}
+
+// Update writes the time when a version of robots.txt was last fetched
+func (r robotsDB) Update(ctx context.Context, rt *models.RobotsTxt) error {
+	query := makePgSQLQuery(models.QueryUpdateRobots)
+
+	return models.RobotsUpdate(ctx, rt, query, r.DB)
+}
@@ -0,0 +1,50 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// runDB is used to implement RunModel interface
+type runDB struct {
+	DB *sql.DB
+}
+
+// newRunDB returns *runDB which implements RunModel interface
+func newRunDB(db *sql.DB) *runDB {
+	return &runDB{
+		DB: db,
+	}
+}
+
+// GetById fetches a row from crawl_runs table by id
+func (r runDB) GetById(ctx context.Context, id int) (*models.CrawlRun, error) {
+	query := makePgSQLQuery(models.QueryGetRunById)
+
+	return models.RunGetById(ctx, id, query, r.DB)
+}
+
+// GetAll fetches all rows from crawl_runs table in orderBy order
+func (r runDB) GetAll(
+	ctx context.Context,
+	rf models.RunFilter,
+	cf models.CommonFilters,
+) ([]*models.CrawlRun, error) {
+	return models.RunGetAll(ctx, rf, cf, models.QueryGetAllRun, r.DB, makePgSQLQuery)
+}
+
+// Insert writes a run to crawl_runs table
+func (r runDB) Insert(ctx context.Context, run *models.CrawlRun) error {
+	query := makePgSQLQuery(models.QueryInsertRun)
+
+	return models.RunInsert(ctx, run, query, r.DB)
+}
+
+// Update writes the finish time, exit reason and counts of a run
+func (r runDB) Update(ctx context.Context, run *models.CrawlRun) error {
+	query := makePgSQLQuery(models.QueryUpdateRun)
+
+	return models.RunUpdate(ctx, run, query, r.DB)
+}
@@ -1,6 +1,7 @@
 package psql
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/0x00f00bar/webcrawlerGo/models"
@@ -19,49 +20,53 @@ func newUrlDB(db *sql.DB) *urlDB {
 }
 
 // GetById fetches a row from urls table by id
-func (u urlDB) GetById(id int) (*models.URL, error) {
+func (u urlDB) GetById(ctx context.Context, id int) (*models.URL, error) {
 	query := makePgSQLQuery(models.QueryGetURLById)
 
-	return models.URLGetById(id, query, u.DB)
+	return models.URLGetById(ctx, id, query, u.DB)
 }
 
 // GetByURL fetches a row from urls table by url string
-func (u urlDB) GetByURL(urlStr string) (*models.URL, error) {
+func (u urlDB) GetByURL(ctx context.Context, urlStr string) (*models.URL, error) {
 	query := makePgSQLQuery(models.QueryGetURLByURL)
 
-	return models.URLGetByURL(urlStr, query, u.DB)
+	return models.URLGetByURL(ctx, urlStr, query, u.DB)
 }
 
 // Insert writes a url to urls table
-func (u urlDB) Insert(m *models.URL) error {
+func (u urlDB) Insert(ctx context.Context, m *models.URL) error {
 	query := makePgSQLQuery(models.QueryInsertURL)
 
-	return models.URLInsert(m, query, u.DB)
+	return models.URLInsert(ctx, m, query, u.DB)
 }
 
 // Update updates a urls table row with provided values.
 // Optimistic lockin enabled: if version change detected
 // return ErrEditConflict
-func (u urlDB) Update(m *models.URL) error {
+func (u urlDB) Update(ctx context.Context, m *models.URL) error {
 	query := makePgSQLQuery(models.QueryUpdateURL)
 
-	return models.URLUpdate(m, query, u.DB)
+	return models.URLUpdate(ctx, m, query, u.DB)
 }
 
 // Delete url row by id
-func (u urlDB) Delete(id int) error {
+func (u urlDB) Delete(ctx context.Context, id int) error {
 	query := makePgSQLQuery(models.QueryDeleteURL)
 
-	return models.URLDelete(id, query, u.DB)
+	return models.URLDelete(ctx, id, query, u.DB)
 }
 
 // GetAll fetches all rows from urls table in orderBy order
-func (u urlDB) GetAll(uf models.URLFilter, cf models.CommonFilters) ([]*models.URL, error) {
-	return models.URLGetAll(uf, cf, models.QueryGetAllURL, u.DB, makePgSQLQuery)
+func (u urlDB) GetAll(
+	ctx context.Context,
+	uf models.URLFilter,
+	cf models.CommonFilters,
+) ([]*models.URL, error) {
+	return models.URLGetAll(ctx, uf, cf, models.QueryGetAllURL, u.DB, makePgSQLQuery)
 }
 
 // GetAll fetches all rows where is_monitored is true from urls table in orderBy order
-func (u urlDB) GetAllMonitored(cf models.CommonFilters) ([]*models.URL, error) {
+func (u urlDB) GetAllMonitored(ctx context.Context, cf models.CommonFilters) ([]*models.URL, error) {
 	uf := models.URLFilter{
 		IsMonitored:        true,
 		IsMonitoredPresent: true,
@@ -69,5 +74,5 @@ func (u urlDB) GetAllMonitored(cf models.CommonFilters) ([]*models.URL, error) {
 		IsAlivePresent:     true,
 	}
 
-	return models.URLGetAll(uf, cf, models.QueryGetAllURL, u.DB, makePgSQLQuery)
+	return models.URLGetAll(ctx, uf, cf, models.QueryGetAllURL, u.DB, makePgSQLQuery)
 }
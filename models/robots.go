@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var RobotsColumns = []string{"id", "host", "status_code", "fetched_at", "checked_at"}
+
+type RobotsFilter struct {
+	Host string `json:"host"`
+}
+
+// Queries related to robots_txt table
+const (
+	QuerySelectRobots = `SELECT id, host, status_code, content, error, fetched_at, checked_at
+	FROM robots_txt `
+	QueryGetRobotsById      = QuerySelectRobots + "WHERE id = __ARG__"
+	QueryGetLatestRobots    = QuerySelectRobots + "WHERE host = __ARG__ ORDER BY id DESC LIMIT 1"
+	QueryGetReachableRobots = QuerySelectRobots + `WHERE host = __ARG__
+	AND status_code BETWEEN 200 AND 499 AND status_code <> 429 ORDER BY id DESC LIMIT 1`
+	QueryGetPreviousRobots = QuerySelectRobots + `WHERE host = __ARG__ AND id < __ARG__
+	ORDER BY id DESC LIMIT 1`
+	QueryGetAllRobots = QuerySelectRobots + "WHERE host LIKE __ARG__ "
+	QueryInsertRobots = `
+	INSERT INTO robots_txt (host, status_code, content, error, fetched_at, checked_at)
+	VALUES (__ARG__, __ARG__, __ARG__, __ARG__, __ARG__, __ARG__)
+	RETURNING id`
+	QueryUpdateRobots = `UPDATE robots_txt SET checked_at = __ARG__ WHERE id = __ARG__`
+)
+
+// RobotsTxt type holds a version of robots.txt of a host. A new version
+// is written when the status or the content of robots.txt changes.
+type RobotsTxt struct {
+	ID         uint      `json:"id"`
+	Host       string    `json:"host"`        // <scheme>://<host>
+	StatusCode int       `json:"status_code"` // HTTP status; 0 when the request failed
+	Content    string    `json:"content"`     // body of 2xx responses
+	Error      string    `json:"error,omitempty"`
+	FetchedAt  time.Time `json:"fetched_at"` // time when the version was first fetched
+	CheckedAt  time.Time `json:"checked_at"` // time when the version was last fetched
+}
+
+// NewRobotsTxt returns new RobotsTxt of host with
+// FetchedAt and CheckedAt set to time.Now
+func NewRobotsTxt(host string, statusCode int, content, errMsg string) *RobotsTxt {
+	now := time.Now()
+	return &RobotsTxt{
+		Host:       host,
+		StatusCode: statusCode,
+		Content:    content,
+		Error:      errMsg,
+		FetchedAt:  now,
+		CheckedAt:  now,
+	}
+}
+
+// Reachable tells if robots.txt was received; HTTP 429, 5xx
+// and failed requests are unreachable
+func (r *RobotsTxt) Reachable() bool {
+	return r.StatusCode >= http.StatusOK &&
+		r.StatusCode < http.StatusInternalServerError &&
+		r.StatusCode != http.StatusTooManyRequests
+}
+
+// scanRobots scans a row selected with QuerySelectRobots
+func scanRobots(scan func(dest ...any) error) (*RobotsTxt, error) {
+	var r RobotsTxt
+	err := scan(&r.ID, &r.Host, &r.StatusCode, &r.Content, &r.Error, &r.FetchedAt, &r.CheckedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// RobotsGet fetches a row from robots_txt table with query and args
+func RobotsGet(ctx context.Context, query string, db *sql.DB, args ...any) (*RobotsTxt, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	r, err := scanRobots(db.QueryRowContext(ctx, query, args...).Scan)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// RobotsGetById fetches a row from robots_txt table by id
+func RobotsGetById(ctx context.Context, id int, query string, db *sql.DB) (*RobotsTxt, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+	return RobotsGet(ctx, query, db, id)
+}
+
+// RobotsGetAll fetches all rows from robots_txt table as per filters
+func RobotsGetAll(
+	ctx context.Context,
+	rf RobotsFilter,
+	cf CommonFilters,
+	query string,
+	db *sql.DB,
+	queryTransformFn func(string) string,
+) ([]*RobotsTxt, error) {
+	args := []any{"%" + rf.Host + "%"}
+
+	orderBy, err := GetOrderByQuery(&cf)
+	if err != nil {
+		return nil, err
+	}
+	query += orderBy
+
+	query += " LIMIT __ARG__ OFFSET __ARG__"
+	args = append(args, cf.Limit(), cf.Offset())
+
+	query = queryTransformFn(query)
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []*RobotsTxt{}
+
+	for rows.Next() {
+		r, err := scanRobots(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// RobotsInsert writes a version of robots.txt to robots_txt table
+func RobotsInsert(ctx context.Context, m *RobotsTxt, query string, db *sql.DB) error {
+	defer observeDBWrite("insert_robots", time.Now())
+
+	args := []any{m.Host, m.StatusCode, m.Content, m.Error, m.FetchedAt, m.CheckedAt}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	return db.QueryRowContext(ctx, query, args...).Scan(&m.ID)
+}
+
+// RobotsUpdate writes the time when a version of robots.txt was last fetched
+func RobotsUpdate(ctx context.Context, m *RobotsTxt, query string, db *sql.DB) error {
+	defer observeDBWrite("update_robots", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	result, err := db.ExecContext(ctx, query, m.CheckedAt, m.ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
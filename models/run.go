@@ -0,0 +1,285 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var RunColumns = []string{
+	"id", "started_at", "finished_at", "base_url", "exit_reason",
+	"urls_crawled", "urls_discovered", "pages_saved", "errors", "dead_urls",
+}
+
+// Exit reasons of a crawl run
+const (
+	RunRunning     = "running"
+	RunCompleted   = "completed"
+	RunInterrupted = "interrupted"
+	RunAborted     = "aborted"
+	RunFailed      = "failed"
+	RunDrained     = "drained" // stopped with URLs left in queue
+)
+
+type RunFilter struct {
+	BaseURL    string `json:"base_url"`
+	ExitReason string `json:"exit_reason"`
+}
+
+// Queries related to crawl_runs table
+const (
+	QuerySelectRun = `SELECT id, started_at, finished_at, base_url, options, exit_reason,
+	urls_crawled, urls_discovered, pages_saved, errors, dead_urls FROM crawl_runs `
+	QueryGetRunById = QuerySelectRun + "WHERE id = __ARG__"
+	QueryGetAllRun  = QuerySelectRun + "WHERE base_url LIKE __ARG__ "
+	QueryInsertRun  = `
+	INSERT INTO crawl_runs (started_at, base_url, options, exit_reason)
+	VALUES (__ARG__, __ARG__, __ARG__, __ARG__)
+	RETURNING id`
+	QueryUpdateRun = `
+	UPDATE crawl_runs
+	SET finished_at = __ARG__, exit_reason = __ARG__, urls_crawled = __ARG__,
+	urls_discovered = __ARG__, pages_saved = __ARG__, errors = __ARG__, dead_urls = __ARG__
+	WHERE id = __ARG__`
+)
+
+// CrawlRun type holds the information of a crawl
+// started by the CLI
+type CrawlRun struct {
+	ID             uint       `json:"id"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"` // nil while the run is in progress
+	BaseURL        string     `json:"base_url"`
+	Options        RunOptions `json:"options"`
+	ExitReason     string     `json:"exit_reason"`
+	URLsCrawled    int        `json:"urls_crawled"`
+	URLsDiscovered int        `json:"urls_discovered"`
+	PagesSaved     int        `json:"pages_saved"`
+	Errors         int        `json:"errors"`
+	DeadURLs       int        `json:"dead_urls"`
+}
+
+// RunOptions holds the effective crawl options of a run.
+// Stored as JSON in crawl_runs table.
+type RunOptions struct {
+	Seeds          []string `json:"seeds,omitempty"`
+	Hosts          []string `json:"hosts,omitempty"`
+	Scheme         string   `json:"scheme,omitempty"`
+	MarkedURLs     []string `json:"murls"`
+	IgnorePatterns []string `json:"ignore"`
+	Crawlers       int      `json:"n"`
+	RequestDelay   string   `json:"req_delay"`
+	HostRate       float64  `json:"host_rps,omitempty"`
+	HostConns      int      `json:"host_conns,omitempty"`
+	AdaptiveDelay  bool     `json:"adaptive_delay"`
+	RobotsTTL      string   `json:"robots_ttl,omitempty"`
+	RetryTimes     int      `json:"retry"`
+	RetryBackoff   string   `json:"retry_backoff"`
+	ErrorPolicy    string   `json:"on_error"`
+	Directives     string   `json:"robots_directives,omitempty"`
+	Extractors     []string `json:"extractors,omitempty"` // names of the link extractors
+	AuthFile       string   `json:"auth,omitempty"`       // path of the credentials file; credentials are not recorded
+	VariantsFile   string   `json:"variants,omitempty"`   // path of the fetch variants file
+	Source         string   `json:"source,omitempty"`     // local directory or WARC file crawled under the base URL
+	Record         string   `json:"record,omitempty"`     // cassette the responses were recorded to
+	Replay         string   `json:"replay,omitempty"`     // cassette the responses were replayed from
+	Proxies        []string `json:"proxies,omitempty"`    // passwords of proxies are redacted
+	CAFile         string   `json:"ca_file,omitempty"`
+	ClientCert     string   `json:"client_cert,omitempty"`
+	ClientKey      string   `json:"client_key,omitempty"`
+	Insecure       bool     `json:"insecure,omitempty"`
+	ConnectTimeout string   `json:"connect_timeout,omitempty"`
+	ReadTimeout    string   `json:"read_timeout,omitempty"`
+	Timeout        string   `json:"timeout,omitempty"`
+	HTTP2          bool     `json:"http2"`
+	UserAgent      string   `json:"ua"`
+	UpdateDaysPast int      `json:"days"`
+	UpdateHrefs    bool     `json:"update_hrefs"`
+	Schedule       string   `json:"schedule,omitempty"` // name of the crawl definition run by the daemon
+}
+
+// Value implements [driver.Valuer]
+func (o RunOptions) Value() (driver.Value, error) {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements [sql.Scanner]
+func (o *RunOptions) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, o)
+	case string:
+		return json.Unmarshal([]byte(v), o)
+	default:
+		return fmt.Errorf("models: cannot scan %T into RunOptions", src)
+	}
+}
+
+// NewCrawlRun returns new CrawlRun with StartedAt set to time.Now
+// and ExitReason set to RunRunning
+func NewCrawlRun(baseURL string, options RunOptions) *CrawlRun {
+	return &CrawlRun{
+		StartedAt:  time.Now(),
+		BaseURL:    baseURL,
+		Options:    options,
+		ExitReason: RunRunning,
+	}
+}
+
+// scanRun scans a row selected with QuerySelectRun
+func scanRun(scan func(dest ...any) error) (*CrawlRun, error) {
+	var run CrawlRun
+	var finishedAt sql.NullTime
+
+	err := scan(
+		&run.ID,
+		&run.StartedAt,
+		&finishedAt,
+		&run.BaseURL,
+		&run.Options,
+		&run.ExitReason,
+		&run.URLsCrawled,
+		&run.URLsDiscovered,
+		&run.PagesSaved,
+		&run.Errors,
+		&run.DeadURLs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return &run, nil
+}
+
+// RunGetById fetches a row from crawl_runs table by id
+func RunGetById(ctx context.Context, id int, query string, db *sql.DB) (*CrawlRun, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	run, err := scanRun(db.QueryRowContext(ctx, query, id).Scan)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+// RunGetAll fetches all rows from crawl_runs table as per filters
+func RunGetAll(
+	ctx context.Context,
+	rf RunFilter,
+	cf CommonFilters,
+	query string,
+	db *sql.DB,
+	queryTransformFn func(string) string,
+) ([]*CrawlRun, error) {
+	args := []any{fmt.Sprintf("%%%s%%", rf.BaseURL)}
+
+	if rf.ExitReason != "" {
+		query += " AND exit_reason = __ARG__"
+		args = append(args, rf.ExitReason)
+	}
+
+	orderBy, err := GetOrderByQuery(&cf)
+	if err != nil {
+		return nil, err
+	}
+	query += orderBy
+
+	query += " LIMIT __ARG__ OFFSET __ARG__"
+	args = append(args, cf.Limit(), cf.Offset())
+
+	query = queryTransformFn(query)
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*CrawlRun{}
+
+	for rows.Next() {
+		run, err := scanRun(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// RunInsert writes a run to crawl_runs table
+func RunInsert(ctx context.Context, m *CrawlRun, query string, db *sql.DB) error {
+	defer observeDBWrite("insert_run", time.Now())
+
+	args := []any{m.StartedAt, m.BaseURL, m.Options, m.ExitReason}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	return db.QueryRowContext(ctx, query, args...).Scan(&m.ID)
+}
+
+// RunUpdate writes the finish time, exit reason
+// and counts of a run
+func RunUpdate(ctx context.Context, m *CrawlRun, query string, db *sql.DB) error {
+	defer observeDBWrite("update_run", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	args := []any{
+		m.FinishedAt,
+		m.ExitReason,
+		m.URLsCrawled,
+		m.URLsDiscovered,
+		m.PagesSaved,
+		m.Errors,
+		m.DeadURLs,
+		m.ID,
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
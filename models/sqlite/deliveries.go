@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// deliveryDB is used to implement DeliveryModel interface
+type deliveryDB struct {
+	DB *sqliteConnections
+}
+
+// newDeliveryDB returns *deliveryDB which implements DeliveryModel interface
+func newDeliveryDB(db *sqliteConnections) *deliveryDB {
+	return &deliveryDB{
+		DB: db,
+	}
+}
+
+// GetById fetches a row from webhook_deliveries table by id
+func (r deliveryDB) GetById(ctx context.Context, id int) (*models.Delivery, error) {
+	query := makeSQLiteQuery(models.QueryGetDeliveryById)
+
+	return models.DeliveryGetById(ctx, id, query, r.DB.readers)
+}
+
+// GetAll fetches all rows from webhook_deliveries table in orderBy order
+func (r deliveryDB) GetAll(
+	ctx context.Context,
+	df models.DeliveryFilter,
+	cf models.CommonFilters,
+) ([]*models.Delivery, error) {
+	return models.DeliveryGetAll(ctx, df, cf, models.QueryGetAllDelivery, r.DB.readers, makeSQLiteQuery)
+}
+
+// Insert writes a delivery to webhook_deliveries table
+func (r deliveryDB) Insert(ctx context.Context, d *models.Delivery) error {
+	query := makeSQLiteQuery(models.QueryInsertDelivery)
+
+	return models.DeliveryInsert(ctx, d, query, r.DB.writer)
+}
+
+// Update writes the status and the result of the last attempt of a delivery
+func (r deliveryDB) Update(ctx context.Context, d *models.Delivery) error {
+	query := makeSQLiteQuery(models.QueryUpdateDelivery)
+
+	return models.DeliveryUpdate(ctx, d, query, r.DB.writer)
+}
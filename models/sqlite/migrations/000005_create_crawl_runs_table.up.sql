@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS crawl_runs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  finished_at DATETIME DEFAULT NULL,
+  base_url TEXT NOT NULL,
+  options TEXT NOT NULL DEFAULT '{}',
+  exit_reason TEXT NOT NULL DEFAULT 'running',
+  urls_crawled INTEGER NOT NULL DEFAULT 0,
+  urls_discovered INTEGER NOT NULL DEFAULT 0,
+  pages_saved INTEGER NOT NULL DEFAULT 0,
+  errors INTEGER NOT NULL DEFAULT 0,
+  dead_urls INTEGER NOT NULL DEFAULT 0
+);
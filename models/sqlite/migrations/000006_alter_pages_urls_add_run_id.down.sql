@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_page_run_id;
+
+ALTER TABLE urls
+DROP COLUMN last_run_id;
+
+ALTER TABLE pages
+DROP COLUMN run_id;
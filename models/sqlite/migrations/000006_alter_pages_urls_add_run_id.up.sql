@@ -0,0 +1,7 @@
+ALTER TABLE pages
+ADD COLUMN run_id INTEGER DEFAULT NULL REFERENCES crawl_runs (id) ON DELETE SET NULL;
+
+ALTER TABLE urls
+ADD COLUMN last_run_id INTEGER DEFAULT NULL REFERENCES crawl_runs (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_page_run_id ON pages(run_id);
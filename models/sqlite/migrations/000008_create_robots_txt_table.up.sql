@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS robots_txt (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  host TEXT NOT NULL,
+  status_code INTEGER NOT NULL DEFAULT 0,
+  content TEXT NOT NULL DEFAULT '',
+  error TEXT NOT NULL DEFAULT '',
+  fetched_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  checked_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_robots_txt_host ON robots_txt(host);
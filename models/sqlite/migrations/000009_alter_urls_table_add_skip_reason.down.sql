@@ -0,0 +1,2 @@
+ALTER TABLE urls
+DROP COLUMN skip_reason;
\ No newline at end of file
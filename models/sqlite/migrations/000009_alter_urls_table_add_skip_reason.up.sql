@@ -0,0 +1,2 @@
+ALTER TABLE urls
+ADD COLUMN skip_reason TEXT NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE pages
+DROP COLUMN variant;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE pages
+ADD COLUMN variant TEXT NOT NULL DEFAULT '';
\ No newline at end of file
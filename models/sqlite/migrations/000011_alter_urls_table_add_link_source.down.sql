@@ -0,0 +1,2 @@
+ALTER TABLE urls
+DROP COLUMN link_source;
\ No newline at end of file
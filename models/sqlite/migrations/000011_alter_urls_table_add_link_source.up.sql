@@ -0,0 +1,2 @@
+ALTER TABLE urls
+ADD COLUMN link_source TEXT NOT NULL DEFAULT '';
\ No newline at end of file
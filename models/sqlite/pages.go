@@ -21,17 +21,31 @@ func newPageDB(db *sqliteConnections) *pageDB {
 }
 
 // GetById fetches a row from pages table by id
-func (p pageDB) GetById(id int) (*models.Page, error) {
+func (p pageDB) GetById(ctx context.Context, id int) (*models.Page, error) {
 	query := makeSQLiteQuery(models.QueryGetPageById)
 
-	return models.PageGetById(id, query, p.DB.readers)
+	return models.PageGetById(ctx, id, query, p.DB.readers)
 }
 
-// GetAllByURL fetches a row from pages table by urlId
-// and order by orderBy
-func (p pageDB) GetAllByURL(urlID uint, cf models.CommonFilters) ([]*models.Page, error) {
+// GetLatestByURL fetches the latest row from pages table by urlId and variant
+func (p pageDB) GetLatestByURL(ctx context.Context, urlID uint, variant string) (*models.Page, error) {
+	query := makeSQLiteQuery(models.QueryGetLatestPageByURL)
+
+	return models.PageGetLatestByURL(ctx, urlID, variant, query, p.DB.readers)
+}
+
+// GetAllByURL fetches a row from pages table by urlId,
+// filtered by pf and order by orderBy
+func (p pageDB) GetAllByURL(
+	ctx context.Context,
+	urlID uint,
+	pf models.PageFilter,
+	cf models.CommonFilters,
+) ([]*models.Page, error) {
 	return models.PageGetAllByURL(
+		ctx,
 		urlID,
+		pf,
 		cf,
 		models.QueryGetAllPageByURL,
 		p.DB.readers,
@@ -39,11 +53,22 @@ func (p pageDB) GetAllByURL(urlID uint, cf models.CommonFilters) ([]*models.Page
 	)
 }
 
+// GetAllByRun fetches rows from pages table saved by
+// crawl run runID, filtered by pf and order by orderBy
+func (p pageDB) GetAllByRun(
+	ctx context.Context,
+	runID uint,
+	pf models.PageFilter,
+	cf models.CommonFilters,
+) ([]*models.Page, error) {
+	return models.PageGetAllByRun(ctx, runID, pf, cf, models.QueryGetAllPageByRun, p.DB.readers, makeSQLiteQuery)
+}
+
 // Insert writes a page to pages table
-func (p pageDB) Insert(m *models.Page) error {
+func (p pageDB) Insert(ctx context.Context, m *models.Page) error {
 	query := makeSQLiteQuery(models.QueryInsertPage)
 
-	return models.PageInsert(m, query, p.DB.writer)
+	return models.PageInsert(ctx, m, query, p.DB.writer)
 }
 
 // Update not required on pages table
@@ -51,31 +76,33 @@ func (p pageDB) Insert(m *models.Page) error {
 // }
 
 // Delete page row by id
-func (p pageDB) Delete(id int) error {
+func (p pageDB) Delete(ctx context.Context, id int) error {
 	query := makeSQLiteQuery(models.QueryDeletePage)
 
-	return models.PageDelete(id, query, p.DB.writer)
+	return models.PageDelete(ctx, id, query, p.DB.writer)
 }
 
 // GetLatestPageCount returns the number of latest pages
-// filtered by baseurl, markedURL and by cutoff date
+// filtered by baseurl, markedURL, variant and by cutoff date
 func (p pageDB) GetLatestPageCount(
 	ctx context.Context,
 	baseURL *url.URL,
 	markedURL string,
+	variant string,
 	cutoffDate time.Time,
 ) (int, error) {
 	query := makeSQLiteQuery(models.QueryGetLatestPagesCount)
 
-	return models.PageGetLatestPageCount(ctx, baseURL, markedURL, cutoffDate, query, p.DB.readers)
+	return models.PageGetLatestPageCount(ctx, baseURL, markedURL, variant, cutoffDate, query, p.DB.readers)
 }
 
 // GetLatestPagesPaginated returns PageContent of latest pages
-// filtered by baseurl, markedURL and by cutoff date
+// filtered by baseurl, markedURL, variant and by cutoff date
 func (p pageDB) GetLatestPagesPaginated(
 	ctx context.Context,
 	baseURL *url.URL,
 	markedURL string,
+	variant string,
 	cutoffDate time.Time,
 	pageNum int,
 	pageSize int,
@@ -86,6 +113,7 @@ func (p pageDB) GetLatestPagesPaginated(
 		ctx,
 		baseURL,
 		markedURL,
+		variant,
 		cutoffDate,
 		pageNum,
 		pageSize,
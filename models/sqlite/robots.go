@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// robotsDB is used to implement RobotsModel interface
+type robotsDB struct {
+	DB *sqliteConnections
+}
+
+// newRobotsDB returns *robotsDB which implements RobotsModel interface
+func newRobotsDB(db *sqliteConnections) *robotsDB {
+	return &robotsDB{
+		DB: db,
+	}
+}
+
+// GetById fetches a row from robots_txt table by id
+func (r robotsDB) GetById(ctx context.Context, id int) (*models.RobotsTxt, error) {
+	query := makeSQLiteQuery(models.QueryGetRobotsById)
+
+	return models.RobotsGetById(ctx, id, query, r.DB.readers)
+}
+
+// GetLatest fetches the latest version of robots.txt of host,
+// or the latest version received when reachable is true
+func (r robotsDB) GetLatest(ctx context.Context, host string, reachable bool) (*models.RobotsTxt, error) {
+	query := makeSQLiteQuery(models.QueryGetLatestRobots)
+	if reachable {
+		query = makeSQLiteQuery(models.QueryGetReachableRobots)
+	}
+
+	return models.RobotsGet(ctx, query, r.DB.readers, host)
+}
+
+// GetPrevious fetches the version of robots.txt of the host of rt before rt
+func (r robotsDB) GetPrevious(ctx context.Context, rt *models.RobotsTxt) (*models.RobotsTxt, error) {
+	query := makeSQLiteQuery(models.QueryGetPreviousRobots)
+
+	return models.RobotsGet(ctx, query, r.DB.readers, rt.Host, rt.ID)
+}
+
+// GetAll fetches all rows from robots_txt table in orderBy order
+func (r robotsDB) GetAll(
+	ctx context.Context,
+	rf models.RobotsFilter,
+	cf models.CommonFilters,
+) ([]*models.RobotsTxt, error) {
+	return models.RobotsGetAll(ctx, rf, cf, models.QueryGetAllRobots, r.DB.readers, makeSQLiteQuery)
+}
+
+// Insert writes a version of robots.txt to robots_txt table
+func (r robotsDB) Insert(ctx context.Context, rt *models.RobotsTxt) error {
+	query := makeSQLiteQuery(models.QueryInsertRobots)
+
+	return models.RobotsInsert(ctx, rt, query, r.DB.writer)
+}
+
+// Update writes the time when a version of robots.txt was last fetched
+func (r robotsDB) Update(ctx context.Context, rt *models.RobotsTxt) error {
+	query := makeSQLiteQuery(models.QueryUpdateRobots)
+
+	return models.RobotsUpdate(ctx, rt, query, r.DB.writer)
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// runDB is used to implement RunModel interface
+type runDB struct {
+	DB *sqliteConnections
+}
+
+// newRunDB returns *runDB which implements RunModel interface
+func newRunDB(db *sqliteConnections) *runDB {
+	return &runDB{
+		DB: db,
+	}
+}
+
+// GetById fetches a row from crawl_runs table by id
+func (r runDB) GetById(ctx context.Context, id int) (*models.CrawlRun, error) {
+	query := makeSQLiteQuery(models.QueryGetRunById)
+
+	return models.RunGetById(ctx, id, query, r.DB.readers)
+}
+
+// GetAll fetches all rows from crawl_runs table in orderBy order
+func (r runDB) GetAll(
+	ctx context.Context,
+	rf models.RunFilter,
+	cf models.CommonFilters,
+) ([]*models.CrawlRun, error) {
+	return models.RunGetAll(ctx, rf, cf, models.QueryGetAllRun, r.DB.readers, makeSQLiteQuery)
+}
+
+// Insert writes a run to crawl_runs table
+func (r runDB) Insert(ctx context.Context, run *models.CrawlRun) error {
+	query := makeSQLiteQuery(models.QueryInsertRun)
+
+	return models.RunInsert(ctx, run, query, r.DB.writer)
+}
+
+// Update writes the finish time, exit reason and counts of a run
+func (r runDB) Update(ctx context.Context, run *models.CrawlRun) error {
+	query := makeSQLiteQuery(models.QueryUpdateRun)
+
+	return models.RunUpdate(ctx, run, query, r.DB.writer)
+}
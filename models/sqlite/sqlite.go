@@ -22,8 +22,12 @@ type sqliteConnections struct {
 }
 
 type SQLiteDB struct {
-	URLModel  *urlDB
-	PageModel *pageDB
+	URLModel      *urlDB
+	PageModel     *pageDB
+	RunModel      *runDB
+	DeliveryModel *deliveryDB
+	StatsModel    *statsDB
+	RobotsModel   *robotsDB
 }
 
 // NewSQLiteDB returns new instance of SQLiteDB with URL and Pages models
@@ -33,8 +37,12 @@ func NewSQLiteDB(dbReader *sql.DB, dbWriter *sql.DB) *SQLiteDB {
 		writer:  dbWriter,
 	}
 	return &SQLiteDB{
-		URLModel:  newUrlDB(sqliteConns),
-		PageModel: newPageDB(sqliteConns),
+		URLModel:      newUrlDB(sqliteConns),
+		PageModel:     newPageDB(sqliteConns),
+		RunModel:      newRunDB(sqliteConns),
+		DeliveryModel: newDeliveryDB(sqliteConns),
+		StatsModel:    newStatsDB(sqliteConns),
+		RobotsModel:   newRobotsDB(sqliteConns),
 	}
 }
 
@@ -64,10 +72,54 @@ func (sq SQLiteDB) InitDatabase(ctx context.Context, db *sql.DB) error {
     FOREIGN KEY (url_id) REFERENCES urls (id) ON DELETE CASCADE
 	);`
 	createPagesURLIDIndex := `CREATE INDEX IF NOT EXISTS idx_page_url_id ON pages(url_id);`
-	checkColIsAlive := `SELECT name FROM pragma_table_info('urls') WHERE name = 'is_alive';`
-	alterURLAddIsAlive := `ALTER TABLE urls ADD COLUMN is_alive BOOLEAN DEFAULT TRUE;`
+	createCrawlRunsTableQuery := `CREATE TABLE IF NOT EXISTS crawl_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	finished_at DATETIME DEFAULT NULL,
+	base_url TEXT NOT NULL,
+	options TEXT NOT NULL DEFAULT '{}',
+	exit_reason TEXT NOT NULL DEFAULT 'running',
+	urls_crawled INTEGER NOT NULL DEFAULT 0,
+	urls_discovered INTEGER NOT NULL DEFAULT 0,
+	pages_saved INTEGER NOT NULL DEFAULT 0,
+	errors INTEGER NOT NULL DEFAULT 0,
+	dead_urls INTEGER NOT NULL DEFAULT 0
+	);`
+	createDeliveriesTableQuery := `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook TEXT NOT NULL,
+	event TEXT NOT NULL,
+	url TEXT NOT NULL DEFAULT '',
+	run_id INTEGER DEFAULT NULL REFERENCES crawl_runs (id) ON DELETE SET NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	response_status INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	finished_at DATETIME DEFAULT NULL
+	);`
 
-	queries := []string{createURLTableQuery, createPagesTableQuery, createPagesURLIDIndex}
+	createRobotsTableQuery := `CREATE TABLE IF NOT EXISTS robots_txt (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	host TEXT NOT NULL,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	content TEXT NOT NULL DEFAULT '',
+	error TEXT NOT NULL DEFAULT '',
+	fetched_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	checked_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	createRobotsHostIndex := `CREATE INDEX IF NOT EXISTS idx_robots_txt_host ON robots_txt(host);`
+
+	queries := []string{
+		createURLTableQuery,
+		createPagesTableQuery,
+		createPagesURLIDIndex,
+		createCrawlRunsTableQuery,
+		createDeliveriesTableQuery,
+		createRobotsTableQuery,
+		createRobotsHostIndex,
+	}
 
 	for _, query := range queries {
 		timeOutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -78,21 +130,67 @@ func (sq SQLiteDB) InitDatabase(ctx context.Context, db *sql.DB) error {
 		}
 	}
 
+	// columns added after the tables were created
+	newColumns := []struct {
+		table, column, alterQuery string
+	}{
+		{"urls", "is_alive", `ALTER TABLE urls ADD COLUMN is_alive BOOLEAN DEFAULT TRUE;`},
+		{
+			"pages",
+			"run_id",
+			`ALTER TABLE pages ADD COLUMN run_id INTEGER DEFAULT NULL
+			REFERENCES crawl_runs (id) ON DELETE SET NULL;`,
+		},
+		{
+			"urls",
+			"last_run_id",
+			`ALTER TABLE urls ADD COLUMN last_run_id INTEGER DEFAULT NULL
+			REFERENCES crawl_runs (id) ON DELETE SET NULL;`,
+		},
+		{"urls", "skip_reason", `ALTER TABLE urls ADD COLUMN skip_reason TEXT NOT NULL DEFAULT '';`},
+		{"pages", "variant", `ALTER TABLE pages ADD COLUMN variant TEXT NOT NULL DEFAULT '';`},
+		{"urls", "link_source", `ALTER TABLE urls ADD COLUMN link_source TEXT NOT NULL DEFAULT '';`},
+	}
+
+	for _, col := range newColumns {
+		err := addColumnIfNotExists(ctx, db, col.table, col.column, col.alterQuery)
+		if err != nil {
+			return err
+		}
+	}
+
 	timeOutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
+	_, err := db.ExecContext(
+		timeOutCtx,
+		`CREATE INDEX IF NOT EXISTS idx_page_run_id ON pages(run_id);`,
+	)
+	return err
+}
+
+// addColumnIfNotExists runs alterQuery when column is not present in table
+func addColumnIfNotExists(
+	ctx context.Context,
+	db *sql.DB,
+	table, column, alterQuery string,
+) error {
+	timeOutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
 	var colName string
-	row := db.QueryRowContext(timeOutCtx, checkColIsAlive)
+	row := db.QueryRowContext(
+		timeOutCtx,
+		`SELECT name FROM pragma_table_info(?) WHERE name = ?;`,
+		table,
+		column,
+	)
 	err := row.Scan(&colName)
-	if errors.Is(err, sql.ErrNoRows) {
-		timeOutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
-		_, err := db.ExecContext(timeOutCtx, alterURLAddIsAlive)
-		if err != nil {
-			return err
-		}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
 	}
 
-	return nil
+	_, err = db.ExecContext(timeOutCtx, alterQuery)
+	return err
 }
 
 // ExecWALCheckpoint will initiate checkpoint in the WAL journal
@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// statsDB is used to implement StatsModel interface
+type statsDB struct {
+	DB *sqliteConnections
+}
+
+// newStatsDB returns *statsDB which implements StatsModel interface
+func newStatsDB(db *sqliteConnections) *statsDB {
+	return &statsDB{
+		DB: db,
+	}
+}
+
+// Get counts the rows of urls, pages and crawl_runs tables of baseURL
+func (s statsDB) Get(ctx context.Context, baseURL string) (*models.Stats, error) {
+	query := makeSQLiteQuery(models.QueryGetStats)
+
+	return models.StatsGet(ctx, baseURL, query, s.DB.readers)
+}
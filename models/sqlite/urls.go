@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"context"
 	"github.com/0x00f00bar/webcrawlerGo/models"
 )
 
@@ -17,49 +18,53 @@ func newUrlDB(db *sqliteConnections) *urlDB {
 }
 
 // GetById fetches a row from urls table by id
-func (u urlDB) GetById(id int) (*models.URL, error) {
+func (u urlDB) GetById(ctx context.Context, id int) (*models.URL, error) {
 	query := makeSQLiteQuery(models.QueryGetURLById)
 
-	return models.URLGetById(id, query, u.DB.readers)
+	return models.URLGetById(ctx, id, query, u.DB.readers)
 }
 
 // GetByURL fetches a row from urls table by url string
-func (u urlDB) GetByURL(urlStr string) (*models.URL, error) {
+func (u urlDB) GetByURL(ctx context.Context, urlStr string) (*models.URL, error) {
 	query := makeSQLiteQuery(models.QueryGetURLByURL)
 
-	return models.URLGetByURL(urlStr, query, u.DB.readers)
+	return models.URLGetByURL(ctx, urlStr, query, u.DB.readers)
 }
 
 // Insert writes a url to urls table
-func (u urlDB) Insert(url *models.URL) error {
+func (u urlDB) Insert(ctx context.Context, url *models.URL) error {
 	query := makeSQLiteQuery(models.QueryInsertURL)
 
-	return models.URLInsert(url, query, u.DB.writer)
+	return models.URLInsert(ctx, url, query, u.DB.writer)
 }
 
 // Update updates a url with provided values.
 // Optimistic locking enabled: if version change detected
 // return ErrEditConflict
-func (u urlDB) Update(url *models.URL) error {
+func (u urlDB) Update(ctx context.Context, url *models.URL) error {
 	query := makeSQLiteQuery(models.QueryUpdateURL)
 
-	return models.URLUpdate(url, query, u.DB.writer)
+	return models.URLUpdate(ctx, url, query, u.DB.writer)
 }
 
 // Delete url row by id
-func (u urlDB) Delete(id int) error {
+func (u urlDB) Delete(ctx context.Context, id int) error {
 	query := makeSQLiteQuery(models.QueryDeleteURL)
 
-	return models.URLDelete(id, query, u.DB.writer)
+	return models.URLDelete(ctx, id, query, u.DB.writer)
 }
 
 // GetAll fetches all rows from urls table in orderBy order
-func (u urlDB) GetAll(uf models.URLFilter, cf models.CommonFilters) ([]*models.URL, error) {
-	return models.URLGetAll(uf, cf, models.QueryGetAllURL, u.DB.readers, makeSQLiteQuery)
+func (u urlDB) GetAll(
+	ctx context.Context,
+	uf models.URLFilter,
+	cf models.CommonFilters,
+) ([]*models.URL, error) {
+	return models.URLGetAll(ctx, uf, cf, models.QueryGetAllURL, u.DB.readers, makeSQLiteQuery)
 }
 
 // GetAll fetches all rows where is_monitored is true from urls table in orderBy order
-func (u urlDB) GetAllMonitored(cf models.CommonFilters) ([]*models.URL, error) {
+func (u urlDB) GetAllMonitored(ctx context.Context, cf models.CommonFilters) ([]*models.URL, error) {
 	uf := models.URLFilter{
 		IsMonitored:        true,
 		IsMonitoredPresent: true,
@@ -67,5 +72,5 @@ func (u urlDB) GetAllMonitored(cf models.CommonFilters) ([]*models.URL, error) {
 		IsAlivePresent:     true,
 	}
 
-	return models.URLGetAll(uf, cf, models.QueryGetAllURL, u.DB.readers, makeSQLiteQuery)
+	return models.URLGetAll(ctx, uf, cf, models.QueryGetAllURL, u.DB.readers, makeSQLiteQuery)
 }
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Queries related to database stats
+const (
+	QueryGetStats = `
+	SELECT
+	(SELECT COUNT(*) FROM urls WHERE url LIKE __ARG__),
+	(SELECT COUNT(*) FROM urls WHERE url LIKE __ARG__ AND is_monitored = true),
+	(SELECT COUNT(*) FROM urls WHERE url LIKE __ARG__ AND is_alive = false),
+	(SELECT COUNT(*) FROM pages INNER JOIN urls ON urls.id = pages.url_id WHERE urls.url LIKE __ARG__),
+	(SELECT COUNT(*) FROM crawl_runs WHERE base_url LIKE __ARG__)`
+)
+
+// Stats type holds the record counts of the database
+type Stats struct {
+	URLs          int `json:"urls"`
+	MonitoredURLs int `json:"monitored_urls"`
+	DeadURLs      int `json:"dead_urls"`
+	Pages         int `json:"pages"`
+	Runs          int `json:"runs"`
+}
+
+// StatsGet counts the records of URLs containing baseURL
+// and of crawl runs of base URLs containing baseURL
+func StatsGet(ctx context.Context, baseURL string, query string, db *sql.DB) (*Stats, error) {
+	pattern := fmt.Sprintf("%%%s%%", baseURL)
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
+	defer cancel()
+
+	var stats Stats
+	err := db.QueryRowContext(ctx, query, pattern, pattern, pattern, pattern, pattern).Scan(
+		&stats.URLs,
+		&stats.MonitoredURLs,
+		&stats.DeadURLs,
+		&stats.Pages,
+		&stats.Runs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -13,7 +13,7 @@ import (
 
 var URLColumns = []string{
 	"id", "url", "first_encountered", "last_checked",
-	"last_saved", "is_monitored", "is_alive", "version",
+	"last_saved", "is_monitored", "is_alive", "version", "last_run_id", "skip_reason", "link_source",
 }
 
 type URLFilter struct {
@@ -22,20 +22,23 @@ type URLFilter struct {
 	IsMonitoredPresent bool   `json:"-"`
 	IsAlive            bool   `json:"is_alive"`
 	IsAlivePresent     bool   `json:"-"`
+	IsSkipped          bool   `json:"is_skipped"` // URLs with a skip reason
+	IsSkippedPresent   bool   `json:"-"`
 }
 
 // Queries related to urls table
 const (
-	QuerySelectURL   = "SELECT id, url, first_encountered, last_checked, last_saved, is_monitored, is_alive, version FROM urls "
+	QuerySelectURL   = "SELECT id, url, first_encountered, last_checked, last_saved, is_monitored, is_alive, version, COALESCE(last_run_id, 0), skip_reason, link_source FROM urls "
 	QueryGetURLById  = QuerySelectURL + "WHERE id = __ARG__"
 	QueryGetURLByURL = QuerySelectURL + "WHERE url = __ARG__"
 	QueryInsertURL   = `
-	INSERT INTO urls (url, last_checked, last_saved, is_monitored)
-	VALUES (__ARG__, __ARG__, __ARG__, __ARG__)
+	INSERT INTO urls (url, last_checked, last_saved, is_monitored, skip_reason, link_source)
+	VALUES (__ARG__, __ARG__, __ARG__, __ARG__, __ARG__, __ARG__)
 	RETURNING id, first_encountered, version`
 	QueryUpdateURL = `
 	UPDATE urls
-	SET last_checked = __ARG__, last_saved = __ARG__, is_monitored = __ARG__, is_alive = __ARG__, version = version + 1
+	SET last_checked = __ARG__, last_saved = __ARG__, is_monitored = __ARG__, is_alive = __ARG__, last_run_id = __ARG__,
+	skip_reason = __ARG__, version = version + 1
 	WHERE id = __ARG__ AND version = __ARG__
 	RETURNING version`
 	QueryDeleteURL          = `DELETE from urls WHERE id = __ARG__`
@@ -56,6 +59,9 @@ type URL struct {
 	IsMonitored      bool      `json:"is_monitored"`
 	IsAlive          bool      `json:"is_alive"`
 	Version          uint      `json:"version"`
+	LastRunID        uint      `json:"last_run_id,omitempty"` // crawl run which last fetched the URL
+	SkipReason       string    `json:"skip_reason,omitempty"` // robots directives the page was last skipped, or would be, for
+	LinkSource       string    `json:"link_source,omitempty"` // tag and attribute, or header, of the link the URL was discovered by
 }
 
 func ValidateURL(v *internal.Validator, u *URL) {
@@ -77,12 +83,12 @@ func NewURL(url string, lastChecked, lastSaved time.Time, isMonitored bool) *URL
 }
 
 // URLGetById fetches a row from urls table by id
-func URLGetById(id int, query string, db *sql.DB) (*URL, error) {
+func URLGetById(ctx context.Context, id int, query string, db *sql.DB) (*URL, error) {
 	if id < 1 {
 		return nil, ErrRecordNotFound
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	var url URL
@@ -96,6 +102,9 @@ func URLGetById(id int, query string, db *sql.DB) (*URL, error) {
 		&url.IsMonitored,
 		&url.IsAlive,
 		&url.Version,
+		&url.LastRunID,
+		&url.SkipReason,
+		&url.LinkSource,
 	)
 	if err != nil {
 		switch {
@@ -109,12 +118,12 @@ func URLGetById(id int, query string, db *sql.DB) (*URL, error) {
 }
 
 // URLGetByURL fetches a row from urls table by url string
-func URLGetByURL(urlStr string, query string, db *sql.DB) (*URL, error) {
+func URLGetByURL(ctx context.Context, urlStr string, query string, db *sql.DB) (*URL, error) {
 	if urlStr == "" {
 		return nil, ErrNullURL
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	var url URL
@@ -128,6 +137,9 @@ func URLGetByURL(urlStr string, query string, db *sql.DB) (*URL, error) {
 		&url.IsMonitored,
 		&url.IsAlive,
 		&url.Version,
+		&url.LastRunID,
+		&url.SkipReason,
+		&url.LinkSource,
 	)
 	if err != nil {
 		switch {
@@ -141,10 +153,12 @@ func URLGetByURL(urlStr string, query string, db *sql.DB) (*URL, error) {
 }
 
 // URLInsert writes a url to urls table
-func URLInsert(m *URL, query string, db *sql.DB) error {
-	args := []interface{}{m.URL, m.LastChecked, m.LastSaved, m.IsMonitored}
+func URLInsert(ctx context.Context, m *URL, query string, db *sql.DB) error {
+	defer observeDBWrite("insert_url", time.Now())
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	args := []interface{}{m.URL, m.LastChecked, m.LastSaved, m.IsMonitored, m.SkipReason, m.LinkSource}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	return db.QueryRowContext(ctx, query, args...).Scan(&m.ID, &m.FirstEncountered, &m.Version)
@@ -153,11 +167,22 @@ func URLInsert(m *URL, query string, db *sql.DB) error {
 // URLUpdate updates a url with provided values.
 // Optimistic locking enabled: if version change detected
 // return ErrEditConflict
-func URLUpdate(m *URL, query string, db *sql.DB) error {
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+func URLUpdate(ctx context.Context, m *URL, query string, db *sql.DB) error {
+	defer observeDBWrite("update_url", time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
-	args := []interface{}{m.LastChecked, m.LastSaved, m.IsMonitored, m.IsAlive, m.ID, m.Version}
+	args := []interface{}{
+		m.LastChecked,
+		m.LastSaved,
+		m.IsMonitored,
+		m.IsAlive,
+		nullID(m.LastRunID),
+		m.SkipReason,
+		m.ID,
+		m.Version,
+	}
 
 	err := db.QueryRowContext(ctx, query, args...).Scan(&m.Version)
 	if err != nil {
@@ -172,12 +197,14 @@ func URLUpdate(m *URL, query string, db *sql.DB) error {
 }
 
 // URLDelete url row by id
-func URLDelete(id int, query string, db *sql.DB) error {
+func URLDelete(ctx context.Context, id int, query string, db *sql.DB) error {
+	defer observeDBWrite("delete_url", time.Now())
+
 	if id < 1 {
 		return ErrRecordNotFound
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	result, err := db.ExecContext(ctx, query, id)
@@ -199,6 +226,7 @@ func URLDelete(id int, query string, db *sql.DB) error {
 
 // URLGetAll fetches all rows from urls table as per filters
 func URLGetAll(
+	ctx context.Context,
 	uf URLFilter,
 	cf CommonFilters,
 	query string,
@@ -216,6 +244,13 @@ func URLGetAll(
 		query += " AND is_monitored = __ARG__"
 		args = append(args, uf.IsMonitored)
 	}
+	if uf.IsSkippedPresent {
+		if uf.IsSkipped {
+			query += " AND skip_reason <> ''"
+		} else {
+			query += " AND skip_reason = ''"
+		}
+	}
 
 	orderBy, err := GetOrderByQuery(&cf)
 	if err != nil {
@@ -228,7 +263,7 @@ func URLGetAll(
 
 	query = queryTransformFn(query)
 
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultDBTimeout)
+	ctx, cancel := context.WithTimeout(ctx, DefaultDBTimeout)
 	defer cancel()
 
 	rows, err := db.QueryContext(ctx, query, args...)
@@ -252,6 +287,9 @@ func URLGetAll(
 			&url.IsMonitored,
 			&url.IsAlive,
 			&url.Version,
+			&url.LastRunID,
+			&url.SkipReason,
+			&url.LinkSource,
 		)
 		if err != nil {
 			return nil, err
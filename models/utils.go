@@ -6,6 +6,15 @@ import (
 	"github.com/0x00f00bar/webcrawlerGo/internal"
 )
 
+// nullID returns nil for id 0 so that
+// unset ids are stored as NULL
+func nullID(id uint) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
 // ValidOrderBy tells if the orderBy is present in validFields
 func ValidOrderBy(orderBy string, validFields []string) bool {
 	return internal.ValuePresent(orderBy, validFields)
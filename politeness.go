@@ -0,0 +1,294 @@
+package webcrawler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jimsmart/grobotstxt"
+)
+
+// limits of the adaptive delay between requests to a host
+const (
+	minBackoffDelay = 100 * time.Millisecond // delay after the first slow down of a host without delay
+	maxHostDelay    = time.Minute            // delay is never raised above
+	minLatencyRise  = 250 * time.Millisecond // smaller rises of latency are not slow downs
+)
+
+// hostLimiter spaces the requests to every host crawled and limits
+// the concurrent requests to a host. It is shared by all crawlers.
+type hostLimiter struct {
+	rate      float64 // max requests per second to a host; 0 to use Crawl-delay only
+	maxConns  int     // max concurrent requests to a host; 0 for no limit
+	adaptive  bool    // adapt delay to the health of the host
+	robots    RobotsPolicy
+	userAgent string
+
+	mu    sync.Mutex
+	hosts map[string]*hostState // keyed by <scheme>://<host>
+}
+
+// hostState is the politeness state of a host
+type hostState struct {
+	conns chan struct{} // semaphore of concurrent requests; nil when not limited
+
+	mu        sync.Mutex
+	baseDelay time.Duration // configured delay; floor of the adaptive delay
+	delay     time.Duration // current delay between requests
+	next      time.Time     // earliest time of the next request
+	latency   time.Duration // moving average of response latency
+}
+
+// newHostLimiter returns pointer to a new hostLimiter. Requests to a host
+// are spaced by the larger of the delay of rate and the Crawl-delay of
+// robots.txt for userAgent, if any.
+func newHostLimiter(rate float64, maxConns int, adaptive bool, robots RobotsPolicy, userAgent string) *hostLimiter {
+	return &hostLimiter{
+		rate:      rate,
+		maxConns:  maxConns,
+		adaptive:  adaptive,
+		robots:    robots,
+		userAgent: userAgent,
+		hosts:     map[string]*hostState{},
+	}
+}
+
+// host returns the state of the host of u, created on first use
+func (hl *hostLimiter) host(u *url.URL) *hostState {
+	key := u.Scheme + "://" + u.Host
+
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	if h, found := hl.hosts[key]; found {
+		return h
+	}
+
+	h := &hostState{}
+	if hl.maxConns > 0 {
+		h.conns = make(chan struct{}, hl.maxConns)
+	}
+	hl.hosts[key] = h
+	return h
+}
+
+// baseDelay returns the delay between requests to the host of u: the larger
+// of the delay of rate and its Crawl-delay, capped at maxHostDelay so that
+// a site cannot stall its crawl for longer. robots.txt may be fetched, so
+// it is called without holding a lock; Crawl-delay changes once robots.txt
+// is fetched again after its TTL.
+func (hl *hostLimiter) baseDelay(ctx context.Context, u *url.URL) time.Duration {
+	var delay time.Duration
+	if hl.rate > 0 {
+		delay = time.Duration(float64(time.Second) / hl.rate)
+	}
+	return max(delay, min(hl.robots.CrawlDelay(ctx, u, hl.userAgent), maxHostDelay))
+}
+
+// wait blocks until a request to the host of u is allowed. The returned
+// func must be called with the response (nil when the request failed)
+// and its latency once the request is done. Returns ctx.Err() when ctx
+// is done while waiting.
+func (hl *hostLimiter) wait(ctx context.Context, u *url.URL) (func(*http.Response, time.Duration), error) {
+	h := hl.host(u)
+	baseDelay := hl.baseDelay(ctx, u)
+
+	if h.conns != nil {
+		select {
+		case h.conns <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// reserve the next slot of the host
+	h.mu.Lock()
+	h.setBaseDelay(baseDelay)
+	now := time.Now()
+	slot := now
+	if h.next.After(now) {
+		slot = h.next
+	}
+	h.next = slot.Add(h.delay)
+	h.mu.Unlock()
+
+	sleep(ctx, slot.Sub(now))
+	if err := ctx.Err(); err != nil {
+		h.release()
+		return nil, err
+	}
+
+	return func(resp *http.Response, latency time.Duration) {
+		if hl.adaptive {
+			h.adapt(resp, latency)
+		}
+		h.release()
+	}, nil
+}
+
+// setBaseDelay sets the configured delay of h to d. The current delay follows
+// it unless raised above d by adapt, which reduces it to d over time.
+// h.mu must be held.
+func (h *hostState) setBaseDelay(d time.Duration) {
+	if d == h.baseDelay {
+		return
+	}
+	if h.delay == h.baseDelay || h.delay < d {
+		h.delay = d
+	}
+	h.baseDelay = d
+}
+
+// release frees the connection slot of a request to h
+func (h *hostState) release() {
+	if h.conns != nil {
+		<-h.conns
+	}
+}
+
+// adapt doubles the delay of h on HTTP 429, 5xx, failed requests and rising
+// latency, and reduces the delay by a quarter, down to the configured delay,
+// when the host is healthy. Retry-After of 429 and 503 responses is respected.
+func (h *hostState) adapt(resp *http.Response, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// latency rising well above its average
+	slow := h.latency > 0 && latency > 2*h.latency && latency-h.latency > minLatencyRise
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = (4*h.latency + latency) / 5
+	}
+
+	failed := resp == nil ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+
+	switch {
+	case failed || slow:
+		h.delay = min(max(2*h.delay, minBackoffDelay), maxHostDelay)
+	case h.delay > h.baseDelay:
+		h.delay -= h.delay / 4
+		if h.delay < h.baseDelay+time.Millisecond {
+			h.delay = h.baseDelay
+		}
+	}
+
+	// hold the next request of a failing host for the new delay
+	if failed {
+		wait := h.delay
+		if resp != nil {
+			wait = max(wait, min(parseRetryAfter(resp.Header.Get("Retry-After")), maxHostDelay))
+		}
+		if next := time.Now().Add(wait); next.After(h.next) {
+			h.next = next
+		}
+	}
+}
+
+// parseRetryAfter returns the delay of a Retry-After header in
+// seconds or HTTP date; 0 when empty or invalid
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// crawlDelay returns the Crawl-delay of robotsTxt for userAgent; the delay of the
+// group of userAgent is used over the delay of the global '*' group. Returns 0
+// when robotsTxt has no Crawl-delay for userAgent.
+func crawlDelay(robotsTxt, userAgent string) time.Duration {
+	cd := &crawlDelayExtractor{agent: strings.ToLower(productToken(userAgent))}
+	grobotstxt.Parse(robotsTxt, cd)
+	if cd.specificFound {
+		return cd.specific
+	}
+	return cd.global
+}
+
+// productToken returns the product token of userAgent
+// e.g. 'webcrawlerGo' of 'webcrawlerGo/v1.0 - Web crawler'
+func productToken(userAgent string) string {
+	i := strings.IndexFunc(userAgent, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	})
+	if i < 0 {
+		return userAgent
+	}
+	return userAgent[:i]
+}
+
+// crawlDelayExtractor is a [grobotstxt.ParseHandler] reading the
+// Crawl-delay of the groups of an agent and of the global group
+type crawlDelayExtractor struct {
+	agent string
+
+	inAgents      bool // reading the user-agent lines of a group
+	specificGroup bool // current group is of agent
+	globalGroup   bool // current group is of '*'
+
+	specific      time.Duration
+	specificFound bool
+	global        time.Duration
+}
+
+func (cd *crawlDelayExtractor) HandleRobotsStart() {}
+
+func (cd *crawlDelayExtractor) HandleRobotsEnd() {}
+
+func (cd *crawlDelayExtractor) HandleUserAgent(lineNum int, value string) {
+	// user-agent lines after rules start a new group
+	if !cd.inAgents {
+		cd.inAgents = true
+		cd.specificGroup = false
+		cd.globalGroup = false
+	}
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "*":
+		cd.globalGroup = true
+	case cd.agent != "" && strings.ToLower(productToken(value)) == cd.agent:
+		cd.specificGroup = true
+	}
+}
+
+func (cd *crawlDelayExtractor) HandleAllow(lineNum int, value string) {
+	cd.inAgents = false
+}
+
+func (cd *crawlDelayExtractor) HandleDisallow(lineNum int, value string) {
+	cd.inAgents = false
+}
+
+func (cd *crawlDelayExtractor) HandleSitemap(lineNum int, value string) {}
+
+func (cd *crawlDelayExtractor) HandleUnknownAction(lineNum int, action, value string) {
+	cd.inAgents = false
+	if !strings.EqualFold(action, "crawl-delay") {
+		return
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(seconds) || seconds < 0 {
+		return
+	}
+	// capped before conversion as large values overflow time.Duration
+	delay := time.Duration(min(seconds, maxHostDelay.Seconds()) * float64(time.Second))
+	if cd.specificGroup && !cd.specificFound {
+		cd.specific, cd.specificFound = delay, true
+	}
+	if cd.globalGroup && cd.global == 0 {
+		cd.global = delay
+	}
+}
@@ -0,0 +1,296 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubRobots is a RobotsPolicy allowing all URLs with
+// the Crawl-delay of their host in delays
+type stubRobots struct {
+	mu     sync.Mutex
+	delays map[string]time.Duration // keyed by host
+	block  chan struct{}            // CrawlDelay of blocked.example.com waits for it when not nil
+}
+
+func (r *stubRobots) Allowed(ctx context.Context, u *url.URL, userAgent string) error {
+	return nil
+}
+
+func (r *stubRobots) CrawlDelay(ctx context.Context, u *url.URL, userAgent string) time.Duration {
+	if u.Host == "blocked.example.com" && r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.delays[u.Host]
+}
+
+// setDelay sets the Crawl-delay of host to d
+func (r *stubRobots) setDelay(host string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delays[host] = d
+}
+
+func TestHostLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Delay", func(t *testing.T) {
+		robots := &stubRobots{delays: map[string]time.Duration{
+			"slow.example.com":    2 * time.Second,
+			"stalled.example.com": 24 * time.Hour,
+		}}
+
+		tests := []struct {
+			rate  float64
+			input string
+			want  time.Duration
+		}{
+			{rate: 4, input: "https://example.com/a", want: 250 * time.Millisecond},
+			{rate: 4, input: "https://slow.example.com/a", want: 2 * time.Second},
+			{rate: 0, input: "https://slow.example.com/a", want: 2 * time.Second},
+			{rate: 0, input: "https://example.com/a", want: 0},
+			{rate: 4, input: "https://stalled.example.com/a", want: maxHostDelay},
+			{rate: 1.0 / 120, input: "https://stalled.example.com/a", want: 2 * time.Minute},
+		}
+
+		for _, test := range tests {
+			hl := newHostLimiter(test.rate, 0, false, robots, "webcrawlerGo")
+			if got := hl.baseDelay(ctx, mustParseURL(t, test.input)); got != test.want {
+				t.Errorf("rate: %v, input: %s, got delay %v, want %v", test.rate, test.input, got, test.want)
+			}
+		}
+	})
+
+	t.Run("HostState", func(t *testing.T) {
+		hl := newHostLimiter(0, 0, false, &stubRobots{}, "webcrawlerGo")
+
+		a := hl.host(mustParseURL(t, "https://example.com/a"))
+		b := hl.host(mustParseURL(t, "https://example.com/b"))
+		c := hl.host(mustParseURL(t, "http://example.com/a"))
+		if a != b {
+			t.Error("expected the same state for URLs of a host")
+		}
+		if a == c {
+			t.Error("expected another state for another scheme")
+		}
+	})
+
+	t.Run("CrawlDelayRefresh", func(t *testing.T) {
+		robots := &stubRobots{delays: map[string]time.Duration{"example.com": time.Millisecond}}
+		hl := newHostLimiter(0, 0, true, robots, "webcrawlerGo")
+		u := mustParseURL(t, "https://example.com/")
+
+		done, err := hl.wait(ctx, u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(&http.Response{StatusCode: http.StatusOK}, time.Millisecond)
+
+		h := hl.host(u)
+		robots.setDelay("example.com", 20*time.Millisecond)
+		done, err = hl.wait(ctx, u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(&http.Response{StatusCode: http.StatusOK}, time.Millisecond)
+		if h.baseDelay != 20*time.Millisecond || h.delay != 20*time.Millisecond {
+			t.Errorf("got delay %v (base %v) after Crawl-delay changed, want 20ms", h.delay, h.baseDelay)
+		}
+
+		// a raised adaptive delay is kept over a lower Crawl-delay
+		h.mu.Lock()
+		h.delay = time.Second
+		h.next = time.Time{}
+		h.mu.Unlock()
+		robots.setDelay("example.com", 10*time.Millisecond)
+		done, err = hl.wait(ctx, u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(nil, 0)
+		if h.baseDelay != 10*time.Millisecond || h.delay < time.Second {
+			t.Errorf("got delay %v (base %v), want adaptive delay kept with base 10ms", h.delay, h.baseDelay)
+		}
+	})
+
+	t.Run("CrawlDelayUnlocked", func(t *testing.T) {
+		robots := &stubRobots{delays: map[string]time.Duration{}, block: make(chan struct{})}
+		hl := newHostLimiter(0, 0, false, robots, "webcrawlerGo")
+
+		blocked := make(chan struct{})
+		go func() {
+			defer close(blocked)
+			if done, err := hl.wait(ctx, mustParseURL(t, "https://blocked.example.com/")); err == nil {
+				done(nil, 0)
+			}
+		}()
+
+		// requests to other hosts do not wait for robots.txt of blocked.example.com
+		result := make(chan error)
+		go func() {
+			done, err := hl.wait(ctx, mustParseURL(t, "https://example.com/"))
+			if err == nil {
+				done(nil, 0)
+			}
+			result <- err
+		}()
+		select {
+		case err := <-result:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(time.Second):
+			t.Error("request to another host waited for the Crawl-delay of a host")
+		}
+
+		close(robots.block)
+		<-blocked
+	})
+
+	t.Run("Spacing", func(t *testing.T) {
+		hl := newHostLimiter(20, 0, false, &stubRobots{}, "webcrawlerGo")
+		u := mustParseURL(t, "https://example.com/")
+
+		start := time.Now()
+		for range 3 {
+			done, err := hl.wait(ctx, u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			done(&http.Response{StatusCode: http.StatusOK}, time.Millisecond)
+		}
+		// the first request is not delayed
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("expected 3 requests to take at least 100ms, took %v", elapsed)
+		}
+
+		// other hosts are not delayed
+		start = time.Now()
+		done, err := hl.wait(ctx, mustParseURL(t, "https://example.org/"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(nil, 0)
+		if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+			t.Errorf("expected request to another host not to wait, took %v", elapsed)
+		}
+	})
+
+	t.Run("MaxConns", func(t *testing.T) {
+		hl := newHostLimiter(0, 1, false, &stubRobots{}, "webcrawlerGo")
+		u := mustParseURL(t, "https://example.com/")
+
+		done, err := hl.wait(ctx, u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if _, err := hl.wait(waitCtx, u); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error %v while a request is in progress, want %v", err, context.DeadlineExceeded)
+		}
+
+		done(&http.Response{StatusCode: http.StatusOK}, time.Millisecond)
+		next, err := hl.wait(ctx, u)
+		if err != nil {
+			t.Fatalf("got error %v after the request was done", err)
+		}
+		next(nil, 0)
+	})
+
+	t.Run("Adapt", func(t *testing.T) {
+		h := &hostState{baseDelay: 0}
+
+		h.adapt(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}, time.Millisecond)
+		if h.delay != minBackoffDelay {
+			t.Errorf("got delay %v after 429, want %v", h.delay, minBackoffDelay)
+		}
+		h.adapt(nil, 0)
+		if h.delay != 2*minBackoffDelay {
+			t.Errorf("got delay %v after failed request, want %v", h.delay, 2*minBackoffDelay)
+		}
+
+		for range 20 {
+			h.adapt(&http.Response{StatusCode: http.StatusOK}, time.Millisecond)
+		}
+		if h.delay != h.baseDelay {
+			t.Errorf("got delay %v after healthy responses, want base delay %v", h.delay, h.baseDelay)
+		}
+
+		h = &hostState{delay: 30 * time.Second}
+		h.adapt(&http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}, 0)
+		if h.delay != maxHostDelay {
+			t.Errorf("got delay %v, want it capped at %v", h.delay, maxHostDelay)
+		}
+	})
+
+	t.Run("parseRetryAfter", func(t *testing.T) {
+		tests := []struct {
+			input string
+			want  time.Duration
+		}{
+			{input: "", want: 0},
+			{input: "120", want: 2 * time.Minute},
+			{input: "soon", want: 0},
+			{input: "Wed, 21 Oct 2015 07:28:00 GMT", want: time.Until(time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC))},
+		}
+
+		for _, test := range tests {
+			got := parseRetryAfter(test.input)
+			if diff := got - test.want; diff < -time.Second || diff > time.Second {
+				t.Errorf("input: %q, got %v, want %v", test.input, got, test.want)
+			}
+		}
+	})
+}
+
+func TestCrawlDelay(t *testing.T) {
+	robotsTxt := `User-agent: *
+Crawl-delay: 5
+Disallow: /private
+
+User-agent: googlebot
+User-agent: webcrawlerGo
+Crawl-delay: 0.5
+Allow: /
+`
+
+	tests := []struct {
+		robotsTxt string
+		userAgent string
+		want      time.Duration
+	}{
+		{robotsTxt: robotsTxt, userAgent: "webcrawlerGo/v1.0 - Web crawler", want: 500 * time.Millisecond},
+		{robotsTxt: robotsTxt, userAgent: "GoogleBot", want: 500 * time.Millisecond},
+		{robotsTxt: robotsTxt, userAgent: "otherbot", want: 5 * time.Second},
+		{robotsTxt: "User-agent: *\nDisallow: /\n", userAgent: "webcrawlerGo", want: 0},
+		{robotsTxt: "User-agent: *\nCrawl-delay: -1\n", userAgent: "webcrawlerGo", want: 0},
+		{robotsTxt: "User-agent: *\nCrawl-delay: NaN\n", userAgent: "webcrawlerGo", want: 0},
+		{robotsTxt: "User-agent: *\nCrawl-delay: 86400\n", userAgent: "webcrawlerGo", want: maxHostDelay},
+		{robotsTxt: "User-agent: *\nCrawl-delay: 1e300\n", userAgent: "webcrawlerGo", want: maxHostDelay},
+	}
+
+	for i, test := range tests {
+		if got := crawlDelay(test.robotsTxt, test.userAgent); got != test.want {
+			t.Errorf("index# %d, user agent: %s, got %v, want %v", i, test.userAgent, got, test.want)
+		}
+	}
+
+	for input, want := range map[string]string{
+		"webcrawlerGo/v1.0 - Web crawler": "webcrawlerGo",
+		"my_bot":                          "my_bot",
+		"":                                "",
+	} {
+		if got := productToken(input); got != want {
+			t.Errorf("user agent: %q, got product token %q, want %q", input, got, want)
+		}
+	}
+}
@@ -1,15 +1,18 @@
 package queue
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
 var (
 	ErrEmptyQueue   = errors.New("queue is empty")
 	ErrItemNotFound = errors.New("item never pushed to queue")
 	ErrOutOfRange   = errors.New("n is greater than length of queue")
+	ErrQueueDrained = errors.New("queue is empty and no item is in flight")
 )
 
 // bool in map represents whether the item should be processed
@@ -18,9 +21,13 @@ var (
 
 // UniqueQueue holds unique values in its queue
 type UniqueQueue struct {
-	queue  []string
-	strMap map[string]bool
-	mu     sync.Mutex
+	queue    []string
+	strMap   map[string]bool
+	inFlight int           // items handed out by Dequeue and not yet marked Done
+	waiting  int           // items to be appended by InsertAfter
+	cleared  int           // times Clear was called; items of InsertAfter are dropped once cleared
+	changed  chan struct{} // closed and replaced whenever queue, inFlight or waiting changes
+	mu       sync.Mutex
 }
 
 // NewQueue returns a pointer to new UniqueQueue
@@ -28,13 +35,18 @@ type UniqueQueue struct {
 // Care: Map is case-sensitive. Use strings.ToLower on key to make case-insensitive.
 func NewQueue() *UniqueQueue {
 	return &UniqueQueue{
-		strMap: map[string]bool{},
-		mu:     sync.Mutex{},
+		strMap:  map[string]bool{},
+		changed: make(chan struct{}),
+		mu:      sync.Mutex{},
 	}
 }
 
 // View the first n items of the queue
+//
+// Thread safe.
 func (q *UniqueQueue) View(n int) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	if len(q.queue) >= n {
 		return fmt.Sprint(q.queue[:n]), nil
 	}
@@ -42,15 +54,42 @@ func (q *UniqueQueue) View(n int) (string, error) {
 }
 
 // Size returns the size of queue
+//
+// Thread safe.
 func (q *UniqueQueue) Size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 	return len(q.queue)
 }
 
+// Items returns a copy of the items waiting in the queue
+//
+// Thread safe.
+func (q *UniqueQueue) Items() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	items := make([]string, len(q.queue))
+	copy(items, q.queue)
+	return items
+}
+
 // IsEmpty tells if queue is empty or not
+//
+// Thread safe.
 func (q *UniqueQueue) IsEmpty() bool {
 	return q.Size() == 0
 }
 
+// InFlight returns the number of items handed out by Dequeue
+// which are yet to be marked Done.
+//
+// Thread safe.
+func (q *UniqueQueue) InFlight() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.inFlight
+}
+
 // FirstEncounter returns true if the item was seen for the first time by the queue in its lifetime
 func (q *UniqueQueue) FirstEncounter(item string) bool {
 	return !isPresentInMap(item, q.strMap)
@@ -104,11 +143,40 @@ func (q *UniqueQueue) Insert(item string) (success bool) {
 	if q.FirstEncounter(item) {
 		q.strMap[item] = false
 		q.queue = append(q.queue, item)
+		q.notify()
 		success = true
 	}
 	return
 }
 
+// Reserve marks item as seen without appending it to the queue, so that
+// the item can be stored before it is handed out by Dequeue. Returns true
+// if item was never seen before; the caller then appends it with Push.
+//
+// Thread safe.
+func (q *UniqueQueue) Reserve(item string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.FirstEncounter(item) {
+		q.strMap[item] = false
+		return true
+	}
+	return false
+}
+
+// Push appends item reserved with Reserve to the queue, keeping its map value.
+//
+// Thread safe.
+func (q *UniqueQueue) Push(item string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !isPresentInMap(item, q.strMap) {
+		q.strMap[item] = false
+	}
+	q.queue = append(q.queue, item)
+	q.notify()
+}
+
 // InsertForce appends item to the queue WITHOUT checking that the item was seen before.
 // Useful when item was not processed successfully and/or needs to be reprocessed. Or while
 // bulk loading from unique set.
@@ -123,16 +191,44 @@ func (q *UniqueQueue) InsertForce(item string) {
 	defer q.mu.Unlock()
 	q.strMap[item] = false
 	q.queue = append(q.queue, item)
+	q.notify()
+}
+
+// InsertAfter is InsertForce once delay has passed, e.g. to retry an item
+// later without holding the one processing it. Dequeue does not report
+// the queue drained while items wait to be appended; Clear drops them.
+//
+// Thread safe.
+func (q *UniqueQueue) InsertAfter(item string, delay time.Duration) {
+	q.mu.Lock()
+	q.waiting++
+	cleared := q.cleared
+	q.mu.Unlock()
+
+	time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if q.cleared != cleared {
+			return
+		}
+		q.waiting--
+		q.strMap[item] = false
+		q.queue = append(q.queue, item)
+		q.notify()
+	})
 }
 
 // Remove pops first item from the queue.
 // Returns ErrEmptyQueue when empty.
 //
+// Items popped by Remove are not tracked as in flight,
+// use Dequeue when the item is to be processed.
+//
 // Thread safe.
 func (q *UniqueQueue) Remove() (string, error) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	if !q.IsEmpty() {
+	if len(q.queue) > 0 {
 		var x string
 		x, q.queue = q.queue[0], q.queue[1:]
 		return x, nil
@@ -140,15 +236,74 @@ func (q *UniqueQueue) Remove() (string, error) {
 	return "", ErrEmptyQueue
 }
 
-// Clear removes all items from the queue but not from its map
+// Dequeue pops first item from the queue and marks it as in flight.
+// Caller must call Done once the item is processed.
+//
+// When the queue is empty Dequeue blocks until an item is inserted,
+// returns ErrQueueDrained when the queue is empty and no item is
+// in flight or waiting (no one is left to insert new items) and returns
+// ctx.Err() when ctx is done.
+//
+// Thread safe.
+func (q *UniqueQueue) Dequeue(ctx context.Context) (string, error) {
+	for {
+		q.mu.Lock()
+		if len(q.queue) > 0 {
+			var x string
+			x, q.queue = q.queue[0], q.queue[1:]
+			q.inFlight++
+			q.notify()
+			q.mu.Unlock()
+			return x, nil
+		}
+		if q.inFlight == 0 && q.waiting == 0 {
+			q.mu.Unlock()
+			return "", ErrQueueDrained
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Done marks an item handed out by Dequeue as processed.
+// Items inserted while processing must be inserted before calling Done.
+//
+// Thread safe.
+func (q *UniqueQueue) Done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inFlight > 0 {
+		q.inFlight--
+		q.notify()
+	}
+}
+
+// Clear removes all items from the queue, and those waiting to be
+// appended, but not from its map
 func (q *UniqueQueue) Clear() {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	if len(q.queue) > 0 {
+	if len(q.queue) > 0 || q.waiting > 0 {
 		q.queue = nil
+		q.waiting = 0
+		q.cleared++
+		q.notify()
 	}
 }
 
+// notify wakes up all the goroutines waiting in Dequeue.
+// Must be called with q.mu held.
+func (q *UniqueQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
 // isPresentInMap checks if item is present in hashmap
 func isPresentInMap(item string, hashmap map[string]bool) bool {
 	_, ok := hashmap[item]
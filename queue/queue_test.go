@@ -1,8 +1,10 @@
 package queue
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 )
 
 func TestUniqueQueue(t *testing.T) {
@@ -77,6 +79,40 @@ func TestUniqueQueue(t *testing.T) {
 		}
 	})
 
+	t.Run("ReservePush", func(t *testing.T) {
+		queue := NewQueue()
+
+		if !queue.Reserve("item1") {
+			t.Fatal("expected first reserve of item1 to succeed")
+		}
+		if queue.Reserve("item1") || queue.Insert("item1") {
+			t.Error("expected reserved item1 to be seen")
+		}
+		if size := queue.Size(); size != 0 {
+			t.Errorf("reserved item should not be queued, got size %d", size)
+		}
+
+		// a reserved item is not handed out until pushed, e.g. while it is stored
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if item, err := queue.Dequeue(ctx); err == nil {
+			t.Errorf("expected reserved item not to be dequeued, got %s", item)
+		}
+
+		queue.SetMapValue("item1", true)
+		queue.Push("item1")
+		if size := queue.Size(); size != 1 {
+			t.Errorf("expected queue size 1 after push, got %d", size)
+		}
+		if v, _ := queue.GetMapValue("item1"); !v {
+			t.Error("push should keep the map value of item1")
+		}
+		if item, err := queue.Dequeue(context.Background()); err != nil || item != "item1" {
+			t.Errorf("expected item1 to be dequeued after push, got %s, %v", item, err)
+		}
+		queue.Done()
+	})
+
 	t.Run("Remove", func(t *testing.T) {
 		queue := NewQueue()
 
@@ -199,4 +235,133 @@ func TestUniqueQueue(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("Items", func(t *testing.T) {
+		queue := NewQueue()
+		queue.Insert("item1")
+		queue.Insert("item2")
+
+		items := queue.Items()
+		if len(items) != 2 || items[0] != "item1" || items[1] != "item2" {
+			t.Errorf("got items %v, want [item1 item2]", items)
+		}
+
+		// modifying returned slice should not modify the queue
+		items[0] = "changed"
+		if got, _ := queue.View(1); got != "[item1]" {
+			t.Errorf("queue modified through Items, got %s", got)
+		}
+	})
+
+	t.Run("DequeueAndDone", func(t *testing.T) {
+		queue := NewQueue()
+		ctx := context.Background()
+
+		// empty queue with nothing in flight is drained
+		if _, err := queue.Dequeue(ctx); !errors.Is(err, ErrQueueDrained) {
+			t.Errorf("empty queue: got error %q, wanted: %q", err, ErrQueueDrained)
+		}
+
+		queue.Insert("item1")
+		got, err := queue.Dequeue(ctx)
+		if got != "item1" || err != nil {
+			t.Errorf("got: %q, %v, wanted: %q, nil", got, err, "item1")
+		}
+		if queue.InFlight() != 1 {
+			t.Errorf("expected 1 item in flight, got %d", queue.InFlight())
+		}
+
+		// Dequeue should block while item1 is in flight and
+		// return the item inserted while processing item1
+		result := make(chan string)
+		go func() {
+			item, _ := queue.Dequeue(ctx)
+			result <- item
+		}()
+
+		select {
+		case item := <-result:
+			t.Fatalf("Dequeue returned %q while queue was empty", item)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		queue.Insert("item2")
+		queue.Done()
+
+		select {
+		case item := <-result:
+			if item != "item2" {
+				t.Errorf("got: %q, wanted: %q", item, "item2")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Dequeue did not return after insert")
+		}
+
+		// waiting Dequeue should return ErrQueueDrained once
+		// the last in flight item is done
+		drained := make(chan error)
+		go func() {
+			_, err := queue.Dequeue(ctx)
+			drained <- err
+		}()
+		queue.Done()
+
+		select {
+		case err := <-drained:
+			if !errors.Is(err, ErrQueueDrained) {
+				t.Errorf("got error: %q, wanted: %q", err, ErrQueueDrained)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Dequeue did not return after last item was done")
+		}
+	})
+
+	t.Run("InsertAfter", func(t *testing.T) {
+		queue := NewQueue()
+		ctx := context.Background()
+
+		queue.Insert("item1")
+		queue.Dequeue(ctx)
+		queue.InsertAfter("item1", 50*time.Millisecond)
+		queue.Done()
+		if queue.Size() != 0 {
+			t.Errorf("queue size should be: 0, got: %d", queue.Size())
+		}
+
+		// Dequeue should wait for the item instead of reporting drained
+		start := time.Now()
+		got, err := queue.Dequeue(ctx)
+		if got != "item1" || err != nil {
+			t.Errorf("got: %q, %v, wanted: %q, nil", got, err, "item1")
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("item appended after %v, wanted at least %v", elapsed, 50*time.Millisecond)
+		}
+
+		// cleared items are dropped
+		queue.InsertAfter("item2", 10*time.Millisecond)
+		queue.Clear()
+		queue.Done()
+		if _, err := queue.Dequeue(ctx); !errors.Is(err, ErrQueueDrained) {
+			t.Errorf("cleared queue: got error %q, wanted: %q", err, ErrQueueDrained)
+		}
+		time.Sleep(30 * time.Millisecond)
+		if queue.Size() != 0 {
+			t.Errorf("queue size should be: 0, got: %d", queue.Size())
+		}
+	})
+
+	t.Run("DequeueContextCancel", func(t *testing.T) {
+		queue := NewQueue()
+		queue.Insert("item1")
+		queue.Dequeue(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := queue.Dequeue(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error: %q, wanted: %q", err, context.DeadlineExceeded)
+		}
+	})
 }
@@ -0,0 +1,283 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jimsmart/grobotstxt"
+
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// DefaultRobotsTTL is the time after which robots.txt
+// of a host is fetched again by RobotsCache
+var DefaultRobotsTTL = 24 * time.Hour
+
+const (
+	// following google's policy, hosts are not crawled for the first 12 hours
+	// robots.txt is unreachable; then the last reachable robots.txt is used
+	// see: https://developers.google.com/search/docs/crawling-indexing/robots/robots_txt#http-status-codes
+	robotsUnreachableDisallow = 12 * time.Hour
+	robotsRetryInterval       = time.Minute // unreachable robots.txt is fetched again after
+	maxRobotsTxtSize          = 500 << 10   // robots.txt is read upto 500 KiB
+)
+
+var (
+	ErrRobotsDisallowed  = errors.New("not allowed by robots.txt")
+	ErrRobotsUnreachable = errors.New("robots.txt unreachable, host is not crawled for now")
+)
+
+// RobotsPolicy tells crawlers which URLs they may fetch and how long to wait
+// between requests to a host as per its robots.txt
+type RobotsPolicy interface {
+	// Allowed returns nil when userAgent may fetch u, ErrRobotsDisallowed
+	// or ErrRobotsUnreachable when it may not, or the error encountered
+	// while getting robots.txt of the host of u.
+	Allowed(ctx context.Context, u *url.URL, userAgent string) error
+
+	// CrawlDelay returns the Crawl-delay of the host of u for userAgent;
+	// 0 when robots.txt has no Crawl-delay for userAgent.
+	CrawlDelay(ctx context.Context, u *url.URL, userAgent string) time.Duration
+}
+
+// RobotsCache is the default RobotsPolicy. It fetches robots.txt of a host
+// when its first URL is checked and again after TTL. Versions of robots.txt
+// are written to Store, when not nil, so that they are kept across runs.
+//
+// As per google's policy, HTTP 4xx (except 429) means no restrictions.
+// While robots.txt is unreachable (HTTP 429, 5xx and failed requests) the host
+// is fully disallowed for 12 hours, then the last reachable version is used,
+// or no restrictions when robots.txt was never reached.
+type RobotsCache struct {
+	Store   models.RobotsModel // optional; robots.txt is kept in memory when nil
+	TTL     time.Duration      // time after which robots.txt is fetched again; DefaultRobotsTTL when 0
+	Client  *http.Client       // client used to fetch robots.txt; replace to use a TransportConfig
+	Fetcher Fetcher            // fetches robots.txt instead of Client when not nil e.g. to record or replay a crawl
+
+	mu    sync.Mutex
+	hosts map[string]*robotsHost // keyed by <scheme>://<host>
+}
+
+// robotsHost holds the versions of robots.txt of a host
+type robotsHost struct {
+	mu        sync.Mutex
+	loaded    bool              // versions were read from store
+	latest    *models.RobotsTxt // latest version; nil before first fetch
+	reachable *models.RobotsTxt // latest reachable version; nil when never reached
+}
+
+// NewRobotsCache returns pointer to a new RobotsCache writing versions of
+// robots.txt to store, when not nil, and fetching robots.txt again after ttl.
+// robots.txt is fetched with a client with 5s timeout.
+func NewRobotsCache(store models.RobotsModel, ttl time.Duration) *RobotsCache {
+	return &RobotsCache{
+		Store:  store,
+		TTL:    ttl,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Allowed implements RobotsPolicy
+func (rc *RobotsCache) Allowed(ctx context.Context, u *url.URL, userAgent string) error {
+	rt, err := rc.get(ctx, u, userAgent)
+	if err != nil {
+		return err
+	}
+	// only 2xx responses have rules
+	if rt.StatusCode >= http.StatusMultipleChoices {
+		return nil
+	}
+	if !grobotstxt.AgentAllowed(rt.Content, userAgent, u.String()) {
+		return ErrRobotsDisallowed
+	}
+	return nil
+}
+
+// CrawlDelay implements RobotsPolicy
+func (rc *RobotsCache) CrawlDelay(ctx context.Context, u *url.URL, userAgent string) time.Duration {
+	rt, err := rc.get(ctx, u, userAgent)
+	if err != nil || rt.StatusCode >= http.StatusMultipleChoices {
+		return 0
+	}
+	return crawlDelay(rt.Content, userAgent)
+}
+
+// get returns the version of robots.txt of the host of u to apply now.
+// robots.txt is fetched by the first caller for the host; other callers
+// wait for it. Returns ErrRobotsUnreachable when the host is disallowed.
+func (rc *RobotsCache) get(ctx context.Context, u *url.URL, userAgent string) (*models.RobotsTxt, error) {
+	host := u.Scheme + "://" + u.Host
+
+	rc.mu.Lock()
+	if rc.hosts == nil {
+		rc.hosts = map[string]*robotsHost{}
+	}
+	h, found := rc.hosts[host]
+	if !found {
+		h = &robotsHost{}
+		rc.hosts[host] = h
+	}
+	rc.mu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.loaded && rc.Store != nil {
+		if err := h.load(ctx, rc.Store, host); err != nil {
+			return nil, fmt.Errorf("could not read robots.txt of %s: %w", host, err)
+		}
+	}
+	h.loaded = true
+
+	now := time.Now()
+	if h.stale(now, rc.ttl()) {
+		if err := rc.refresh(ctx, h, host, userAgent); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case h.latest.Reachable():
+		return h.latest, nil
+	case now.Sub(h.latest.FetchedAt) < robotsUnreachableDisallow:
+		return nil, ErrRobotsUnreachable
+	case h.reachable != nil:
+		return h.reachable, nil
+	default:
+		// no restrictions when robots.txt was never reached
+		return &models.RobotsTxt{Host: host, StatusCode: http.StatusNotFound}, nil
+	}
+}
+
+// ttl returns TTL or DefaultRobotsTTL when not set
+func (rc *RobotsCache) ttl() time.Duration {
+	if rc.TTL > 0 {
+		return rc.TTL
+	}
+	return DefaultRobotsTTL
+}
+
+// load reads the latest versions of robots.txt of host from store
+func (h *robotsHost) load(ctx context.Context, store models.RobotsModel, host string) error {
+	latest, err := store.GetLatest(ctx, host, false)
+	if err != nil {
+		if errors.Is(err, models.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	h.latest = latest
+	if latest.Reachable() {
+		h.reachable = latest
+		return nil
+	}
+
+	reachable, err := store.GetLatest(ctx, host, true)
+	if err != nil && !errors.Is(err, models.ErrRecordNotFound) {
+		return err
+	}
+	h.reachable = reachable
+	return nil
+}
+
+// stale tells if robots.txt of h should be fetched again
+func (h *robotsHost) stale(now time.Time, ttl time.Duration) bool {
+	switch {
+	case h.latest == nil:
+		return true
+	case h.latest.Reachable():
+		return now.Sub(h.latest.CheckedAt) >= ttl
+	default:
+		return now.Sub(h.latest.CheckedAt) >= robotsRetryInterval
+	}
+}
+
+// refresh fetches robots.txt of host and writes it to store as a new
+// version when its status or content changed. Consecutive unreachable
+// responses are the same version, so that the version tells since when
+// robots.txt is unreachable.
+func (rc *RobotsCache) refresh(ctx context.Context, h *robotsHost, host, userAgent string) error {
+	var fetcher Fetcher = &HTTPFetcher{Client: rc.Client}
+	if rc.Fetcher != nil {
+		fetcher = rc.Fetcher
+	}
+	fetched := fetchRobotsTxt(ctx, fetcher, host, userAgent)
+	if ctx.Err() != nil {
+		// keep the current version when shutting down
+		if h.latest == nil {
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	sameVersion := h.latest != nil &&
+		h.latest.Reachable() == fetched.Reachable() &&
+		(!fetched.Reachable() ||
+			h.latest.StatusCode == fetched.StatusCode && h.latest.Content == fetched.Content)
+
+	if sameVersion {
+		h.latest.CheckedAt = fetched.CheckedAt
+		if rc.Store != nil {
+			if err := rc.Store.Update(ctx, h.latest); err != nil {
+				return fmt.Errorf("could not update robots.txt of %s: %w", host, err)
+			}
+		}
+		return nil
+	}
+
+	if rc.Store != nil {
+		if err := rc.Store.Insert(ctx, fetched); err != nil {
+			return fmt.Errorf("could not save robots.txt of %s: %w", host, err)
+		}
+	}
+	h.latest = fetched
+	if fetched.Reachable() {
+		h.reachable = fetched
+	}
+	return nil
+}
+
+// fetchRobotsTxt fetches <host>/robots.txt with fetcher and userAgent. Failed
+// requests are returned as a version with status code 0 and the error.
+func fetchRobotsTxt(ctx context.Context, fetcher Fetcher, host, userAgent string) *models.RobotsTxt {
+	unreachable := func(statusCode int, err error) *models.RobotsTxt {
+		return models.NewRobotsTxt(host, statusCode, "", err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host+"/robots.txt", nil)
+	if err != nil {
+		return unreachable(0, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := fetcher.Fetch(req)
+	if err != nil {
+		return unreachable(0, fmt.Errorf("could not get robots.txt, error: %v", err))
+	}
+	defer resp.Body.Close()
+
+	rt := models.NewRobotsTxt(host, resp.StatusCode, "", "")
+	if !rt.Reachable() {
+		return unreachable(resp.StatusCode, fmt.Errorf(
+			"could not get robots.txt, received HTTP status %d: %s",
+			resp.StatusCode,
+			http.StatusText(resp.StatusCode),
+		))
+	}
+
+	// body of 4xx responses is not robots.txt
+	if resp.StatusCode < http.StatusMultipleChoices {
+		respBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsTxtSize))
+		if err != nil {
+			return unreachable(0, fmt.Errorf("error while reading robots.txt: %v", err))
+		}
+		rt.Content = string(respBytes)
+	}
+	return rt
+}
@@ -0,0 +1,189 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// robotsServer is a test server of robots.txt whose response can be changed
+type robotsServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	status  int // 0 fails the request
+	content string
+	fetches int
+}
+
+// newRobotsServer returns a started robotsServer serving robots.txt with status and content
+func newRobotsServer(t *testing.T, status int, content string) *robotsServer {
+	t.Helper()
+	rs := &robotsServer{status: status, content: content}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs.mu.Lock()
+		rs.fetches++
+		status, content := rs.status, rs.content
+		rs.mu.Unlock()
+		if status == 0 {
+			panic(http.ErrAbortHandler)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, content)
+	}))
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+// set changes the response of robots.txt
+func (rs *robotsServer) set(status int, content string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.status, rs.content = status, content
+}
+
+// fetchCount returns the number of requests for robots.txt
+func (rs *robotsServer) fetchCount() int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.fetches
+}
+
+// ageRobots moves the times of the cached versions of robots.txt
+// of host back by d, as if d passed since they were fetched
+func ageRobots(rc *RobotsCache, host string, d time.Duration) {
+	rc.mu.Lock()
+	h := rc.hosts[host]
+	rc.mu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latest.FetchedAt = h.latest.FetchedAt.Add(-d)
+	h.latest.CheckedAt = h.latest.CheckedAt.Add(-d)
+	if h.reachable != nil && h.reachable != h.latest {
+		h.reachable.FetchedAt = h.reachable.FetchedAt.Add(-d)
+		h.reachable.CheckedAt = h.reachable.CheckedAt.Add(-d)
+	}
+}
+
+func TestRobotsCache(t *testing.T) {
+	const robotsTxt = "User-agent: *\nDisallow: /private\n"
+	ctx := context.Background()
+
+	// checkAllowed checks the errors of Allowed for the paths of want on srv
+	checkAllowed := func(t *testing.T, rc *RobotsCache, srv *robotsServer, want map[string]error) {
+		t.Helper()
+		for path, wantErr := range want {
+			if err := rc.Allowed(ctx, mustParseURL(t, srv.URL+path), "webcrawlerGo"); !errors.Is(err, wantErr) {
+				t.Errorf("path: %s, got error %v, want %v", path, err, wantErr)
+			}
+		}
+	}
+
+	// checkFetches checks the number of fetches of robots.txt of srv
+	checkFetches := func(t *testing.T, srv *robotsServer, want int) {
+		t.Helper()
+		if got := srv.fetchCount(); got != want {
+			t.Errorf("got %d fetches of robots.txt, want %d", got, want)
+		}
+	}
+
+	t.Run("Status", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			status  int
+			private error // error of the path disallowed by robots.txt
+			public  error
+		}{
+			{name: "2xx", status: http.StatusOK, private: ErrRobotsDisallowed},
+			{name: "4xx", status: http.StatusNotFound},
+			{name: "403", status: http.StatusForbidden},
+			{name: "429", status: http.StatusTooManyRequests, private: ErrRobotsUnreachable, public: ErrRobotsUnreachable},
+			{name: "5xx", status: http.StatusServiceUnavailable, private: ErrRobotsUnreachable, public: ErrRobotsUnreachable},
+			{name: "failed request", status: 0, private: ErrRobotsUnreachable, public: ErrRobotsUnreachable},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				srv := newRobotsServer(t, test.status, robotsTxt)
+				rc := &RobotsCache{Client: srv.Client()}
+				checkAllowed(t, rc, srv, map[string]error{"/private/a": test.private, "/a": test.public})
+				checkFetches(t, srv, 1)
+			})
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		srv := newRobotsServer(t, http.StatusOK, robotsTxt)
+		rc := &RobotsCache{Client: srv.Client(), TTL: time.Hour}
+
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": ErrRobotsDisallowed})
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": ErrRobotsDisallowed})
+		checkFetches(t, srv, 1)
+
+		// changes apply once robots.txt is fetched again after TTL
+		srv.set(http.StatusOK, "User-agent: *\nDisallow:\n")
+		ageRobots(rc, srv.URL, 59*time.Minute)
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": ErrRobotsDisallowed})
+		checkFetches(t, srv, 1)
+
+		ageRobots(rc, srv.URL, time.Minute)
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": nil})
+		checkFetches(t, srv, 2)
+
+		// a version fetched again is only checked again
+		ageRobots(rc, srv.URL, time.Hour)
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": nil})
+		checkFetches(t, srv, 3)
+		latest := rc.hosts[srv.URL].latest
+		if !latest.FetchedAt.Before(latest.CheckedAt) {
+			t.Errorf("got fetched at %v, checked at %v, want the version kept", latest.FetchedAt, latest.CheckedAt)
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		srv := newRobotsServer(t, http.StatusOK, robotsTxt)
+		rc := &RobotsCache{Client: srv.Client(), TTL: time.Hour}
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": ErrRobotsDisallowed, "/a": nil})
+
+		// the host is disallowed while robots.txt is unreachable
+		srv.set(http.StatusInternalServerError, "")
+		ageRobots(rc, srv.URL, time.Hour)
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": ErrRobotsUnreachable, "/a": ErrRobotsUnreachable})
+		checkFetches(t, srv, 2)
+		if got := rc.CrawlDelay(ctx, mustParseURL(t, srv.URL+"/a"), "webcrawlerGo"); got != 0 {
+			t.Errorf("got Crawl-delay %v, want 0", got)
+		}
+
+		// unreachable robots.txt is fetched again after robotsRetryInterval
+		ageRobots(rc, srv.URL, robotsRetryInterval)
+		checkAllowed(t, rc, srv, map[string]error{"/a": ErrRobotsUnreachable})
+		checkFetches(t, srv, 3)
+
+		// then the last reachable version applies
+		ageRobots(rc, srv.URL, robotsUnreachableDisallow)
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": ErrRobotsDisallowed, "/a": nil})
+		checkFetches(t, srv, 4)
+
+		// until robots.txt is reachable again
+		srv.set(http.StatusOK, "User-agent: *\nDisallow:\n")
+		ageRobots(rc, srv.URL, robotsRetryInterval)
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": nil})
+		checkFetches(t, srv, 5)
+	})
+
+	t.Run("NeverReached", func(t *testing.T) {
+		srv := newRobotsServer(t, 0, "")
+		rc := &RobotsCache{Client: srv.Client()}
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": ErrRobotsUnreachable})
+
+		// no restrictions after robotsUnreachableDisallow
+		ageRobots(rc, srv.URL, robotsUnreachableDisallow)
+		checkAllowed(t, rc, srv, map[string]error{"/private/a": nil, "/a": nil})
+		checkFetches(t, srv, 2)
+	})
+}
@@ -0,0 +1,314 @@
+// Package schedule parses cron expressions, intervals and
+// daily time windows used to run recurring crawls.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the activation times of a recurring job
+type Schedule interface {
+	// Next returns the next activation time after t
+	Next(t time.Time) time.Time
+}
+
+// Interval activates every d
+type Interval time.Duration
+
+// Next returns t + interval
+func (i Interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+func (i Interval) String() string {
+	return "@every " + time.Duration(i).String()
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses spec as one of:
+//   - a cron expression with 5 fields: minute hour day-of-month month day-of-week
+//     e.g. "30 3 * * 1-5"; fields accept '*', lists, ranges, steps and
+//     3 letter month and day names
+//   - a macro: @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly
+//   - an interval: "@every 6h" or a duration e.g. "6h"; minimum 1m
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errors.New("empty spec")
+	}
+
+	if expr, ok := macros[strings.ToLower(spec)]; ok {
+		spec = expr
+	}
+
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		return parseInterval(strings.TrimSpace(every))
+	}
+	if d, err := time.ParseDuration(spec); err == nil {
+		return parseInterval(d.String())
+	}
+
+	return parseCron(spec)
+}
+
+func parseInterval(s string) (Interval, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q", s)
+	}
+	if d < time.Minute {
+		return 0, fmt.Errorf("interval %s is less than 1m", d)
+	}
+	return Interval(d), nil
+}
+
+// Cron activates on the minutes matching a cron expression
+type Cron struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64 // bit i is set when value i matches
+	domRestricted, dowRestricted  bool   // field was not '*'
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    []string // names of values starting at min
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day-of-month", min: 1, max: 31}
+	monthField  = field{
+		name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"},
+	}
+	// 7 is also Sunday
+	dowField = field{
+		name: "day-of-week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"},
+	}
+)
+
+func parseCron(spec string) (*Cron, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression %q, got %d", spec, len(fields))
+	}
+
+	c := &Cron{spec: spec}
+	var err error
+	if c.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if c.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if c.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if c.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if c.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// Sunday is 0 or 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domRestricted = fields[2] != "*"
+	c.dowRestricted = fields[4] != "*"
+	return c, nil
+}
+
+// parse returns the bitset of values matched by s
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case rangePart == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			loPart, hiPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = f.value(loPart); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiPart); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+			hi = lo
+			// "5/10" means from 5 to max every 10
+			if hasStep {
+				hi = f.max
+			}
+		}
+
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << i
+		}
+	}
+	return bits, nil
+}
+
+// value returns the number or name s as a value of f
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, must be %d-%d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first minute after t matching c in the location of t.
+// Returns zero time when no time matches within 5 years e.g. "0 0 30 2 *".
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches tells if the day of t matches c. As in cron, when both
+// day-of-month and day-of-week are restricted either may match.
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+func (c *Cron) String() string {
+	return c.spec
+}
+
+// Window is a daily time window e.g. quiet hours from 22:00 to 06:00
+type Window struct {
+	start, end int // minutes since midnight; end < start when the window spans midnight
+}
+
+// ParseWindow parses s of the form "HH:MM-HH:MM"
+func ParseWindow(s string) (Window, error) {
+	startPart, endPart, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %q, must be HH:MM-HH:MM", s)
+	}
+
+	start, err := parseClock(startPart)
+	if err != nil {
+		return Window{}, err
+	}
+	end, err := parseClock(endPart)
+	if err != nil {
+		return Window{}, err
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("empty window %q", s)
+	}
+	return Window{start: start, end: end}, nil
+}
+
+// parseClock returns the minutes since midnight of "HH:MM"
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains tells if t is inside w in the location of t
+func (w Window) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return m >= w.start && m < w.end
+	}
+	return m >= w.start || m < w.end
+}
+
+// End returns the first time after t when w ends
+func (w Window) End(t time.Time) time.Time {
+	end := time.Date(t.Year(), t.Month(), t.Day(), w.end/60, w.end%60, 0, 0, t.Location())
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// Start returns the first time after t when w starts
+func (w Window) Start(t time.Time) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), w.start/60, w.start%60, 0, 0, t.Location())
+	if !start.After(t) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start
+}
+
+func (w Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.start/60, w.start%60, w.end/60, w.end%60)
+}
+
+// MarshalText implements [encoding.TextMarshaler]
+func (w Window) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
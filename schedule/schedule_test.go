@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Next", func(t *testing.T) {
+		// Wednesday
+		from := time.Date(2024, time.January, 10, 10, 30, 15, 0, time.UTC)
+
+		tests := []struct {
+			spec string
+			want time.Time
+		}{
+			{spec: "*/15 * * * *", want: time.Date(2024, time.January, 10, 10, 45, 0, 0, time.UTC)},
+			{spec: "0 3 * * *", want: time.Date(2024, time.January, 11, 3, 0, 0, 0, time.UTC)},
+			{spec: "@daily", want: time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC)},
+			{spec: "30 9 * * mon-fri", want: time.Date(2024, time.January, 11, 9, 30, 0, 0, time.UTC)},
+			{spec: "0 0 * * 7", want: time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+			{spec: "0 12 1 feb *", want: time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC)},
+			// day-of-month or day-of-week when both are restricted
+			{spec: "0 0 15 * fri", want: time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)},
+			{spec: "5,35 10-11 * * *", want: time.Date(2024, time.January, 10, 10, 35, 0, 0, time.UTC)},
+			{spec: "6h", want: from.Add(6 * time.Hour)},
+			{spec: "@every 90m", want: from.Add(90 * time.Minute)},
+		}
+
+		for _, test := range tests {
+			s, err := Parse(test.spec)
+			if err != nil {
+				t.Errorf("spec: %q, unexpected error: %v", test.spec, err)
+				continue
+			}
+			if got := s.Next(from); !got.Equal(test.want) {
+				t.Errorf("spec: %q, got %s, want %s", test.spec, got, test.want)
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "30s"} {
+			if _, err := Parse(spec); err == nil {
+				t.Errorf("spec: %q, expected error", spec)
+			}
+		}
+	})
+}
+
+func TestWindow(t *testing.T) {
+	w, err := ParseWindow("22:00-06:30")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	day := func(h, m int) time.Time {
+		return time.Date(2024, time.January, 10, h, m, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{at: day(21, 59), want: false},
+		{at: day(22, 0), want: true},
+		{at: day(3, 0), want: true},
+		{at: day(6, 30), want: false},
+	}
+	for _, test := range tests {
+		if got := w.Contains(test.at); got != test.want {
+			t.Errorf("at: %s, got %t, want %t", test.at.Format("15:04"), got, test.want)
+		}
+	}
+
+	if got, want := w.End(day(23, 0)), day(6, 30).AddDate(0, 0, 1); !got.Equal(want) {
+		t.Errorf("end: got %s, want %s", got, want)
+	}
+	if got, want := w.End(day(3, 0)), day(6, 30); !got.Equal(want) {
+		t.Errorf("end: got %s, want %s", got, want)
+	}
+
+	if _, err := ParseWindow("22:00"); err == nil {
+		t.Error("expected error for window without end")
+	}
+}
@@ -0,0 +1,123 @@
+package webcrawler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+)
+
+// Scope is the set of URLs crawled in a run: URLs on the hosts of the
+// seed URLs and on the hosts allowed by the host rules.
+//
+// Host rules are either an exact host e.g. "docs.example.com" or a
+// wildcard e.g. "*.example.com" allowing every subdomain of example.com
+// (but not example.com itself). Hosts are matched without the port.
+type Scope struct {
+	Seeds  []*url.URL // absolute HTTP/HTTPS URLs to start crawling from
+	Hosts  []string   // host rules of other hosts to crawl
+	Scheme string     // "http" or "https" to rewrite in-scope URLs to; URLs keep their scheme when empty
+}
+
+// NewScope returns a Scope of seeds and host rules with scheme.
+// Returns error when a seed cannot be parsed or the scope is invalid.
+func NewScope(seeds []string, hosts []string, scheme string) (*Scope, error) {
+	s := &Scope{Hosts: hosts, Scheme: scheme}
+	for _, seed := range seeds {
+		u, err := url.Parse(seed)
+		if err != nil {
+			return nil, fmt.Errorf("scope: could not parse seed '%s': %v", seed, err)
+		}
+		s.Seeds = append(s.Seeds, u)
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ValidHostRule tells if rule is an exact host or a wildcard of subdomains
+func ValidHostRule(rule string) bool {
+	host := strings.TrimPrefix(rule, "*.")
+	return host != "" && !strings.ContainsAny(host, "*/:@ ") && !strings.HasPrefix(host, ".")
+}
+
+// validate verifies the seeds, host rules and scheme of s
+func (s *Scope) validate() error {
+	if len(s.Seeds) == 0 {
+		return errors.New("scope: at least one seed URL is required")
+	}
+	for _, seed := range s.Seeds {
+		if !internal.IsValidScheme(seed.Scheme) {
+			return fmt.Errorf("scope: invalid scheme '%s'. Supported schemes: HTTP, HTTPS", seed.Scheme)
+		}
+		if !internal.IsAbsoluteURL(seed.String()) {
+			return fmt.Errorf("scope: seed URL '%s' should be absolute", seed)
+		}
+	}
+	for _, rule := range s.Hosts {
+		if !ValidHostRule(rule) {
+			return fmt.Errorf("scope: invalid host rule '%s'", rule)
+		}
+	}
+	if s.Scheme != "" && !internal.IsValidScheme(s.Scheme) {
+		return fmt.Errorf("scope: invalid scheme '%s'. Supported schemes: HTTP, HTTPS", s.Scheme)
+	}
+	return nil
+}
+
+// allowsHost tells if hostname is the host of a seed or matches a host rule
+func (s *Scope) allowsHost(hostname string) bool {
+	hostname = strings.ToLower(hostname)
+	for _, seed := range s.Seeds {
+		if strings.ToLower(seed.Hostname()) == hostname {
+			return true
+		}
+	}
+	for _, rule := range s.Hosts {
+		rule = strings.ToLower(rule)
+		if domain, found := strings.CutPrefix(rule, "*."); found {
+			if strings.HasSuffix(hostname, "."+domain) {
+				return true
+			}
+		} else if rule == hostname {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains tells if u is an absolute URL in scope. When Scheme is set,
+// URLs of the other scheme are not in scope.
+func (s *Scope) Contains(u *url.URL) bool {
+	if u.Scheme == "" || u.Host == "" {
+		return false
+	}
+	if s.Scheme != "" && u.Scheme != s.Scheme {
+		return false
+	}
+	return s.allowsHost(u.Hostname())
+}
+
+// Canonical returns u with its scheme rewritten to Scheme when u is an
+// HTTP/HTTPS URL on a host in scope. Other URLs are returned as is.
+func (s *Scope) Canonical(u *url.URL) *url.URL {
+	if s.Scheme == "" || u.Scheme == s.Scheme || !internal.IsValidScheme(u.Scheme) ||
+		!s.allowsHost(u.Hostname()) {
+		return u
+	}
+	c := *u
+	c.Scheme = s.Scheme
+	return &c
+}
+
+// CanonicalSeeds returns the seeds of s with their scheme rewritten to Scheme
+func (s *Scope) CanonicalSeeds() []*url.URL {
+	seeds := make([]*url.URL, len(s.Seeds))
+	for i, seed := range s.Seeds {
+		seeds[i] = s.Canonical(seed)
+	}
+	return seeds
+}
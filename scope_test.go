@@ -0,0 +1,138 @@
+package webcrawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	t.Run("NewScope", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			seeds   []string
+			hosts   []string
+			scheme  string
+			wantErr bool
+		}{
+			{name: "valid", seeds: []string{"https://example.com"}, hosts: []string{"*.example.com"}},
+			{name: "no seeds", wantErr: true},
+			{name: "relative seed", seeds: []string{"/docs"}, wantErr: true},
+			{name: "unsupported scheme", seeds: []string{"ftp://example.com"}, wantErr: true},
+			{name: "host rule with path", seeds: []string{"https://example.com"}, hosts: []string{"example.org/docs"}, wantErr: true},
+			{name: "host rule with inner wildcard", seeds: []string{"https://example.com"}, hosts: []string{"docs.*.com"}, wantErr: true},
+			{name: "invalid scheme", seeds: []string{"https://example.com"}, scheme: "ftp", wantErr: true},
+		}
+
+		for _, test := range tests {
+			_, err := NewScope(test.seeds, test.hosts, test.scheme)
+			if (err != nil) != test.wantErr {
+				t.Errorf("%s: got error %v, want error %t", test.name, err, test.wantErr)
+			}
+		}
+	})
+
+	t.Run("ValidHostRule", func(t *testing.T) {
+		tests := []struct {
+			rule string
+			want bool
+		}{
+			{rule: "docs.example.com", want: true},
+			{rule: "*.example.com", want: true},
+			{rule: "", want: false},
+			{rule: "*.", want: false},
+			{rule: "*", want: false},
+			{rule: ".example.com", want: false},
+			{rule: "*.*.example.com", want: false},
+			{rule: "example.com:8080", want: false},
+			{rule: "user@example.com", want: false},
+		}
+
+		for _, test := range tests {
+			if got := ValidHostRule(test.rule); got != test.want {
+				t.Errorf("rule: %q, got %t, want %t", test.rule, got, test.want)
+			}
+		}
+	})
+
+	t.Run("Contains", func(t *testing.T) {
+		scope, err := NewScope(
+			[]string{"https://example.com/docs"},
+			[]string{"blog.example.org", "*.Example.net"},
+			"",
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			input string
+			want  bool
+		}{
+			{input: "https://example.com/other", want: true},
+			{input: "http://example.com/", want: true},
+			{input: "https://EXAMPLE.com/", want: true},
+			{input: "https://example.com:8443/", want: true},
+			{input: "https://www.example.com/", want: false},
+			{input: "https://blog.example.org/post", want: true},
+			{input: "https://example.org/", want: false},
+			{input: "https://a.example.net/", want: true},
+			{input: "https://a.b.example.net/", want: true},
+			{input: "https://example.net/", want: false},
+			{input: "https://badexample.net/", want: false},
+			{input: "/relative", want: false},
+		}
+
+		for _, test := range tests {
+			if got := scope.Contains(mustParseURL(t, test.input)); got != test.want {
+				t.Errorf("input: %s, got %t, want %t", test.input, got, test.want)
+			}
+		}
+	})
+
+	t.Run("SchemeUpgrade", func(t *testing.T) {
+		scope, err := NewScope([]string{"http://example.com"}, []string{"*.example.com"}, "https")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			input     string
+			contains  bool
+			canonical string
+		}{
+			{input: "http://example.com/a?b=c", contains: false, canonical: "https://example.com/a?b=c"},
+			{input: "https://example.com/a", contains: true, canonical: "https://example.com/a"},
+			{input: "http://docs.example.com/", contains: false, canonical: "https://docs.example.com/"},
+			{input: "http://example.org/", contains: false, canonical: "http://example.org/"},
+			{input: "mailto:info@example.com", contains: false, canonical: "mailto:info@example.com"},
+		}
+
+		for _, test := range tests {
+			u := mustParseURL(t, test.input)
+			if got := scope.Contains(u); got != test.contains {
+				t.Errorf("input: %s, contains got %t, want %t", test.input, got, test.contains)
+			}
+			if got := scope.Canonical(u).String(); got != test.canonical {
+				t.Errorf("input: %s, canonical got %s, want %s", test.input, got, test.canonical)
+			}
+			if u.String() != test.input {
+				t.Errorf("input: %s, Canonical modified its argument to %s", test.input, u)
+			}
+		}
+
+		seeds := scope.CanonicalSeeds()
+		if len(seeds) != 1 || seeds[0].String() != "https://example.com" {
+			t.Errorf("got canonical seeds %v, want [https://example.com]", seeds)
+		}
+	})
+}
+
+// mustParseURL returns the URL of rawURL; fails t when it cannot be parsed
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("could not parse %q: %v", rawURL, err)
+	}
+	return u
+}
@@ -0,0 +1,124 @@
+package webcrawler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CrawlerState is what a crawler is currently doing
+type CrawlerState int
+
+const (
+	CrawlerIdle       CrawlerState = iota // waiting for a URL from queue
+	CrawlerFetching                       // making the GET request
+	CrawlerProcessing                     // reading response and pushing embedded URLs to queue
+	CrawlerSaving                         // saving page content to model
+	CrawlerWaiting                        // sleeping for RequestDelay, RetryBackoff or the politeness delay of a host
+	CrawlerPaused                         // waiting for the engine to resume
+	CrawlerStopped                        // exited
+)
+
+var crawlerStateNames = []string{
+	"idle", "fetching", "processing", "saving", "waiting", "paused", "stopped",
+}
+
+func (s CrawlerState) String() string {
+	if int(s) < len(crawlerStateNames) && s >= 0 {
+		return crawlerStateNames[s]
+	}
+	return fmt.Sprintf("CrawlerState(%d)", s)
+}
+
+// MarshalText implements [encoding.TextMarshaler]
+func (s CrawlerState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// CrawlerStatus is the current state of a crawler
+type CrawlerStatus struct {
+	Name  string       `json:"name"`
+	State CrawlerState `json:"state"`
+	URL   string       `json:"url,omitempty"` // URL being processed; empty when idle or stopped
+	Since time.Time    `json:"since"`         // time when the crawler entered State
+}
+
+// crawlerStatus guards the CrawlerStatus of a crawler
+type crawlerStatus struct {
+	mu     sync.Mutex
+	status CrawlerStatus
+}
+
+// set changes state and URL of the status
+func (cs *crawlerStatus) set(state CrawlerState, url string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.status.State = state
+	cs.status.URL = url
+	cs.status.Since = time.Now()
+}
+
+// get returns a copy of the status
+func (cs *crawlerStatus) get() CrawlerStatus {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.status
+}
+
+// Snapshot holds the live stats of a running engine
+type Snapshot struct {
+	StartedAt      time.Time       `json:"started_at"`      // time when Run was called; zero before Run
+	QueueSize      int             `json:"queue_size"`      // URLs waiting in queue
+	InFlight       int             `json:"in_flight"`       // URLs being processed by crawlers
+	URLsCrawled    int             `json:"urls_crawled"`    // URLs fetched from the queue
+	URLsDiscovered int             `json:"urls_discovered"` // new URLs pushed to the queue and model
+	PagesSaved     int             `json:"pages_saved"`     // pages saved to model
+	FailedRequests int             `json:"failed_requests"` // GET requests which failed
+	DeadURLs       int             `json:"dead_urls"`       // URLs marked as dead
+	FetchErrors    int             `json:"fetch_errors"`    // count of FetchError
+	StorageErrors  int             `json:"storage_errors"`  // count of StorageError
+	SkippedURLs    int             `json:"skipped_urls"`    // count of PolicySkipError
+	StatusCodes    map[int]int     `json:"status_codes"`    // count of responses by HTTP status code
+	Crawlers       []CrawlerStatus `json:"crawlers"`        // status of every crawler
+	ActiveCrawlers int             `json:"active_crawlers"` // crawlers which are not stopped or stopping
+	Paused         bool            `json:"paused"`          // crawlers are paused
+	Draining       bool            `json:"draining"`        // crawlers are stopping after in-flight URLs
+	RequestDelay   time.Duration   `json:"request_delay"`   // current delay between subsequent requests
+}
+
+// Elapsed returns the time since the crawl started
+func (s Snapshot) Elapsed() time.Duration {
+	if s.StartedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.StartedAt)
+}
+
+// Rate returns the URLs crawled per second
+func (s Snapshot) Rate() float64 {
+	elapsed := s.Elapsed().Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.URLsCrawled) / elapsed
+}
+
+// ErrorRate returns the fraction of crawled URLs which
+// ended in a fetch or storage error
+func (s Snapshot) ErrorRate() float64 {
+	if s.URLsCrawled == 0 {
+		return 0
+	}
+	return float64(s.FetchErrors+s.StorageErrors) / float64(s.URLsCrawled)
+}
+
+// ETA estimates the time left to drain the queue at the current rate.
+// Returns -1 when the rate is not known yet.
+func (s Snapshot) ETA() time.Duration {
+	rate := s.Rate()
+	if rate <= 0 {
+		return -1
+	}
+	remaining := float64(s.QueueSize + s.InFlight)
+	return time.Duration(remaining / rate * float64(time.Second))
+}
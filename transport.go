@@ -0,0 +1,176 @@
+package webcrawler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxIdleConnsPerHost is the max idle connections kept
+// per host by clients of TransportConfig without MaxIdleConnsPerHost
+const DefaultMaxIdleConnsPerHost = 50
+
+// TransportConfig configures the HTTP clients of crawlers and of
+// robots.txt fetches: proxies, TLS, timeouts and HTTP/2.
+// The zero value is a client with DefaultRequestTimeout.
+type TransportConfig struct {
+	Proxies             []string      // http, https, socks5 or socks5h proxy URLs used in rotation; proxy of the environment when empty
+	CAFile              string        // PEM file of CA certificates trusted along with the system roots
+	CertFile            string        // PEM file of the client certificate for mTLS; requires KeyFile
+	KeyFile             string        // PEM file of the key of the client certificate
+	InsecureSkipVerify  bool          // do not verify certificates of servers; only for development
+	ConnectTimeout      time.Duration // timeout of dialing and of the TLS handshake; defaults of net/http when 0
+	ReadTimeout         time.Duration // timeout of waiting for response headers after sending a request; no timeout when 0
+	Timeout             time.Duration // timeout of a whole request, including reading the body; DefaultRequestTimeout when 0
+	DisableHTTP2        bool          // use HTTP/1.1 only
+	MaxIdleConnsPerHost int           // DefaultMaxIdleConnsPerHost when 0
+}
+
+// Validate checks the proxies, client certificate and timeouts of tc
+func (tc *TransportConfig) Validate() error {
+	for _, proxy := range tc.Proxies {
+		if _, err := parseProxy(proxy); err != nil {
+			return err
+		}
+	}
+	if (tc.CertFile == "") != (tc.KeyFile == "") {
+		return errors.New("transport: client certificate and key must be provided together")
+	}
+	if tc.ConnectTimeout < 0 || tc.ReadTimeout < 0 || tc.Timeout < 0 {
+		return errors.New("transport: timeouts cannot be negative")
+	}
+	if tc.MaxIdleConnsPerHost < 0 {
+		return errors.New("transport: MaxIdleConnsPerHost cannot be negative")
+	}
+	return nil
+}
+
+// NewClient returns a new [http.Client] configured with tc; a nil
+// tc returns the client of a zero TransportConfig. Returns error when
+// tc is invalid or its certificate files cannot be loaded.
+func (tc *TransportConfig) NewClient() (*http.Client, error) {
+	if tc == nil {
+		tc = &TransportConfig{}
+	}
+	if err := tc.Validate(); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	if tc.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = tc.MaxIdleConnsPerHost
+	}
+
+	if len(tc.Proxies) > 0 {
+		proxies := make([]*url.URL, len(tc.Proxies))
+		for i, proxy := range tc.Proxies {
+			proxies[i], _ = parseProxy(proxy)
+		}
+		// requests are sent through the proxies in turn
+		var next atomic.Uint64
+		transport.Proxy = func(*http.Request) (*url.URL, error) {
+			return proxies[(next.Add(1)-1)%uint64(len(proxies))], nil
+		}
+	}
+
+	if tc.ConnectTimeout > 0 {
+		dialer := &net.Dialer{Timeout: tc.ConnectTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = tc.ConnectTimeout
+	}
+	transport.ResponseHeaderTimeout = tc.ReadTimeout
+
+	if tc.DisableHTTP2 {
+		// a non-nil empty map disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		// the clone of a used DefaultTransport still offers h2 to servers
+		if transport.TLSClientConfig != nil {
+			transport.TLSClientConfig.NextProtos = slices.DeleteFunc(
+				slices.Clone(transport.TLSClientConfig.NextProtos),
+				func(proto string) bool { return proto == "h2" },
+			)
+		}
+	}
+
+	tlsConfig, err := tc.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	timeout := DefaultRequestTimeout
+	if tc.Timeout > 0 {
+		timeout = tc.Timeout
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// tlsConfig returns the TLS config of tc; nil when tc has no TLS options
+func (tc *TransportConfig) tlsConfig() (*tls.Config, error) {
+	if tc.CAFile == "" && tc.CertFile == "" && !tc.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: tc.InsecureSkipVerify}
+
+	if tc.CAFile != "" {
+		pem, err := os.ReadFile(tc.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("transport: could not read CA file: %v", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("transport: no certificates found in CA file '%s'", tc.CAFile)
+		}
+		cfg.RootCAs = roots
+	}
+
+	if tc.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("transport: could not load client certificate: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// ValidProxy tells if proxy is an absolute http, https, socks5 or socks5h URL
+func ValidProxy(proxy string) bool {
+	_, err := parseProxy(proxy)
+	return err == nil
+}
+
+// parseProxy parses an absolute http, https, socks5 or socks5h proxy URL
+func parseProxy(proxy string) (*url.URL, error) {
+	// proxy URLs may have credentials; they are not included in errors
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return nil, errors.New("transport: invalid proxy URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return u, nil
+	default:
+		return nil, fmt.Errorf(
+			"transport: invalid scheme '%s' of proxy '%s'. Supported schemes: http, https, socks5, socks5h",
+			u.Scheme,
+			u.Redacted(),
+		)
+	}
+}
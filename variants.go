@@ -0,0 +1,68 @@
+package webcrawler
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/0x00f00bar/webcrawlerGo/internal"
+)
+
+// variantNameRegex matches valid variant names; names are used
+// in the file names of pages saved to disk
+var variantNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Variant is a named request profile. Saved pages of URLs matching the
+// patterns of a variant are fetched again with its headers and cookies
+// and saved as pages of the variant, e.g. the mobile page of a URL.
+type Variant struct {
+	Name     string            `json:"name"`     // name of the variant recorded with its pages
+	Patterns []string          `json:"patterns"` // URL patterns the variant is fetched for; every saved URL when empty
+	Headers  map[string]string `json:"headers"`  // headers of the requests e.g. User-Agent, Accept-Language
+	Cookies  map[string]string `json:"cookies"`  // cookies sent along with the cookies of the session, if any
+}
+
+// ValidVariantName tells if name can be the name of a Variant
+func ValidVariantName(name string) bool {
+	return variantNameRegex.MatchString(name)
+}
+
+// ValidateVariants checks that variants have valid and unique names
+// and set at least one header or cookie
+func ValidateVariants(variants []*Variant) error {
+	names := map[string]bool{}
+	for i, v := range variants {
+		if v == nil {
+			return fmt.Errorf("variant #%d: cannot be null", i+1)
+		}
+		if !ValidVariantName(v.Name) {
+			return fmt.Errorf(
+				"variant #%d: name %q must be 1-32 letters, digits, '-' or '_'",
+				i+1, v.Name,
+			)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("variant #%d: duplicate name %q", i+1, v.Name)
+		}
+		names[v.Name] = true
+		if len(v.Headers) == 0 && len(v.Cookies) == 0 {
+			return fmt.Errorf("variant %q: headers or cookies must be provided", v.Name)
+		}
+	}
+	return nil
+}
+
+// matches tells if v is fetched for href
+func (v *Variant) matches(href string) bool {
+	return len(v.Patterns) == 0 || internal.ContainsAny(href, v.Patterns)
+}
+
+// apply sets the headers and cookies of v on req
+func (v *Variant) apply(req *http.Request) {
+	for k, val := range v.Headers {
+		req.Header.Set(k, val)
+	}
+	for name, val := range v.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: val})
+	}
+}
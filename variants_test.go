@@ -0,0 +1,131 @@
+package webcrawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestVariants(t *testing.T) {
+	t.Run("ValidateVariants", func(t *testing.T) {
+		mobile := func(name string) *Variant {
+			return &Variant{Name: name, Headers: map[string]string{"User-Agent": "mobile"}}
+		}
+
+		tests := []struct {
+			name    string
+			input   []*Variant
+			wantErr bool
+		}{
+			{name: "none", input: nil},
+			{name: "valid names", input: []*Variant{mobile("mobile"), mobile("de_DE"), mobile("v-2"), mobile(strings.Repeat("a", 32))}},
+			{name: "cookies only", input: []*Variant{{Name: "beta", Cookies: map[string]string{"beta": "1"}}}},
+			{name: "empty name", input: []*Variant{mobile("")}, wantErr: true},
+			{name: "long name", input: []*Variant{mobile(strings.Repeat("a", 33))}, wantErr: true},
+			{name: "path in name", input: []*Variant{mobile("../mobile")}, wantErr: true},
+			{name: "space in name", input: []*Variant{mobile("mobile safari")}, wantErr: true},
+			{name: "duplicate names", input: []*Variant{mobile("mobile"), mobile("desktop"), mobile("mobile")}, wantErr: true},
+			{name: "names are case sensitive", input: []*Variant{mobile("mobile"), mobile("Mobile")}},
+			{name: "nil variant", input: []*Variant{mobile("mobile"), nil}, wantErr: true},
+			{name: "no headers or cookies", input: []*Variant{{Name: "empty", Patterns: []string{"/docs/"}}}, wantErr: true},
+		}
+		for _, test := range tests {
+			if err := ValidateVariants(test.input); (err != nil) != test.wantErr {
+				t.Errorf("%s: got error %v, want error %t", test.name, err, test.wantErr)
+			}
+		}
+	})
+
+	t.Run("matches", func(t *testing.T) {
+		tests := []struct {
+			patterns []string
+			input    string
+			want     bool
+		}{
+			{patterns: nil, input: "https://example.com/a", want: true},
+			{patterns: []string{"/docs/"}, input: "https://example.com/docs/a", want: true},
+			{patterns: []string{"/docs/"}, input: "https://example.com/blog/a", want: false},
+			{patterns: []string{"/docs/", "/blog/"}, input: "https://example.com/blog/a", want: true},
+			{patterns: []string{"lang=de"}, input: "https://example.com/a?lang=de", want: true},
+			{patterns: []string{"/Docs/"}, input: "https://example.com/docs/a", want: false},
+		}
+		for _, test := range tests {
+			v := &Variant{Name: "v", Patterns: test.patterns}
+			if got := v.matches(test.input); got != test.want {
+				t.Errorf("patterns: %q, input: %q, got %t, want %t", test.patterns, test.input, got, test.want)
+			}
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+				return
+			}
+			io.WriteString(w, r.Header.Get("User-Agent")+"|"+r.Header.Get("X-Api-Key")+"|"+r.Header.Get("Cookie"))
+		}))
+		defer srv.Close()
+
+		// the session cookie is kept in the cookie jar of auth
+		auth := &Auth{Headers: map[string]string{"X-Api-Key": "secret", "User-Agent": "auth"}}
+		auth.scopeTo([]*url.URL{mustParseURL(t, srv.URL)})
+		client := &http.Client{}
+		if err := auth.Start(context.Background(), client, "webcrawlerGo"); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(srv.URL + "/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		tests := []struct {
+			name    string
+			variant *Variant
+			want    string
+		}{
+			{name: "no variant", want: "auth|secret|session=abc"},
+			{
+				name:    "headers",
+				variant: &Variant{Name: "mobile", Headers: map[string]string{"User-Agent": "mobile"}},
+				want:    "mobile|secret|session=abc",
+			},
+			{
+				name:    "cookies",
+				variant: &Variant{Name: "beta", Cookies: map[string]string{"beta": "1"}},
+				want:    "auth|secret|beta=1; session=abc",
+			},
+			// cookies of a variant come first, so servers reading the
+			// first cookie of a name see the cookie of the variant
+			{
+				name:    "cookie of the session",
+				variant: &Variant{Name: "guest", Cookies: map[string]string{"session": "guest"}},
+				want:    "auth|secret|session=guest; session=abc",
+			},
+		}
+		for _, test := range tests {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/page", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			auth.authorize(req)
+			if test.variant != nil {
+				test.variant.apply(req)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != test.want {
+				t.Errorf("%s: got %q, want %q", test.name, body, test.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package webcrawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxWARCRedirects is the number of redirects followed within a WARC file
+const maxWARCRedirects = 10
+
+// WARCFetcher is a Fetcher serving the responses archived in a WARC file,
+// e.g. of an earlier crawl by another tool, without network. Gzipped WARC
+// files are read as well.
+//
+// Response and resource records are served by their target URI; of URLs
+// archived several times, the last capture. Redirects are followed within
+// the archive. URLs not in the archive are served HTTP 404.
+// Records are read into memory by NewWARCFetcher.
+type WARCFetcher struct {
+	records  map[string]*warcRecord // keyed by warcKey of target URI
+	firstURL string                 // target URI of the first response
+	vhost    *url.URL               // site the site of firstURL is served under; nil when not set
+	origin   *url.URL               // site of firstURL
+}
+
+// warcRecord is an archived response
+type warcRecord struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// NewWARCFetcher returns pointer to a new WARCFetcher of the WARC file at path
+func NewWARCFetcher(path string) (*WARCFetcher, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("warc: %w", err)
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		// records of .warc.gz files are concatenated gzip members
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("warc: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	f := &WARCFetcher{records: map[string]*warcRecord{}}
+	tp := textproto.NewReader(bufio.NewReader(r))
+	for n := 1; ; n++ {
+		uri, record, err := readWARCRecord(tp)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("warc: record #%d: %w", n, err)
+		}
+		if record == nil {
+			continue
+		}
+		if f.firstURL == "" {
+			f.firstURL = uri
+		}
+		f.records[warcKey(uri)] = record
+	}
+	return f, nil
+}
+
+// Len returns the number of URLs in the archive
+func (f *WARCFetcher) Len() int {
+	return len(f.records)
+}
+
+// FirstURL returns the target URI of the first response in the archive;
+// empty when there are no responses
+func (f *WARCFetcher) FirstURL() string {
+	return f.firstURL
+}
+
+// SetVHost serves the archived site of the first response under the
+// site of vhost: URLs of vhost are looked up on the archived site, and
+// redirects to the archived site are served as redirects to vhost.
+// Absolute links of the archived pages are not rewritten. Returns error
+// when there are no responses or vhost has a path.
+func (f *WARCFetcher) SetVHost(vhost *url.URL) error {
+	first, err := url.Parse(f.firstURL)
+	if err != nil || first.Host == "" {
+		return errors.New("warc: no responses in the archive")
+	}
+	if strings.Trim(vhost.Path, "/") != "" {
+		return fmt.Errorf("warc: vhost %q cannot have a path", vhost)
+	}
+	f.vhost = &url.URL{Scheme: vhost.Scheme, Host: vhost.Host}
+	f.origin = &url.URL{Scheme: first.Scheme, Host: first.Host}
+	return nil
+}
+
+// Fetch implements Fetcher
+func (f *WARCFetcher) Fetch(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return newReplayResponse(req, http.StatusMethodNotAllowed, http.Header{}, nil), nil
+	}
+
+	finalURL := rebaseURL(req.URL, f.vhost, f.origin)
+	record := f.records[warcKey(finalURL.String())]
+	for i := 0; record != nil && i < maxWARCRedirects; i++ {
+		location := record.header.Get("Location")
+		if record.status < 300 || record.status > 399 || location == "" {
+			break
+		}
+		next, err := finalURL.Parse(location)
+		if err != nil || f.records[warcKey(next.String())] == nil {
+			break
+		}
+		finalURL = next
+		record = f.records[warcKey(next.String())]
+	}
+	if record == nil {
+		return newReplayResponse(req, http.StatusNotFound, http.Header{}, nil), nil
+	}
+
+	// relative URLs of a redirected page resolve against the final URL
+	finalURL = rebaseURL(finalURL, f.origin, f.vhost)
+	if finalURL.String() != req.URL.String() {
+		req = req.Clone(req.Context())
+		req.URL = finalURL
+	}
+	body := record.body
+	if req.Method == http.MethodHead {
+		body = nil
+	}
+	return newReplayResponse(req, record.status, record.header.Clone(), body), nil
+}
+
+// readWARCRecord reads the next record of tp. Returns the target URI
+// and response of response and resource records; record is nil for
+// other records. Returns io.EOF when there are no more records.
+func readWARCRecord(tp *textproto.Reader) (uri string, record *warcRecord, err error) {
+	// records are separated by blank lines
+	var version string
+	for version == "" {
+		version, err = tp.ReadLine()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return "", nil, fmt.Errorf("invalid version line %q", version)
+	}
+
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return "", nil, err
+	}
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return "", nil, errors.New("invalid Content-Length")
+	}
+	block := make([]byte, length)
+	if _, err := io.ReadFull(tp.R, block); err != nil {
+		return "", nil, io.ErrUnexpectedEOF
+	}
+
+	// target URIs of WARC/1.0 may be enclosed in '<>'
+	uri = strings.Trim(header.Get("WARC-Target-URI"), "<>")
+	if warcKey(uri) == "" {
+		return "", nil, nil
+	}
+
+	switch header.Get("WARC-Type") {
+	case "response":
+		if !strings.HasPrefix(header.Get("Content-Type"), "application/http") {
+			return "", nil, nil
+		}
+		record, err = parseHTTPResponse(block)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", uri, err)
+		}
+		return uri, record, nil
+	case "resource":
+		h := http.Header{}
+		if ct := header.Get("Content-Type"); ct != "" {
+			h.Set("Content-Type", ct)
+		}
+		return uri, &warcRecord{status: http.StatusOK, header: h, body: block}, nil
+	}
+	return "", nil, nil
+}
+
+// rebaseURL returns u on the site of to when it is on the site of from;
+// u when from is nil
+func rebaseURL(u, from, to *url.URL) *url.URL {
+	if from == nil || !strings.EqualFold(u.Scheme, from.Scheme) || !strings.EqualFold(u.Host, from.Host) {
+		return u
+	}
+	rebased := *u
+	rebased.Scheme, rebased.Host = to.Scheme, to.Host
+	return &rebased
+}
+
+// warcKey returns the key of the record of rawURL: the URL without
+// fragment and with path '/' when empty; empty when rawURL is invalid
+func warcKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	u.Fragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+// parseHTTPResponse returns the record of the HTTP response block
+func parseHTTPResponse(block []byte) (*warcRecord, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+	content, err := io.ReadAll(body)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+
+	// archived content is decoded
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.Header.Del("Transfer-Encoding")
+	return &warcRecord{status: resp.StatusCode, header: resp.Header, body: content}, nil
+}
@@ -0,0 +1,224 @@
+package webcrawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// warcRecordBytes returns a WARC/1.1 record of type with the
+// target URI uri, Content-Type contentType and block
+func warcRecordBytes(recordType, uri, contentType, block string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "WARC/1.1\r\nWARC-Type: %s\r\n", recordType)
+	if uri != "" {
+		fmt.Fprintf(&b, "WARC-Target-URI: %s\r\n", uri)
+	}
+	fmt.Fprintf(&b, "Content-Type: %s\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n", contentType, len(block), block)
+	return b.String()
+}
+
+// gzipString returns s gzipped
+func gzipString(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(gz, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestWARCFetcher(t *testing.T) {
+	const httpResponse = "application/http; msgtype=response"
+	gzipped := gzipString(t, "<h1>news</h1>")
+
+	var records []string
+	records = append(records,
+		warcRecordBytes("warcinfo", "", "application/warc-fields", "software: test\r\n"),
+		warcRecordBytes("request", "https://example.org/", "application/http; msgtype=request",
+			"GET / HTTP/1.1\r\nHost: example.org\r\n\r\n"),
+		warcRecordBytes("response", "<https://example.org>", httpResponse,
+			"HTTP/1.1 301 Moved Permanently\r\nLocation: /home\r\nContent-Length: 0\r\n\r\n"),
+		warcRecordBytes("response", "https://example.org/home#top", httpResponse,
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: 12\r\n\r\n<h1>old</h1>"),
+		warcRecordBytes("response", "https://example.org/home", httpResponse,
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: 13\r\n\r\n<h1>home</h1>"),
+		warcRecordBytes("response", "https://example.org/news", httpResponse, fmt.Sprintf(
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Encoding: gzip\r\nContent-Length: %d\r\n\r\n%s",
+			len(gzipped), gzipped,
+		)),
+		warcRecordBytes("response", "https://example.org/loop", httpResponse,
+			"HTTP/1.1 302 Found\r\nLocation: /loop\r\n\r\n"),
+		warcRecordBytes("resource", "https://example.org/robots.txt", "text/plain", "User-agent: *\nDisallow:\n"),
+	)
+
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "crawl.warc")
+	if err := os.WriteFile(plain, []byte(strings.Join(records, "")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// members of .warc.gz files are gzipped records
+	var gzipFile strings.Builder
+	for _, record := range records {
+		gzipFile.WriteString(gzipString(t, record))
+	}
+	gzipPath := filepath.Join(dir, "crawl.warc.gz")
+	if err := os.WriteFile(gzipPath, []byte(gzipFile.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{plain, gzipPath} {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			fetcher, err := NewWARCFetcher(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fetcher.Len() != 5 {
+				t.Errorf("got %d URLs, want 5", fetcher.Len())
+			}
+			if got := fetcher.FirstURL(); got != "https://example.org" {
+				t.Errorf("got first URL %q, want %q", got, "https://example.org")
+			}
+
+			tests := []struct {
+				method   string
+				input    string
+				status   int
+				finalURL string
+				body     string
+			}{
+				{method: http.MethodGet, input: "https://example.org", status: http.StatusOK,
+					finalURL: "https://example.org/home", body: "<h1>home</h1>"},
+				{method: http.MethodGet, input: "https://example.org/home#main", status: http.StatusOK,
+					finalURL: "https://example.org/home#main", body: "<h1>home</h1>"},
+				{method: http.MethodHead, input: "https://example.org/home", status: http.StatusOK,
+					finalURL: "https://example.org/home"},
+				{method: http.MethodGet, input: "https://example.org/news", status: http.StatusOK,
+					finalURL: "https://example.org/news", body: "<h1>news</h1>"},
+				{method: http.MethodGet, input: "https://example.org/loop", status: http.StatusFound,
+					finalURL: "https://example.org/loop"},
+				{method: http.MethodGet, input: "https://example.org/robots.txt", status: http.StatusOK,
+					finalURL: "https://example.org/robots.txt", body: "User-agent: *\nDisallow:\n"},
+				{method: http.MethodGet, input: "https://example.org/missing", status: http.StatusNotFound,
+					finalURL: "https://example.org/missing"},
+				{method: http.MethodPost, input: "https://example.org/home", status: http.StatusMethodNotAllowed,
+					finalURL: "https://example.org/home"},
+			}
+
+			for _, test := range tests {
+				req, err := http.NewRequest(test.method, test.input, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err := fetcher.Fetch(req)
+				if err != nil {
+					t.Errorf("%s %s: got error %v", test.method, test.input, err)
+					continue
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+
+				if resp.StatusCode != test.status {
+					t.Errorf("%s %s: got status %d, want %d", test.method, test.input, resp.StatusCode, test.status)
+				}
+				if got := resp.Request.URL.String(); got != test.finalURL {
+					t.Errorf("%s %s: got final URL %s, want %s", test.method, test.input, got, test.finalURL)
+				}
+				if string(body) != test.body {
+					t.Errorf("%s %s: got body %q, want %q", test.method, test.input, body, test.body)
+				}
+				if resp.Header.Get("Content-Encoding") != "" {
+					t.Errorf("%s %s: expected decoded content", test.method, test.input)
+				}
+			}
+		})
+	}
+
+	t.Run("VHost", func(t *testing.T) {
+		fetcher, err := NewWARCFetcher(plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fetcher.SetVHost(mustParseURL(t, "http://localhost:8080/sub")); err == nil {
+			t.Error("expected error for a vhost with a path")
+		}
+		if err := fetcher.SetVHost(mustParseURL(t, "http://localhost:8080")); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			input    string
+			status   int
+			finalURL string
+		}{
+			{input: "http://localhost:8080", status: http.StatusOK, finalURL: "http://localhost:8080/home"},
+			{input: "http://localhost:8080/news", status: http.StatusOK, finalURL: "http://localhost:8080/news"},
+			{input: "http://localhost:8080/robots.txt", status: http.StatusOK, finalURL: "http://localhost:8080/robots.txt"},
+			{input: "http://localhost:8080/missing", status: http.StatusNotFound, finalURL: "http://localhost:8080/missing"},
+			{input: "https://example.org/home", status: http.StatusOK, finalURL: "http://localhost:8080/home"},
+			{input: "https://localhost:8080/home", status: http.StatusNotFound, finalURL: "https://localhost:8080/home"},
+		}
+		for _, test := range tests {
+			req, err := http.NewRequest(http.MethodGet, test.input, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := fetcher.Fetch(req)
+			if err != nil {
+				t.Errorf("input: %s, got error %v", test.input, err)
+				continue
+			}
+			resp.Body.Close()
+			if resp.StatusCode != test.status {
+				t.Errorf("input: %s, got status %d, want %d", test.input, resp.StatusCode, test.status)
+			}
+			if got := resp.Request.URL.String(); got != test.finalURL {
+				t.Errorf("input: %s, got final URL %s, want %s", test.input, got, test.finalURL)
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := map[string]string{
+			"version": "HTTP/1.1 200 OK\r\n\r\n",
+			"length":  "WARC/1.1\r\nWARC-Type: resource\r\nContent-Length: x\r\n\r\n",
+			"short":   "WARC/1.1\r\nWARC-Type: resource\r\nContent-Length: 100\r\n\r\nshort",
+		}
+		for name, content := range tests {
+			path := filepath.Join(dir, name+".warc")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewWARCFetcher(path); err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+	})
+
+	t.Run("warcKey", func(t *testing.T) {
+		tests := []struct {
+			input string
+			want  string
+		}{
+			{input: "https://example.org", want: "https://example.org/"},
+			{input: "https://example.org/a?b=c#d", want: "https://example.org/a?b=c"},
+			{input: "/relative", want: ""},
+			{input: "://invalid", want: ""},
+		}
+		for _, test := range tests {
+			if got := warcKey(test.input); got != test.want {
+				t.Errorf("input: %q, got %q, want %q", test.input, got, test.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,298 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// Headers sent with every delivery
+const (
+	EventHeader     = "X-Webcrawler-Event"
+	DeliveryHeader  = "X-Webcrawler-Delivery"      // id of the recorded delivery
+	SignatureHeader = "X-Webcrawler-Signature-256" // "sha256=" + Sign(secret, body); only with a secret
+)
+
+// queueSize is the number of events held for a webhook
+// while earlier deliveries are in progress
+const queueSize = 256
+
+// Sign returns the hex encoded HMAC-SHA256 of body with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher delivers crawl events to webhooks. Events of a webhook are
+// delivered in order; a slow webhook does not delay the others.
+type Dispatcher struct {
+	Client       *http.Client  // client to post payloads with
+	RetryBackoff time.Duration // delay before the first retry; doubled on every retry
+	MaxBackoff   time.Duration // maximum delay between retries
+
+	hooks      []*Webhook
+	deliveries models.DeliveryModel
+	logger     *slog.Logger
+	ctx        context.Context // cancelled to abandon pending deliveries
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewDispatcher returns pointer to a new Dispatcher sending events to hooks.
+// Hooks should be validated. Deliveries are recorded to deliveries when not nil.
+// Logs to logger when not nil.
+func NewDispatcher(hooks []*Webhook, deliveries models.DeliveryModel, logger *slog.Logger) *Dispatcher {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		RetryBackoff: time.Second,
+		MaxBackoff:   time.Minute,
+		hooks:        hooks,
+		deliveries:   deliveries,
+		logger:       logger,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Filter returns the filter of the events sent to the webhooks of d
+func (d *Dispatcher) Filter() webcrawler.EventFilter {
+	var filter webcrawler.EventFilter
+	for _, t := range Events {
+		for _, hook := range d.hooks {
+			if hook.subscribed(t) {
+				filter.Types = append(filter.Types, t)
+				break
+			}
+		}
+	}
+	return filter
+}
+
+// Start delivers the events received on c in background until c is closed.
+// URL events are only delivered for monitored URLs.
+func (d *Dispatcher) Start(c <-chan webcrawler.Event) {
+	queues := make([]chan webcrawler.Event, len(d.hooks))
+	for i, hook := range d.hooks {
+		queues[i] = make(chan webcrawler.Event, queueSize)
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for e := range queues[i] {
+				d.deliver(hook, e)
+			}
+		}()
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer func() {
+			for _, q := range queues {
+				close(q)
+			}
+		}()
+
+		for e := range c {
+			if (e.Type == webcrawler.EventURLDead || e.Type == webcrawler.EventURLAlive) && !e.Monitored {
+				continue
+			}
+			for i, hook := range d.hooks {
+				if !hook.subscribed(e.Type) {
+					continue
+				}
+				select {
+				case queues[i] <- e:
+				default:
+					d.drop(hook, e)
+				}
+			}
+		}
+	}()
+}
+
+// Shutdown waits for the delivery of the events received before the
+// channel passed to Start was closed. When ctx is done the pending
+// retries are abandoned and their deliveries recorded as failed.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// drop records e as dropped for hook, whose queue is full
+func (d *Dispatcher) drop(hook *Webhook, e webcrawler.Event) {
+	deliveriesDroppedTotal.WithLabelValues(hook.Name).Inc()
+	d.logger.Warn("webhook queue full, dropped event", "webhook", hook.Name, "event", e.Type, "url", e.URL)
+	if d.deliveries == nil {
+		return
+	}
+
+	payload, err := hook.payload(e)
+	if err != nil {
+		d.logger.Error("could not create webhook payload", "webhook", hook.Name, "event", e.Type, "err", err)
+		return
+	}
+	ctx := context.WithoutCancel(d.ctx)
+	delivery := models.NewDelivery(hook.Name, e.Type.String(), e.URL, e.RunID, string(payload))
+	if err := d.deliveries.Insert(ctx, delivery); err != nil {
+		d.logger.Error("could not record webhook delivery", "webhook", hook.Name, "err", err)
+		return
+	}
+	finishedAt := time.Now()
+	delivery.Status = models.DeliveryDropped
+	delivery.Error = "webhook queue full"
+	delivery.FinishedAt = &finishedAt
+	if err := d.deliveries.Update(ctx, delivery); err != nil {
+		d.logger.Error("could not update webhook delivery", "webhook", hook.Name, "id", delivery.ID, "err", err)
+	}
+}
+
+// deliver sends e to hook, retrying failed attempts, and records the delivery
+func (d *Dispatcher) deliver(hook *Webhook, e webcrawler.Event) {
+	payload, err := hook.payload(e)
+	if err != nil {
+		d.logger.Error("could not create webhook payload", "webhook", hook.Name, "event", e.Type, "err", err)
+		return
+	}
+
+	// record deliveries even when pending deliveries are abandoned
+	recordCtx := context.WithoutCancel(d.ctx)
+
+	delivery := models.NewDelivery(hook.Name, e.Type.String(), e.URL, e.RunID, string(payload))
+	if d.deliveries != nil {
+		if err := d.deliveries.Insert(recordCtx, delivery); err != nil {
+			d.logger.Error("could not record webhook delivery", "webhook", hook.Name, "err", err)
+		}
+	}
+
+	backoff := d.RetryBackoff
+	for {
+		delivery.Attempts++
+		delivery.ResponseStatus, err = d.post(hook, delivery, payload)
+		if err == nil {
+			delivery.Status = models.DeliveryDelivered
+			delivery.Error = ""
+			break
+		}
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = err.Error()
+
+		if !retryable(delivery.ResponseStatus) || delivery.Attempts >= hook.MaxAttempts {
+			break
+		}
+		d.logger.Warn(
+			"webhook delivery failed, retrying",
+			"webhook", hook.Name,
+			"event", e.Type,
+			"attempt", delivery.Attempts,
+			"backoff", backoff,
+			"err", err,
+		)
+		if !sleep(d.ctx, backoff) {
+			delivery.Error = "abandoned on shutdown: " + delivery.Error
+			break
+		}
+		backoff = min(backoff*2, d.MaxBackoff)
+	}
+
+	finishedAt := time.Now()
+	delivery.FinishedAt = &finishedAt
+
+	if d.deliveries != nil && delivery.ID != 0 {
+		if err := d.deliveries.Update(recordCtx, delivery); err != nil {
+			d.logger.Error("could not update webhook delivery", "webhook", hook.Name, "id", delivery.ID, "err", err)
+		}
+	}
+
+	if delivery.Status == models.DeliveryDelivered {
+		d.logger.Info("delivered webhook", "webhook", hook.Name, "event", e.Type, "url", e.URL, "attempts", delivery.Attempts)
+	} else {
+		d.logger.Error(
+			"webhook delivery failed",
+			"webhook", hook.Name,
+			"event", e.Type,
+			"url", e.URL,
+			"attempts", delivery.Attempts,
+			"err", delivery.Error,
+		)
+	}
+}
+
+// post sends payload to hook and returns the response status;
+// 0 when no response was received
+func (d *Dispatcher) post(hook *Webhook, delivery *models.Delivery, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "webcrawlerGo-webhook")
+	req.Header.Set(EventHeader, delivery.Event)
+	if delivery.ID != 0 {
+		req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	}
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(hook.Secret, payload))
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// read some of the body to reuse the connection
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("received HTTP status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable tells if a delivery with response status should be retried;
+// status is 0 when no response was received
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// sleep waits for d or until ctx is done.
+// Returns false when ctx is done.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
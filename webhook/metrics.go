@@ -0,0 +1,16 @@
+package webhook
+
+import (
+	"github.com/0x00f00bar/webcrawlerGo/metrics"
+)
+
+// webhook metrics exported on metrics.DefaultRegistry
+var deliveriesDroppedTotal = metrics.NewCounterVec(
+	"webcrawlergo_webhook_deliveries_dropped_total",
+	"Number of events not delivered to a webhook as its queue was full, by webhook.",
+	"webhook",
+)
+
+func init() {
+	metrics.MustRegister(deliveriesDroppedTotal)
+}
@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+)
+
+// genericPayload is the event sent with FormatGeneric
+type genericPayload struct {
+	Webhook string `json:"webhook"`
+	webcrawler.Event
+}
+
+// slackPayload is a Slack incoming webhook message
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// teamsPayload is a Microsoft Teams connector message card
+type teamsPayload struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	Summary    string `json:"summary"`
+	ThemeColor string `json:"themeColor"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+}
+
+// payload returns the body sent to w for e
+func (w *Webhook) payload(e webcrawler.Event) ([]byte, error) {
+	title, text, color := describe(e)
+
+	switch w.Format {
+	case FormatSlack:
+		return json.Marshal(slackPayload{Text: fmt.Sprintf("*%s*\n%s", title, text)})
+	case FormatTeams:
+		return json.Marshal(teamsPayload{
+			Type:       "MessageCard",
+			Context:    "https://schema.org/extensions",
+			Summary:    title,
+			ThemeColor: color,
+			Title:      title,
+			Text:       text,
+		})
+	default:
+		return json.Marshal(genericPayload{Webhook: w.Name, Event: e})
+	}
+}
+
+// describe returns the title, text and theme color
+// of the chat message for e
+func describe(e webcrawler.Event) (title, text, color string) {
+	const (
+		red   = "D93F0B"
+		green = "2EA44F"
+		blue  = "0078D7"
+	)
+
+	switch e.Type {
+	case webcrawler.EventPageChanged:
+		page := e.URL
+		if e.Variant != "" {
+			page += " (variant " + e.Variant + ")"
+		}
+		return "Page changed",
+			fmt.Sprintf("Content of %s changed (page #%d, previous page #%d)", page, e.PageID, e.PreviousPageID),
+			blue
+	case webcrawler.EventURLDead:
+		return "URL is dead",
+			fmt.Sprintf("%s returned HTTP %d", e.URL, e.StatusCode),
+			red
+	case webcrawler.EventURLAlive:
+		return "URL is back alive",
+			fmt.Sprintf("%s is back online (HTTP %d)", e.URL, e.StatusCode),
+			green
+	case webcrawler.EventRunFailed:
+		return "Crawl run failed",
+			fmt.Sprintf("Crawl run #%d of %s failed: %s", e.RunID, e.URL, e.Error),
+			red
+	default:
+		return e.Type.String(), e.URL, blue
+	}
+}
@@ -0,0 +1,105 @@
+// Package webhook sends crawl events to HTTP endpoints.
+//
+// A Dispatcher receives events from a [webcrawler.EventBus] subscription
+// and posts a JSON payload to each Webhook subscribed to the event type.
+// Payloads are signed with HMAC-SHA256 when the webhook has a secret,
+// failed deliveries are retried with exponential backoff and every
+// delivery is recorded with [models.DeliveryModel].
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+)
+
+// Format is the format of the payload sent to a webhook
+type Format string
+
+const (
+	FormatGeneric Format = "generic" // the event as JSON
+	FormatSlack   Format = "slack"   // Slack incoming webhook message
+	FormatTeams   Format = "teams"   // Microsoft Teams connector message card
+)
+
+// DefaultMaxAttempts is the number of delivery attempts of a webhook
+// without MaxAttempts
+const DefaultMaxAttempts = 5
+
+// Events are the event types sent to webhooks
+var Events = []webcrawler.EventType{
+	webcrawler.EventPageChanged,
+	webcrawler.EventURLDead,
+	webcrawler.EventURLAlive,
+	webcrawler.EventRunFailed,
+}
+
+// Webhook is an endpoint notified of crawl events
+type Webhook struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret"`       // key to sign payloads with; payloads are not signed when empty
+	Format      Format   `json:"format"`       // payload format; FormatGeneric when empty
+	Events      []string `json:"events"`       // event types to send; all of Events when empty
+	MaxAttempts int      `json:"max_attempts"` // delivery attempts before giving up; DefaultMaxAttempts when 0
+
+	eventTypes []webcrawler.EventType // parsed Events (internal)
+}
+
+// Validate checks the fields of w and sets the defaults
+// of the optional fields
+func (w *Webhook) Validate() error {
+	if w.Name == "" {
+		return errors.New("name must be provided")
+	}
+
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q must be absolute http/https URL", w.URL)
+	}
+
+	switch w.Format {
+	case "":
+		w.Format = FormatGeneric
+	case FormatGeneric, FormatSlack, FormatTeams:
+	default:
+		return fmt.Errorf("format %q must be one of: generic, slack, teams", w.Format)
+	}
+
+	if w.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts cannot be negative")
+	}
+	if w.MaxAttempts == 0 {
+		w.MaxAttempts = DefaultMaxAttempts
+	}
+
+	w.eventTypes = nil
+	for _, name := range w.Events {
+		t, err := webcrawler.ParseEventType(name)
+		if err != nil || !slices.Contains(Events, t) {
+			return fmt.Errorf("event %q must be one of: %s", name, eventNames())
+		}
+		w.eventTypes = append(w.eventTypes, t)
+	}
+	if len(w.eventTypes) == 0 {
+		w.eventTypes = Events
+	}
+	return nil
+}
+
+// subscribed tells if w is sent events of type t
+func (w *Webhook) subscribed(t webcrawler.EventType) bool {
+	return slices.Contains(w.eventTypes, t)
+}
+
+func eventNames() string {
+	names := make([]string, len(Events))
+	for i, t := range Events {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ", ")
+}
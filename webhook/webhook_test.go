@@ -0,0 +1,328 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	webcrawler "github.com/0x00f00bar/webcrawlerGo"
+	"github.com/0x00f00bar/webcrawlerGo/models"
+)
+
+// memDeliveries is an in-memory DeliveryModel
+type memDeliveries struct {
+	mu         sync.Mutex
+	deliveries map[uint]models.Delivery
+}
+
+func (m *memDeliveries) GetAll(context.Context, models.DeliveryFilter, models.CommonFilters) ([]*models.Delivery, error) {
+	return nil, nil
+}
+
+func (m *memDeliveries) GetById(_ context.Context, id int) (*models.Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deliveries[uint(id)]
+	if !ok {
+		return nil, models.ErrRecordNotFound
+	}
+	return &d, nil
+}
+
+func (m *memDeliveries) Insert(_ context.Context, d *models.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.deliveries == nil {
+		m.deliveries = make(map[uint]models.Delivery)
+	}
+	d.ID = uint(len(m.deliveries) + 1)
+	m.deliveries[d.ID] = *d
+	return nil
+}
+
+func (m *memDeliveries) Update(_ context.Context, d *models.Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[d.ID] = *d
+	return nil
+}
+
+// receiver records the requests to a local webhook endpoint
+// and responds with the next of statuses
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// dispatch sends events to hooks through a Dispatcher and
+// waits for the deliveries
+func dispatch(t *testing.T, hooks []*Webhook, deliveries models.DeliveryModel, events ...webcrawler.Event) {
+	t.Helper()
+
+	for _, hook := range hooks {
+		if err := hook.Validate(); err != nil {
+			t.Fatalf("invalid webhook: %v", err)
+		}
+	}
+
+	d := NewDispatcher(hooks, deliveries, nil)
+	d.RetryBackoff = time.Millisecond
+
+	c := make(chan webcrawler.Event, len(events))
+	for _, e := range events {
+		c <- e
+	}
+	close(c)
+	d.Start(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Run("SignedWithRetry", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests}}
+		srv := httptest.NewServer(rc)
+		defer srv.Close()
+
+		deliveries := &memDeliveries{}
+		hook := &Webhook{Name: "local", URL: srv.URL, Secret: "s3cret"}
+		event := webcrawler.Event{
+			Type:           webcrawler.EventPageChanged,
+			URL:            "https://example.com/blog",
+			RunID:          7,
+			Monitored:      true,
+			PageID:         2,
+			PreviousPageID: 1,
+		}
+
+		dispatch(t, []*Webhook{hook}, deliveries, event)
+
+		if len(rc.requests) != 3 {
+			t.Fatalf("requests: got %d, want 3", len(rc.requests))
+		}
+
+		last, body := rc.requests[2], rc.bodies[2]
+		if got, want := last.Header.Get(SignatureHeader), "sha256="+Sign("s3cret", body); got != want {
+			t.Errorf("signature: got %q, want %q", got, want)
+		}
+		if got := last.Header.Get(EventHeader); got != "page_changed" {
+			t.Errorf("event header: got %q", got)
+		}
+		if got := last.Header.Get(DeliveryHeader); got != "1" {
+			t.Errorf("delivery header: got %q", got)
+		}
+
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("payload: %v", err)
+		}
+		if payload["webhook"] != "local" || payload["type"] != "page_changed" || payload["url"] != event.URL {
+			t.Errorf("unexpected payload: %s", body)
+		}
+
+		d, err := deliveries.GetById(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("delivery not recorded: %v", err)
+		}
+		if d.Status != models.DeliveryDelivered || d.Attempts != 3 || d.ResponseStatus != http.StatusOK {
+			t.Errorf("delivery: got status %s, attempts %d, response %d", d.Status, d.Attempts, d.ResponseStatus)
+		}
+		if d.RunID != 7 || d.FinishedAt == nil {
+			t.Errorf("delivery: got run id %d, finished at %v", d.RunID, d.FinishedAt)
+		}
+	})
+
+	t.Run("NotRetried", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusBadRequest}}
+		srv := httptest.NewServer(rc)
+		defer srv.Close()
+
+		deliveries := &memDeliveries{}
+		hook := &Webhook{Name: "local", URL: srv.URL}
+
+		dispatch(t, []*Webhook{hook}, deliveries,
+			webcrawler.Event{Type: webcrawler.EventURLDead, URL: "https://example.com/gone", Monitored: true},
+		)
+
+		if len(rc.requests) != 1 {
+			t.Fatalf("requests: got %d, want 1", len(rc.requests))
+		}
+		if rc.requests[0].Header.Get(SignatureHeader) != "" {
+			t.Error("payload signed without secret")
+		}
+		d, _ := deliveries.GetById(context.Background(), 1)
+		if d == nil || d.Status != models.DeliveryFailed || d.Attempts != 1 || d.ResponseStatus != http.StatusBadRequest {
+			t.Errorf("delivery: got %+v", d)
+		}
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		release := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer srv.Close()
+
+		deliveries := &memDeliveries{}
+		hook := &Webhook{Name: "slow", URL: srv.URL}
+		if err := hook.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		dropped := deliveriesDroppedTotal.WithLabelValues(hook.Name)
+
+		// one event is delivered while queueSize events wait
+		total := queueSize + 10
+		c := make(chan webcrawler.Event, total)
+		for range total {
+			c <- webcrawler.Event{Type: webcrawler.EventRunFailed, URL: "https://example.com"}
+		}
+		close(c)
+		d := NewDispatcher([]*Webhook{hook}, deliveries, nil)
+		d.Start(c)
+
+		countDropped := func() int {
+			deliveries.mu.Lock()
+			defer deliveries.mu.Unlock()
+			n := 0
+			for _, delivery := range deliveries.deliveries {
+				if delivery.Status == models.DeliveryDropped {
+					n++
+				}
+			}
+			return n
+		}
+		for deadline := time.Now().Add(5 * time.Second); countDropped() < total-queueSize-1; {
+			if time.Now().After(deadline) {
+				t.Fatalf("dropped deliveries: got %d, want at least %d", countDropped(), total-queueSize-1)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		close(release)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.Shutdown(ctx); err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+
+		n := countDropped()
+		if len(deliveries.deliveries) != total {
+			t.Errorf("deliveries: got %d, want %d", len(deliveries.deliveries), total)
+		}
+		if got := int(dropped.Value()); got != n {
+			t.Errorf("dropped metric: got %d, want %d", got, n)
+		}
+		for _, delivery := range deliveries.deliveries {
+			if delivery.Status == models.DeliveryDropped && (delivery.Error == "" || delivery.FinishedAt == nil) {
+				t.Errorf("dropped delivery: got %+v", delivery)
+			}
+		}
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		rc := &receiver{}
+		srv := httptest.NewServer(rc)
+		defer srv.Close()
+
+		hook := &Webhook{Name: "dead-only", URL: srv.URL, Events: []string{"url_dead"}}
+
+		dispatch(t, []*Webhook{hook}, nil,
+			webcrawler.Event{Type: webcrawler.EventURLDead, URL: "https://example.com/a"}, // not monitored
+			webcrawler.Event{Type: webcrawler.EventURLAlive, URL: "https://example.com/b", Monitored: true},
+			webcrawler.Event{Type: webcrawler.EventURLDead, URL: "https://example.com/c", Monitored: true},
+		)
+
+		if len(rc.requests) != 1 {
+			t.Fatalf("requests: got %d, want 1", len(rc.requests))
+		}
+		if got := rc.requests[0].Header.Get(EventHeader); got != "url_dead" {
+			t.Errorf("event header: got %q", got)
+		}
+	})
+}
+
+func TestPayload(t *testing.T) {
+	event := webcrawler.Event{
+		Type:       webcrawler.EventURLDead,
+		URL:        "https://example.com/gone",
+		StatusCode: http.StatusNotFound,
+	}
+
+	t.Run("Slack", func(t *testing.T) {
+		hook := &Webhook{Name: "slack", URL: "https://hooks.slack.com/x", Format: FormatSlack}
+		body, err := hook.payload(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg map[string]string
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if want := "*URL is dead*\nhttps://example.com/gone returned HTTP 404"; msg["text"] != want {
+			t.Errorf("text: got %q, want %q", msg["text"], want)
+		}
+	})
+
+	t.Run("Teams", func(t *testing.T) {
+		hook := &Webhook{Name: "teams", URL: "https://example.webhook.office.com/x", Format: FormatTeams}
+		body, err := hook.payload(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var card map[string]string
+		if err := json.Unmarshal(body, &card); err != nil {
+			t.Fatal(err)
+		}
+		if card["@type"] != "MessageCard" || card["title"] != "URL is dead" || card["themeColor"] == "" {
+			t.Errorf("unexpected card: %s", body)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		hook  Webhook
+		valid bool
+	}{
+		{hook: Webhook{Name: "a", URL: "http://localhost:9000/hook"}, valid: true},
+		{hook: Webhook{Name: "a", URL: "https://example.com", Format: FormatTeams, Events: []string{"run_failed"}}, valid: true},
+		{hook: Webhook{URL: "https://example.com"}},
+		{hook: Webhook{Name: "a", URL: "example.com/hook"}},
+		{hook: Webhook{Name: "a", URL: "https://example.com", Format: "xml"}},
+		{hook: Webhook{Name: "a", URL: "https://example.com", Events: []string{"page_saved"}}},
+		{hook: Webhook{Name: "a", URL: "https://example.com", MaxAttempts: -1}},
+	}
+
+	for i, test := range tests {
+		err := test.hook.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("test %d: got error %v, want valid %t", i, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package webcrawler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// xmlContentTypes are the media types of XML feeds and sitemaps
+var xmlContentTypes = []string{
+	"application/xml",
+	"text/xml",
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/rdf+xml",
+}
+
+// FeedExtractor finds the links of the items of RSS and RDF feeds
+// and the link hrefs of Atom feeds. Other XML pages have no links.
+type FeedExtractor struct{}
+
+// Name implements LinkExtractor
+func (*FeedExtractor) Name() string { return "feed" }
+
+// ContentTypes implements LinkExtractor
+func (*FeedExtractor) ContentTypes() []string { return xmlContentTypes }
+
+// Extract implements LinkExtractor
+func (*FeedExtractor) Extract(page *Page) ([]Link, error) {
+	var links []Link
+	err := walkXML(page.Body, func(root string, el xml.StartElement, text func() string) bool {
+		switch root {
+		case "rss", "RDF":
+			if el.Name.Local == "link" {
+				if href := page.Resolve(text()); href != "" {
+					links = append(links, Link{URL: href, Source: "rss[link]"})
+				}
+			}
+		case "feed":
+			if el.Name.Local != "link" {
+				break
+			}
+			rel := "alternate"
+			var href string
+			for _, attr := range el.Attr {
+				switch attr.Name.Local {
+				case "rel":
+					rel = attr.Value
+				case "href":
+					href = attr.Value
+				}
+			}
+			if followedRel(rel) != "" {
+				if href = page.Resolve(href); href != "" {
+					links = append(links, Link{URL: href, Source: "atom[link]"})
+				}
+			}
+		default:
+			return false
+		}
+		return true
+	})
+	return links, err
+}
+
+// SitemapExtractor finds the page URLs of sitemaps and
+// the sitemap URLs of sitemap indexes
+type SitemapExtractor struct{}
+
+// Name implements LinkExtractor
+func (*SitemapExtractor) Name() string { return "sitemap" }
+
+// ContentTypes implements LinkExtractor
+func (*SitemapExtractor) ContentTypes() []string { return xmlContentTypes }
+
+// Extract implements LinkExtractor
+func (*SitemapExtractor) Extract(page *Page) ([]Link, error) {
+	var links []Link
+	err := walkXML(page.Body, func(root string, el xml.StartElement, text func() string) bool {
+		if root != "urlset" && root != "sitemapindex" {
+			return false
+		}
+		if el.Name.Local == "loc" {
+			if href := page.Resolve(text()); href != "" {
+				links = append(links, Link{URL: href, Source: root + "[loc]"})
+			}
+		}
+		return true
+	})
+	return links, err
+}
+
+// walkXML calls visit with the local name of the root element of
+// the XML document body for each of its elements, until visit returns
+// false. text returns the character data of the element.
+func walkXML(body []byte, visit func(root string, el xml.StartElement, text func() string) bool) error {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.Strict = false
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+
+	var root string
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = el.Name.Local
+		}
+		text := func() string {
+			var s string
+			_ = dec.DecodeElement(&s, &el)
+			return strings.TrimSpace(s)
+		}
+		if !visit(root, el, text) {
+			return nil
+		}
+	}
+}